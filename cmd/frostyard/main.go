@@ -1,15 +1,17 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
-	"unicode"
 
 	"github.com/frostyard/site/internal/build"
+	"github.com/frostyard/site/internal/check"
+	"github.com/frostyard/site/internal/config"
+	"github.com/frostyard/site/internal/content"
 	"github.com/frostyard/site/internal/server"
 )
 
@@ -29,11 +31,23 @@ func main() {
 
 	switch cmd {
 	case "build":
+		fs := flag.NewFlagSet("build", flag.ExitOnError)
+		publish := addPublishFlags(fs)
+		jobs := fs.Int("jobs", 0, "pages parsed and rendered in parallel (0 = number of CPUs)")
+		strict := fs.Bool("strict", false, "fail if any internal link is broken")
+		fs.Parse(os.Args[2:])
+		siteCfg := loadSiteConfig(root)
 		cfg := build.Config{
 			ContentDir: filepath.Join(root, "content"),
 			StaticDir:  filepath.Join(root, "static"),
 			OutputDir:  filepath.Join(root, "dist"),
 			Root:       root,
+			Site:       siteCfg,
+			Drafts:     *publish.drafts,
+			Future:     *publish.future,
+			Expired:    *publish.expired,
+			Jobs:       *jobs,
+			Strict:     *strict,
 		}
 		if err := build.Build(cfg); err != nil {
 			fmt.Fprintf(os.Stderr, "Build failed: %v\n", err)
@@ -41,22 +55,55 @@ func main() {
 		}
 
 	case "serve":
+		fs := flag.NewFlagSet("serve", flag.ExitOnError)
+		memory := fs.Bool("memory", false, "render pages on request instead of writing dist/")
+		publish := addPublishFlags(fs)
+		fs.Parse(os.Args[2:])
 		addr := ":3000"
-		if len(os.Args) > 2 {
-			addr = os.Args[2]
+		if fs.NArg() > 0 {
+			addr = fs.Arg(0)
+			// Flags stop at the address; parse the ones after it too
+			fs.Parse(fs.Args()[1:])
 		}
+		if fs.NArg() > 0 {
+			fmt.Fprintf(os.Stderr, "Usage: frostyard serve [flags] [addr]\n")
+			os.Exit(1)
+		}
+		siteCfg := loadSiteConfig(root)
 		cfg := server.Config{
 			ContentDir: filepath.Join(root, "content"),
 			StaticDir:  filepath.Join(root, "static"),
 			OutputDir:  filepath.Join(root, "dist"),
 			Addr:       addr,
 			Root:       root,
+			Site:       siteCfg,
+			InMemory:   *memory,
+			Drafts:     *publish.drafts,
+			Future:     *publish.future,
+			Expired:    *publish.expired,
 		}
 		if err := server.Serve(cfg); err != nil {
 			fmt.Fprintf(os.Stderr, "Server failed: %v\n", err)
 			os.Exit(1)
 		}
 
+	case "check":
+		fs := flag.NewFlagSet("check", flag.ExitOnError)
+		external := fs.Bool("external", false, "also check external http(s) links")
+		fs.Parse(os.Args[2:])
+		siteCfg := loadSiteConfig(root)
+		if err := checkSite(root, siteCfg, *external); err != nil {
+			fmt.Fprintf(os.Stderr, "Check failed: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "lint":
+		siteCfg := loadSiteConfig(root)
+		if err := lintSite(root, siteCfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Lint failed: %v\n", err)
+			os.Exit(1)
+		}
+
 	case "new":
 		if len(os.Args) < 3 {
 			fmt.Fprintf(os.Stderr, "Usage: frostyard new <page|post> <args>\n")
@@ -97,14 +144,129 @@ func main() {
 	}
 }
 
+// lintSite checks the frontmatter of every page in content/ and prints each
+// problem with its file and line.
+func lintSite(root string, siteCfg config.Config) error {
+	cfg := build.Config{
+		ContentDir: filepath.Join(root, "content"),
+		Site:       siteCfg,
+	}
+	if err := build.Lint(cfg); err != nil {
+		return err
+	}
+	fmt.Println("Frontmatter OK")
+	return nil
+}
+
+// checkSite checks the internal links, and optionally the external links, of
+// the site built in dist/. Links are reported at their location in the
+// markdown sources where possible.
+func checkSite(root string, siteCfg config.Config, external bool) error {
+	contentDir := filepath.Join(root, "content")
+
+	// Include every page so links in drafts are traced to their sources too
+	site, err := content.LoadContent(contentDir, content.LoadOptions{
+		Taxonomies: siteCfg.Taxonomies,
+		Drafts:     true,
+		Future:     true,
+		Expired:    true,
+	})
+	if err != nil {
+		return err
+	}
+
+	checkCfg := check.Config{
+		OutputDir:  filepath.Join(root, "dist"),
+		ContentDir: contentDir,
+		Site:       site,
+	}
+
+	broken, err := check.Internal(checkCfg)
+	if err != nil {
+		return err
+	}
+	for _, link := range broken {
+		fmt.Println(link)
+	}
+	failed := len(broken) > 0
+	fmt.Println(count(len(broken), "broken internal link", "broken internal links"))
+
+	if external {
+		lc := siteCfg.LinkCheck
+		opts := check.ExternalOptions{
+			Timeout:  lc.Timeout,
+			Workers:  lc.Workers,
+			Rate:     lc.Rate,
+			Retries:  lc.Retries,
+			Allow:    lc.Allow,
+			CacheTTL: lc.CacheTTL,
+		}
+		if lc.CacheTTL > 0 {
+			opts.CacheFile = filepath.Join(root, ".cache", "linkcheck.json")
+		}
+
+		broken, err := check.External(checkCfg, opts)
+		if err != nil {
+			return err
+		}
+		for _, link := range broken {
+			fmt.Println(link)
+		}
+		failed = failed || len(broken) > 0
+		fmt.Println(count(len(broken), "broken external link", "broken external links"))
+	}
+
+	if failed {
+		return fmt.Errorf("broken links found")
+	}
+	return nil
+}
+
+// count formats n followed by the singular or plural noun, as in
+// "1 broken internal link" or "3 broken internal links".
+func count(n int, singular, plural string) string {
+	if n == 1 {
+		return "1 " + singular
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
+
+// publishFlags holds the flags that include unpublished content.
+type publishFlags struct {
+	drafts  *bool
+	future  *bool
+	expired *bool
+}
+
+// addPublishFlags registers --drafts, --future and --expired on fs.
+func addPublishFlags(fs *flag.FlagSet) publishFlags {
+	return publishFlags{
+		drafts:  fs.Bool("drafts", false, "include pages with draft: true"),
+		future:  fs.Bool("future", false, "include pages with a future publishDate or date"),
+		expired: fs.Bool("expired", false, "include pages whose expiryDate has passed"),
+	}
+}
+
 func printUsage() {
 	fmt.Println(`Usage: frostyard <command> [args]
 
 Commands:
   build              Build the site to dist/
-  serve              Start a local development server
+    --jobs N         Parse and render N pages in parallel (default: number of CPUs)
+    --strict         Fail if any internal link is broken
+  check              Check internal links and #fragments in dist/
+    --external       Also check external links (cached in .cache/linkcheck.json)
+  lint               Check the frontmatter of every page in content/
+  serve [flags] [addr]
+                     Start a local development server (default :3000)
+    --memory         Render pages from memory instead of writing dist/
   new page <path>    Create a new page (e.g., docs/guides/setup)
-  new post <title>   Create a new blog post`)
+  new post <title>   Create a new blog post
+
+Flags for build and serve:
+  --drafts           Include pages with draft: true
+  --future           Include pages with a future publishDate (or date)
+  --expired          Include pages whose expiryDate has passed`)
 }
 
 func findProjectRoot() (string, error) {
@@ -126,6 +288,16 @@ func findProjectRoot() (string, error) {
 	}
 }
 
+// loadSiteConfig loads frostyard.yaml from the project root, exiting on error.
+func loadSiteConfig(root string) config.Config {
+	cfg, err := config.Load(filepath.Join(root, config.FileName))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	return cfg
+}
+
 func scaffoldPage(root, relPath string) error {
 	// Ensure .md extension
 	if !strings.HasSuffix(relPath, ".md") {
@@ -163,7 +335,7 @@ title: "%s"
 }
 
 func scaffoldPost(root, title string) error {
-	slug := slugify(title)
+	slug := content.Slugify(title)
 	date := time.Now().Format("2006-01-02")
 	filename := fmt.Sprintf("%s-%s.md", date, slug)
 
@@ -195,17 +367,3 @@ tags: []
 	fmt.Printf("Created post: %s\n", fullPath)
 	return nil
 }
-
-// slugify converts a title to a URL-friendly slug.
-func slugify(s string) string {
-	s = strings.ToLower(s)
-	s = strings.Map(func(r rune) rune {
-		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == ' ' || r == '-' {
-			return r
-		}
-		return -1
-	}, s)
-	s = strings.TrimSpace(s)
-	s = regexp.MustCompile(`[\s-]+`).ReplaceAllString(s, "-")
-	return s
-}
@@ -0,0 +1,107 @@
+package build
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"github.com/frostyard/site/internal/config"
+)
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"feed"`
+	XMLNS    string      `xml:"xmlns,attr"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated,omitempty"` // Required, but left out rather than made up
+	Links    []atomLink  `xml:"link"`
+	Author   *atomPerson `xml:"author,omitempty"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Links      []atomLink     `xml:"link"`
+	Author     *atomPerson    `xml:"author,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Summary    string         `xml:"summary,omitempty"`
+	Content    *atomContent   `xml:"content,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// newAtomFeed builds an Atom 1.0 feed for f. The site name is the feed-level
+// author so that entries without an author remain valid.
+func newAtomFeed(siteCfg config.Config, f feed, items []feedItem) atomFeed {
+	out := atomFeed{
+		XMLNS:    "http://www.w3.org/2005/Atom",
+		ID:       siteCfg.BaseURL + f.Path,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Links: []atomLink{
+			{Href: siteCfg.BaseURL + f.Path + "atom.xml", Rel: "self", Type: "application/atom+xml"},
+			{Href: siteCfg.BaseURL + f.Path, Rel: "alternate", Type: "text/html"},
+		},
+		Author: &atomPerson{Name: siteCfg.Name},
+	}
+	if !f.Updated.IsZero() {
+		out.Updated = f.Updated.Format(time.RFC3339)
+	}
+
+	for _, item := range items {
+		entry := atomEntry{
+			ID:      item.ID,
+			Title:   item.Title,
+			Updated: f.Updated.Format(time.RFC3339),
+			Links:   []atomLink{{Href: item.URL, Rel: "alternate", Type: "text/html"}},
+			Summary: item.Description,
+		}
+		if !item.Date.IsZero() {
+			entry.Updated = item.Date.Format(time.RFC3339)
+			entry.Published = entry.Updated
+		}
+		if item.Author != "" {
+			entry.Author = &atomPerson{Name: item.Author}
+		}
+		for _, tag := range item.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		if item.Content != "" {
+			entry.Content = &atomContent{Type: "html", Value: item.Content}
+		}
+		out.Entries = append(out.Entries, entry)
+	}
+
+	return out
+}
+
+// marshalAtom returns feed as an XML document.
+func marshalAtom(feed atomFeed) ([]byte, error) {
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling Atom feed: %w", err)
+	}
+
+	return []byte(xml.Header + string(data)), nil
+}
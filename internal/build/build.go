@@ -1,16 +1,20 @@
 package build
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strings"
 
+	"github.com/frostyard/site/internal/check"
+	"github.com/frostyard/site/internal/config"
 	"github.com/frostyard/site/internal/content"
 	"github.com/frostyard/site/internal/render"
+	"github.com/frostyard/site/templates/components"
 	"github.com/frostyard/site/templates/pages"
+	_ "github.com/frostyard/site/templates/shortcodes" // Registers the content shortcodes
 )
 
 // Config holds the build configuration.
@@ -19,33 +23,67 @@ type Config struct {
 	StaticDir  string // Path to static assets directory (e.g., "static")
 	OutputDir  string // Path to output directory (e.g., "dist")
 	Root       string // Project root directory
+	Site       config.Config
+	Drafts     bool // Render pages with draft: true
+	Future     bool // Render pages with a publish date in the future
+	Expired    bool // Render pages whose expiry date has passed
+	Jobs       int  // Pages parsed and rendered in parallel; all CPUs if < 1
+	Strict     bool // Fail the build if any internal link is broken
+
+	// Markdown holds extra options for the markdown Renderer, such as
+	// goldmark extensions or shortcodes used by this build only
+	Markdown []content.RendererOption
 }
 
+// The external tools a build runs. Tests replace them with no-ops, since
+// the tools may be missing or need the network.
+var (
+	tailwind = runTailwind
+	pagefind = runPagefind
+)
+
 // Build orchestrates the full site build: load content, render HTML, copy static assets.
 func Build(cfg Config) error {
+	return NewBuilder(cfg).Build()
+}
+
+// Build runs a full build, replacing the output directory, and remembers the
+// loaded content so that later calls to Rebuild can be incremental.
+func (b *Builder) Build() error {
+	cfg := b.cfg
+
 	// Clean output directory
 	if err := os.RemoveAll(cfg.OutputDir); err != nil {
 		return fmt.Errorf("cleaning output directory: %w", err)
 	}
 
-	// Load content
-	site, err := content.LoadContent(cfg.ContentDir)
+	// Load content, rejecting invalid frontmatter
+	if err := Lint(cfg); err != nil {
+		return err
+	}
+	parsed, err := b.renderer.ParseDir(cfg.ContentDir, cfg.Jobs)
 	if err != nil {
 		return fmt.Errorf("loading content: %w", err)
 	}
+	if err := b.checkPublishedLinks(parsed); err != nil {
+		return fmt.Errorf("loading content: %w", err)
+	}
+	if err := checkTaxonomyPaths(b.cfg.Site, parsed); err != nil {
+		return err
+	}
+	b.pages = make(map[string]*content.Page, len(parsed))
+	for _, page := range parsed {
+		b.pages[b.sourceFile(page)] = page
+	}
+	b.failed = nil
+	site := b.newSite()
 
 	fmt.Printf("Loaded %d pages, %d blog posts\n", len(site.Pages), len(site.Posts))
 
-	// Render each page to HTML
-	for _, page := range site.Pages {
-		if err := renderPage(page, site, cfg.OutputDir); err != nil {
-			return fmt.Errorf("rendering %s: %w", page.Path, err)
-		}
-	}
-
-	// Render static templ pages (Home, Downloads, Community)
-	if err := renderStaticPages(cfg.OutputDir); err != nil {
-		return fmt.Errorf("rendering static pages: %w", err)
+	// Render markdown pages, the paginated blog index, taxonomy pages and
+	// static templ pages (Home, Downloads, Community) to HTML
+	if _, err := renderTasks(siteTasks(cfg.Site, site), cfg.OutputDir, cfg.Jobs, nil); err != nil {
+		return err
 	}
 
 	// Copy static assets
@@ -53,30 +91,114 @@ func Build(cfg Config) error {
 		return fmt.Errorf("copying static assets: %w", err)
 	}
 
-	// Run Tailwind CSS
-	if err := runTailwind(cfg.Root, cfg.OutputDir); err != nil {
+	// Run Tailwind CSS and write the code highlighting styles
+	if err := tailwind(cfg.Root, cfg.OutputDir); err != nil {
 		return fmt.Errorf("running tailwind: %w", err)
 	}
+	if err := writeHighlightCSS(cfg.Site, cfg.OutputDir); err != nil {
+		return fmt.Errorf("writing highlight CSS: %w", err)
+	}
 
 	// Generate sitemap
-	if err := generateSitemap(site, cfg.OutputDir); err != nil {
+	lm := b.fileLastmods()
+	if err := generateSitemap(cfg, site, lm); err != nil {
 		return fmt.Errorf("generating sitemap: %w", err)
 	}
 
-	// Generate RSS feed
-	if err := generateRSS(site, cfg.OutputDir); err != nil {
-		return fmt.Errorf("generating RSS feed: %w", err)
+	// Generate RSS, Atom and JSON feeds
+	if err := generateFeeds(cfg.Site, site, cfg.OutputDir, lm); err != nil {
+		return fmt.Errorf("generating feeds: %w", err)
 	}
 
 	// Run Pagefind to generate search index (optional)
-	if err := runPagefind(cfg.OutputDir); err != nil {
+	if err := pagefind(cfg.OutputDir); err != nil {
 		return fmt.Errorf("running pagefind: %w", err)
 	}
 
+	// Check internal links (strict mode)
+	if cfg.Strict {
+		if err := checkLinks(cfg, site); err != nil {
+			return err
+		}
+	}
+
+	b.commit(site)
+
 	fmt.Printf("Build complete: %s\n", cfg.OutputDir)
 	return nil
 }
 
+// checkLinks reports every broken internal link in the output directory and
+// fails if there are any.
+func checkLinks(cfg Config, site *content.Site) error {
+	broken, err := check.Internal(check.Config{
+		OutputDir:  cfg.OutputDir,
+		ContentDir: cfg.ContentDir,
+		Site:       site,
+	})
+	if err != nil {
+		return fmt.Errorf("checking links: %w", err)
+	}
+	for _, link := range broken {
+		fmt.Fprintln(os.Stderr, link)
+	}
+	if len(broken) > 0 {
+		return errors.New(count(len(broken), "broken internal link", "broken internal links"))
+	}
+	return nil
+}
+
+// LintOptions returns the frontmatter rules for a site: its configured
+// taxonomies and params are allowed as keys and icons must be ones
+// components.Icon draws.
+func LintOptions(siteCfg config.Config) content.LintOptions {
+	return content.LintOptions{
+		Taxonomies: siteCfg.Taxonomies,
+		Params:     siteCfg.Params,
+		Icons:      components.IconNames,
+	}
+}
+
+// Lint checks the frontmatter of the given markdown files, or of the whole
+// content directory if none are given, printing any problems to stderr. It is
+// shared by builds and `frostyard lint`, so both report problems alike.
+func Lint(cfg Config, paths ...string) error {
+	opts := LintOptions(cfg.Site)
+
+	var problems []content.Problem
+	if len(paths) == 0 {
+		found, err := content.LintDir(cfg.ContentDir, opts)
+		if err != nil {
+			return fmt.Errorf("linting content: %w", err)
+		}
+		problems = found
+	}
+	for _, path := range paths {
+		found, err := content.LintFile(cfg.ContentDir, path, opts)
+		if err != nil {
+			return fmt.Errorf("linting content: %w", err)
+		}
+		problems = append(problems, found...)
+	}
+
+	for _, problem := range problems {
+		fmt.Fprintln(os.Stderr, problem)
+	}
+	if len(problems) > 0 {
+		return errors.New(count(len(problems), "frontmatter problem", "frontmatter problems"))
+	}
+	return nil
+}
+
+// count formats n followed by the singular or plural noun, as in
+// "1 frontmatter problem" or "3 frontmatter problems".
+func count(n int, singular, plural string) string {
+	if n == 1 {
+		return "1 " + singular
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
+
 // runPagefind runs pagefind to generate the search index for the built site.
 // If pagefind is not available (neither in PATH nor via npx), it prints a note and skips.
 func runPagefind(outputDir string) error {
@@ -100,33 +222,47 @@ func runPagefind(outputDir string) error {
 	return cmd.Run()
 }
 
-// renderPage renders a single page to HTML and writes it to the output directory.
-func renderPage(page *content.Page, site *content.Site, outputDir string) error {
-	var html string
-	var err error
-
-	switch {
-	case strings.HasPrefix(page.Path, "/blog/posts/"):
-		html, err = render.RenderBlogPost(page)
-	case page.Path == "/":
-		html, err = render.RenderLandingPage(page.Content)
-	default:
-		html, err = render.RenderDocsPage(page, site)
+// blogPageHTML renders page n (starting at 1) of the blog post listing.
+func blogPageHTML(siteCfg config.Config, site *content.Site, n int) (string, error) {
+	var index *content.Page
+	for _, p := range site.Pages {
+		if p.Path == "/blog/" {
+			index = p
+			break
+		}
 	}
 
-	if err != nil {
-		return fmt.Errorf("rendering page: %w", err)
+	perPage := max(siteCfg.Paginate, 1)
+	total := blogPageCount(siteCfg, site)
+	start := (n - 1) * perPage
+	end := min(start+perPage, len(site.Posts))
+
+	pager := components.Pager{Current: n, Total: total}
+	if n > 1 {
+		pager.PrevPath = render.BlogPagePath(n - 1)
+	}
+	if n < total {
+		pager.NextPath = render.BlogPagePath(n + 1)
 	}
 
-	// Determine output file path: outputDir/page.Path/index.html
-	outPath := filepath.Join(outputDir, page.Path, "index.html")
+	return render.RenderBlogIndex(siteCfg, index, site.Posts[start:end], pager)
+}
+
+// blogPageCount returns the number of blog index pages; there is always at
+// least one, even without posts.
+func blogPageCount(siteCfg config.Config, site *content.Site) int {
+	perPage := max(siteCfg.Paginate, 1)
+	return max((len(site.Posts)+perPage-1)/perPage, 1)
+}
+
+// writeHTML writes html to outputDir/urlPath/index.html, creating directories as needed.
+func writeHTML(outputDir, urlPath, html string) error {
+	outPath := filepath.Join(outputDir, urlPath, "index.html")
 
-	// Create directory
 	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
 		return fmt.Errorf("creating directory for %s: %w", outPath, err)
 	}
 
-	// Write HTML file
 	if err := os.WriteFile(outPath, []byte(html), 0o644); err != nil {
 		return fmt.Errorf("writing %s: %w", outPath, err)
 	}
@@ -184,31 +320,50 @@ func runTailwind(root, outputDir string) error {
 	return cmd.Run()
 }
 
-// renderStaticPages renders the templ-only static pages (Home, Downloads, Community).
-func renderStaticPages(outputDir string) error {
-	staticPages := map[string]func() (string, error){
-		"/":            func() (string, error) { return render.RenderStaticPage(pages.Home()) },
-		"/downloads/":  func() (string, error) { return render.RenderStaticPage(pages.Downloads()) },
-		"/community/":  func() (string, error) { return render.RenderStaticPage(pages.Community()) },
+// writeHighlightCSS writes css/chroma.css, the styles of highlighted code in
+// the site's light and dark highlight styles.
+func writeHighlightCSS(siteCfg config.Config, outputDir string) error {
+	css, err := content.HighlightCSS(siteCfg.Highlight.Light, siteCfg.Highlight.Dark)
+	if err != nil {
+		return err
 	}
-
-	for path, renderFn := range staticPages {
-		html, err := renderFn()
-		if err != nil {
-			return fmt.Errorf("rendering static page %s: %w", path, err)
-		}
-
-		outPath := filepath.Join(outputDir, path, "index.html")
-		if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
-			return fmt.Errorf("creating directory for %s: %w", outPath, err)
-		}
-
-		if err := os.WriteFile(outPath, []byte(html), 0o644); err != nil {
-			return fmt.Errorf("writing %s: %w", outPath, err)
-		}
+	cssDir := filepath.Join(outputDir, "css")
+	if err := os.MkdirAll(cssDir, 0o755); err != nil {
+		return err
 	}
+	return os.WriteFile(filepath.Join(cssDir, "chroma.css"), []byte(css), 0o644)
+}
 
-	return nil
+// staticPage is a templ-only page with no markdown source.
+type staticPage struct {
+	Path   string // URL path
+	Source string // Template file relative to the project root, used for sitemap lastmod
+	Render func(siteCfg config.Config) (string, error)
+}
+
+// staticPages lists the templ-only pages (Home, Downloads, Community).
+var staticPages = []staticPage{
+	{
+		Path:   "/",
+		Source: "templates/pages/home.templ",
+		Render: func(siteCfg config.Config) (string, error) {
+			return render.RenderStaticPage(pages.Home(render.PageMeta(siteCfg, "", "", "/")))
+		},
+	},
+	{
+		Path:   "/downloads/",
+		Source: "templates/pages/downloads.templ",
+		Render: func(siteCfg config.Config) (string, error) {
+			return render.RenderStaticPage(pages.Downloads(render.PageMeta(siteCfg, "Downloads", "", "/downloads/")))
+		},
+	},
+	{
+		Path:   "/community/",
+		Source: "templates/pages/community.templ",
+		Render: func(siteCfg config.Config) (string, error) {
+			return render.RenderStaticPage(pages.Community(render.PageMeta(siteCfg, "Community", "", "/community/")))
+		},
+	},
 }
 
 // copyFile copies a single file from src to dst.
@@ -1,12 +1,23 @@
 package build
 
 import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/frostyard/site/internal/config"
 )
 
+func init() {
+	// Keep the tests from running the Tailwind CSS and Pagefind CLIs
+	tailwind = func(root, outputDir string) error { return nil }
+	pagefind = func(outputDir string) error { return nil }
+}
+
 func TestBuild(t *testing.T) {
 	// Create a temp directory for the test project
 	tmpDir := t.TempDir()
@@ -48,6 +59,7 @@ Hello world.
 		StaticDir:  filepath.Join(tmpDir, "static"), // does not exist, should be skipped
 		OutputDir:  outputDir,
 		Root:       tmpDir,
+		Site:       config.Default(),
 	}
 
 	if err := Build(cfg); err != nil {
@@ -70,4 +82,424 @@ Hello world.
 	if !strings.Contains(html, "Hello world.") {
 		t.Errorf("Expected HTML to contain 'Hello world.', got:\n%s", html)
 	}
+
+	// Verify the code highlighting styles were written for both themes
+	css, err := os.ReadFile(filepath.Join(outputDir, "css", "chroma.css"))
+	if err != nil {
+		t.Fatalf("Expected css/chroma.css to exist: %v", err)
+	}
+	if !strings.Contains(string(css), ":root:not(.dark) .chroma") || !strings.Contains(string(css), ".dark .chroma") {
+		t.Errorf("Expected light and dark rules in chroma.css, got:\n%s", css)
+	}
+}
+
+func TestBuildParallelDeterministic(t *testing.T) {
+	tmpDir := t.TempDir()
+	contentDir := filepath.Join(tmpDir, "content")
+	docsDir := filepath.Join(contentDir, "docs")
+	postsDir := filepath.Join(contentDir, "blog", "posts")
+	for _, dir := range []string{docsDir, postsDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(docsDir, "_index.md"), []byte("---\ntitle: \"Docs\"\n---\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 20 {
+		doc := fmt.Sprintf("---\ntitle: \"Page %d\"\nweight: %d\n---\n\nBody %d.\n", i, i%3, i)
+		if err := os.WriteFile(filepath.Join(docsDir, fmt.Sprintf("page-%02d.md", i)), []byte(doc), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		post := fmt.Sprintf("---\ntitle: \"Post %d\"\ndate: \"2026-01-%02d\"\ntags: [t%d]\n---\n\nPost %d.\n", i, i+1, i%4, i)
+		if err := os.WriteFile(filepath.Join(postsDir, fmt.Sprintf("post-%02d.md", i)), []byte(post), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Lastmods come from the file times, which both builds share, so the
+	// feeds and the sitemap must match too
+	snapshot := func(jobs int) map[string]string {
+		t.Helper()
+		outputDir := filepath.Join(tmpDir, fmt.Sprintf("dist-%d", jobs))
+		cfg := Config{
+			ContentDir: contentDir,
+			StaticDir:  filepath.Join(tmpDir, "static"),
+			OutputDir:  outputDir,
+			Root:       tmpDir,
+			Site:       config.Default(),
+			Jobs:       jobs,
+		}
+		if err := Build(cfg); err != nil {
+			t.Fatalf("Build with %d jobs failed: %v", jobs, err)
+		}
+		files := make(map[string]string)
+		err := filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			switch filepath.Ext(path) {
+			case ".html", ".xml", ".json":
+			default:
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			rel, _ := filepath.Rel(outputDir, path)
+			files[rel] = string(data)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return files
+	}
+
+	serial, concurrent := snapshot(1), snapshot(8)
+	if len(serial) != len(concurrent) {
+		t.Fatalf("serial build wrote %d files, parallel build %d", len(serial), len(concurrent))
+	}
+	for _, path := range []string{"sitemap.xml", "blog/feed.xml", "blog/atom.xml", "blog/feed.json"} {
+		if _, ok := serial[path]; !ok {
+			t.Errorf("expected %s in the build output", path)
+		}
+	}
+	for path, data := range serial {
+		if concurrent[path] != data {
+			t.Errorf("%s differs between serial and parallel builds", path)
+		}
+	}
+}
+
+func TestBuildDrafts(t *testing.T) {
+	tmpDir := t.TempDir()
+	docsDir := filepath.Join(tmpDir, "content", "docs")
+	if err := os.MkdirAll(docsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"_index.md": "---\ntitle: \"Docs\"\n---\n",
+		"wip.md":    "---\ntitle: \"Work in Progress\"\ndraft: true\n---\n\nNot done.\n",
+		"done.md":   "---\ntitle: \"Done\"\n---\n\nFinished.\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(docsDir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outputDir := filepath.Join(tmpDir, "dist")
+	cfg := Config{
+		ContentDir: filepath.Join(tmpDir, "content"),
+		StaticDir:  filepath.Join(tmpDir, "static"),
+		OutputDir:  outputDir,
+		Root:       tmpDir,
+		Site:       config.Default(),
+	}
+
+	if err := Build(cfg); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "docs", "wip", "index.html")); !os.IsNotExist(err) {
+		t.Errorf("draft should not be rendered without Drafts, got err=%v", err)
+	}
+
+	cfg.Drafts = true
+	if err := Build(cfg); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	wip, err := os.ReadFile(filepath.Join(outputDir, "docs", "wip", "index.html"))
+	if err != nil {
+		t.Fatalf("draft should be rendered with Drafts: %v", err)
+	}
+	if !strings.Contains(string(wip), "only appears in builds with") {
+		t.Errorf("draft page should show the draft banner")
+	}
+	done, err := os.ReadFile(filepath.Join(outputDir, "docs", "done", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(done), "only appears in builds with") {
+		t.Errorf("published page should not show the draft banner")
+	}
+}
+
+func TestBuildStrict(t *testing.T) {
+	tmpDir := t.TempDir()
+	docsDir := filepath.Join(tmpDir, "content", "docs")
+	if err := os.MkdirAll(docsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	page := "---\ntitle: \"Intro\"\n---\n\nSee [the guide](/docs/guide/).\n"
+	if err := os.WriteFile(filepath.Join(docsDir, "intro.md"), []byte(page), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Config{
+		ContentDir: filepath.Join(tmpDir, "content"),
+		StaticDir:  filepath.Join(tmpDir, "static"),
+		OutputDir:  filepath.Join(tmpDir, "dist"),
+		Root:       tmpDir,
+		Site:       config.Default(),
+	}
+	if err := Build(cfg); err != nil {
+		t.Fatalf("Build without Strict should ignore broken links: %v", err)
+	}
+
+	cfg.Strict = true
+	err := Build(cfg)
+	if err == nil || !strings.Contains(err.Error(), "broken internal link") {
+		t.Errorf("Build with Strict should fail on the broken link, got %v", err)
+	}
+}
+
+func TestBuildRejectsInvalidFrontmatter(t *testing.T) {
+	tmpDir := t.TempDir()
+	docsDir := filepath.Join(tmpDir, "content", "docs")
+	if err := os.MkdirAll(docsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	page := "---\ntitle: \"Intro\"\nicon: snowman\n---\n\nHello.\n"
+	if err := os.WriteFile(filepath.Join(docsDir, "intro.md"), []byte(page), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Build(Config{
+		ContentDir: filepath.Join(tmpDir, "content"),
+		StaticDir:  filepath.Join(tmpDir, "static"),
+		OutputDir:  filepath.Join(tmpDir, "dist"),
+		Root:       tmpDir,
+		Site:       config.Default(),
+	})
+	if err == nil || err.Error() != "1 frontmatter problem" {
+		t.Errorf("Build should fail on the unknown icon, got %v", err)
+	}
+}
+
+func TestBuildRejectsTaxonomyOverPages(t *testing.T) {
+	tmpDir := t.TempDir()
+	docsDir := filepath.Join(tmpDir, "content", "docs")
+	if err := os.MkdirAll(docsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(docsDir, "intro.md"), []byte("---\ntitle: \"Intro\"\n---\n\nHello.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"docs", "Downloads", "blog"} {
+		site := config.Default()
+		site.Taxonomies = append(site.Taxonomies, config.Taxonomy{Name: name, Title: name, Singular: name})
+		err := Build(Config{
+			ContentDir: filepath.Join(tmpDir, "content"),
+			StaticDir:  filepath.Join(tmpDir, "static"),
+			OutputDir:  filepath.Join(tmpDir, "dist"),
+			Root:       tmpDir,
+			Site:       site,
+		})
+		if err == nil || !strings.Contains(err.Error(), "rename the taxonomy") {
+			t.Errorf("Build with a taxonomy named %q: error = %v, want a path clash", name, err)
+		}
+	}
+}
+
+func TestBuildBlogIndex(t *testing.T) {
+	tmpDir := t.TempDir()
+	contentDir := filepath.Join(tmpDir, "content")
+	postsDir := filepath.Join(contentDir, "blog", "posts")
+	if err := os.MkdirAll(postsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		filepath.Join(contentDir, "blog", "_index.md"): "---\ntitle: \"Blog\"\n---\n\nBlog intro.\n",
+		filepath.Join(postsDir, "older.md"):            "---\ntitle: \"Older Post\"\ndate: \"2026-01-01\"\n---\n\nOlder.\n",
+		filepath.Join(postsDir, "newer.md"):            "---\ntitle: \"Newer Post\"\ndate: \"2026-02-01\"\n---\n\nNewer.\n",
+	}
+	for path, data := range files {
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outputDir := filepath.Join(tmpDir, "dist")
+	site := config.Default()
+	site.Paginate = 1
+	cfg := Config{
+		ContentDir: contentDir,
+		StaticDir:  filepath.Join(tmpDir, "static"),
+		OutputDir:  outputDir,
+		Root:       tmpDir,
+		Site:       site,
+	}
+
+	if err := Build(cfg); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	readHTML := func(rel ...string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(append([]string{outputDir}, rel...)...))
+		if err != nil {
+			t.Fatalf("reading output: %v", err)
+		}
+		return string(data)
+	}
+
+	first := readHTML("blog", "index.html")
+	if !strings.Contains(first, "Newer Post") || strings.Contains(first, "Older Post") {
+		t.Errorf("first blog page should list only the newest post")
+	}
+	if !strings.Contains(first, "Blog intro.") {
+		t.Errorf("first blog page should include the _index.md intro")
+	}
+	if !strings.Contains(first, `href="/blog/page/2/"`) {
+		t.Errorf("first blog page should link to /blog/page/2/")
+	}
+
+	second := readHTML("blog", "page", "2", "index.html")
+	if !strings.Contains(second, "Older Post") {
+		t.Errorf("second blog page should list the older post")
+	}
+	if strings.Contains(second, "Blog intro.") {
+		t.Errorf("second blog page should not repeat the intro")
+	}
+
+	post := readHTML("blog", "posts", "newer", "index.html")
+	if !strings.Contains(post, `href="/blog/posts/older/"`) {
+		t.Errorf("newer post should link to the previous (older) post")
+	}
+}
+
+func TestBuildTagPages(t *testing.T) {
+	tmpDir := t.TempDir()
+	postsDir := filepath.Join(tmpDir, "content", "blog", "posts")
+	if err := os.MkdirAll(postsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	post := "---\ntitle: \"Tagged Post\"\ndate: \"2026-01-01\"\ntags: [Release Notes]\n---\n\nBody.\n"
+	if err := os.WriteFile(filepath.Join(postsDir, "tagged.md"), []byte(post), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	outputDir := filepath.Join(tmpDir, "dist")
+	cfg := Config{
+		ContentDir: filepath.Join(tmpDir, "content"),
+		StaticDir:  filepath.Join(tmpDir, "static"),
+		OutputDir:  outputDir,
+		Root:       tmpDir,
+		Site:       config.Default(),
+	}
+	if err := Build(cfg); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	index, err := os.ReadFile(filepath.Join(outputDir, "tags", "index.html"))
+	if err != nil {
+		t.Fatalf("expected tags index: %v", err)
+	}
+	if !strings.Contains(string(index), `href="/tags/release-notes/"`) {
+		t.Errorf("tags index should link to /tags/release-notes/")
+	}
+
+	term, err := os.ReadFile(filepath.Join(outputDir, "tags", "release-notes", "index.html"))
+	if err != nil {
+		t.Fatalf("expected tag page: %v", err)
+	}
+	if !strings.Contains(string(term), "Tagged Post") {
+		t.Errorf("tag page should list the tagged post")
+	}
+
+	postHTML, err := os.ReadFile(filepath.Join(outputDir, "blog", "posts", "tagged", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(postHTML), `href="/tags/release-notes/"`) {
+		t.Errorf("blog post should link to its tag page")
+	}
+
+	if _, err := os.Stat(filepath.Join(outputDir, "tags", "release-notes", "feed.xml")); err != nil {
+		t.Errorf("expected per-tag feed: %v", err)
+	}
+}
+
+func TestBuildFeeds(t *testing.T) {
+	tmpDir := t.TempDir()
+	postsDir := filepath.Join(tmpDir, "content", "blog", "posts")
+	if err := os.MkdirAll(postsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	post := "---\ntitle: \"Snow Released\"\ndate: \"2026-03-01\"\nauthor: \"bjk\"\ndescription: \"Snow 1.0\"\ntags: [release]\n---\n\nSee the [docs](/docs/).\n"
+	if err := os.WriteFile(filepath.Join(postsDir, "snow.md"), []byte(post), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	outputDir := filepath.Join(tmpDir, "dist")
+	cfg := Config{
+		ContentDir: filepath.Join(tmpDir, "content"),
+		StaticDir:  filepath.Join(tmpDir, "static"),
+		OutputDir:  outputDir,
+		Root:       tmpDir,
+		Site:       config.Default(),
+	}
+	if err := Build(cfg); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	rss, err := os.ReadFile(filepath.Join(outputDir, "blog", "feed.xml"))
+	if err != nil {
+		t.Fatalf("expected RSS feed: %v", err)
+	}
+	var parsedRSS rssFeed
+	if err := xml.Unmarshal(rss, &parsedRSS); err != nil {
+		t.Fatalf("RSS feed is not valid XML: %v", err)
+	}
+	for _, want := range []string{
+		`<guid isPermaLink="true">https://frostyard.github.io/blog/posts/snow/</guid>`,
+		`<dc:creator>bjk</dc:creator>`,
+		`<category>release</category>`,
+		`<content:encoded><![CDATA[`,
+		`href="https://frostyard.github.io/docs/"`,
+	} {
+		if !strings.Contains(string(rss), want) {
+			t.Errorf("RSS feed missing %s", want)
+		}
+	}
+
+	atom, err := os.ReadFile(filepath.Join(outputDir, "blog", "atom.xml"))
+	if err != nil {
+		t.Fatalf("expected Atom feed: %v", err)
+	}
+	var parsedAtom atomFeed
+	if err := xml.Unmarshal(atom, &parsedAtom); err != nil {
+		t.Fatalf("Atom feed is not valid XML: %v", err)
+	}
+	if len(parsedAtom.Entries) != 1 || parsedAtom.Entries[0].Author == nil || parsedAtom.Entries[0].Author.Name != "bjk" {
+		t.Errorf("Atom entries = %+v, want one entry authored by bjk", parsedAtom.Entries)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, "blog", "feed.json"))
+	if err != nil {
+		t.Fatalf("expected JSON feed: %v", err)
+	}
+	var parsedJSON jsonFeed
+	if err := json.Unmarshal(data, &parsedJSON); err != nil {
+		t.Fatalf("JSON feed is not valid JSON: %v", err)
+	}
+	if parsedJSON.Version != "https://jsonfeed.org/version/1.1" || len(parsedJSON.Items) != 1 {
+		t.Errorf("JSON feed = %+v, want version 1.1 with one item", parsedJSON)
+	}
+	if parsedJSON.Items[0].ContentHTML == "" {
+		t.Error("JSON feed item has no content_html, want full content")
+	}
+
+	if _, err := os.Stat(filepath.Join(outputDir, "tags", "release", "atom.xml")); err != nil {
+		t.Errorf("expected per-term Atom feed: %v", err)
+	}
+
+	page, err := os.ReadFile(filepath.Join(outputDir, "blog", "posts", "snow", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(page), `<link rel="alternate" type="application/atom+xml"`) {
+		t.Error("page is missing Atom feed discovery link")
+	}
 }
@@ -0,0 +1,172 @@
+package build
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/frostyard/site/internal/config"
+	"github.com/frostyard/site/internal/content"
+)
+
+// feed describes one syndication feed. Every feed is written as RSS 2.0
+// (feed.xml), Atom 1.0 (atom.xml) and JSON Feed 1.1 (feed.json) into the
+// output directory of its listing page.
+type feed struct {
+	Title       string
+	Description string
+	Path        string // URL path of the listing page (e.g., "/blog/")
+	Pages       []*content.Page
+	Listing     *content.Page // The listing page's _index.md, if it has one
+	Updated     time.Time     // When the feed last changed, from feedUpdated; zero if unknown
+}
+
+// feedItem holds the format-independent data of one feed entry.
+type feedItem struct {
+	ID          string // Permanent absolute URL, used as GUID/id
+	Title       string
+	URL         string
+	Description string
+	Content     string // Full HTML content with absolute URLs; empty unless enabled
+	Author      string
+	Tags        []string
+	Date        time.Time // Zero if the page has no date
+}
+
+// feedFile is one file of a feed in one format, rendered on demand.
+type feedFile struct {
+	Path   string // URL path (e.g., "/blog/feed.xml")
+	Render func() ([]byte, error)
+}
+
+// generateFeeds writes every file of feedFiles into outputDir.
+func generateFeeds(siteCfg config.Config, site *content.Site, outputDir string, lm lastmods) error {
+	for _, file := range feedFiles(siteCfg, site, lm) {
+		data, err := file.Render()
+		if err != nil {
+			return err
+		}
+		if err := writeFeedFile(filepath.Join(outputDir, filepath.FromSlash(file.Path)), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// feedFiles returns the files of the blog feed and, if enabled, of one feed
+// per taxonomy term (e.g., tags/<slug>/) in every supported format.
+func feedFiles(siteCfg config.Config, site *content.Site, lm lastmods) []feedFile {
+	listings := make(map[string]*content.Page)
+	for _, page := range site.Pages {
+		listings[page.Path] = page
+	}
+
+	feeds := []feed{{
+		Title:       siteCfg.Feed.Title,
+		Description: siteCfg.Feed.Description,
+		Path:        "/blog/",
+		Pages:       site.Posts,
+		Listing:     listings["/blog/"],
+	}}
+
+	if siteCfg.Feed.PerTerm {
+		for _, tax := range site.Taxonomies {
+			for _, term := range tax.Terms {
+				feeds = append(feeds, feed{
+					Title:       fmt.Sprintf("%s: %s", siteCfg.Feed.Title, term.Name),
+					Description: fmt.Sprintf("Pages with %s %q", strings.ToLower(tax.Singular), term.Name),
+					Path:        term.Path,
+					Pages:       term.Pages,
+					Listing:     listings[term.Path],
+				})
+			}
+		}
+	}
+
+	files := make([]feedFile, 0, 3*len(feeds))
+	for _, f := range feeds {
+		prepare := func() (feed, []feedItem) {
+			items := feedItems(siteCfg, f.Pages)
+			lastmod := lm.newest(f.Pages)
+			if lastmod.IsZero() && f.Listing != nil {
+				// A feed without pages changes with its listing page
+				lastmod = lm.page(f.Listing)
+			}
+			dated := f
+			dated.Updated = feedUpdated(items, lastmod)
+			return dated, items
+		}
+		files = append(files,
+			feedFile{Path: f.Path + "feed.xml", Render: func() ([]byte, error) {
+				f, items := prepare()
+				return marshalRSS(newRSSFeed(siteCfg, f, items))
+			}},
+			feedFile{Path: f.Path + "atom.xml", Render: func() ([]byte, error) {
+				f, items := prepare()
+				return marshalAtom(newAtomFeed(siteCfg, f, items))
+			}},
+			feedFile{Path: f.Path + "feed.json", Render: func() ([]byte, error) {
+				f, items := prepare()
+				return marshalJSONFeed(newJSONFeed(siteCfg, f, items))
+			}},
+		)
+	}
+	return files
+}
+
+// feedItems converts pages into feed entries.
+func feedItems(siteCfg config.Config, pages []*content.Page) []feedItem {
+	items := make([]feedItem, 0, len(pages))
+	for _, page := range pages {
+		url := siteCfg.BaseURL + page.Path
+		item := feedItem{
+			ID:          url,
+			Title:       page.Title,
+			URL:         url,
+			Description: page.Description,
+			Author:      page.Author,
+			Tags:        page.Tags,
+			Date:        page.ParsedDate,
+		}
+		if siteCfg.Feed.FullContent {
+			item.Content = absoluteURLs(string(page.ContentWithFootnotes()), siteCfg.BaseURL, url)
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// feedUpdated returns the most recent item date, or lastmod, the newest
+// lastmod of the feed's pages (or of its listing page if it has none), if no
+// item is dated. Both only change with the content, so rebuilding an
+// unchanged site keeps the feed as it was. The result is zero if neither is
+// known.
+func feedUpdated(items []feedItem, lastmod time.Time) time.Time {
+	var latest time.Time
+	for _, item := range items {
+		if item.Date.After(latest) {
+			latest = item.Date
+		}
+	}
+	if latest.IsZero() {
+		return lastmod.UTC()
+	}
+	return latest
+}
+
+// absoluteURLs rewrites root-relative href and src attributes in rendered
+// HTML to absolute URLs, since feed readers display content off-site.
+// Fragment links (footnotes, heading anchors) point into pageURL, the page
+// the HTML belongs to. Protocol-relative URLs (//cdn.example.com/...) are
+// left alone.
+func absoluteURLs(html, baseURL, pageURL string) string {
+	r := strings.NewReplacer(
+		`href="//`, `href="//`,
+		`src="//`, `src="//`,
+		`href="/`, `href="`+baseURL+`/`,
+		`src="/`, `src="`+baseURL+`/`,
+		`href="#`, `href="`+pageURL+`#`,
+	)
+	return r.Replace(html)
+}
@@ -0,0 +1,65 @@
+package build
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frostyard/site/internal/config"
+	"github.com/frostyard/site/internal/content"
+)
+
+func TestAbsoluteURLs(t *testing.T) {
+	in := `<a href="/docs/">Docs</a> <img src="/img/a.png"> <script src="//cdn.example.com/x.js"></script> <a href="//example.org/">x</a> <a href="https://example.com/">y</a> <sup><a href="#fn:1">1</a></sup>`
+	want := `<a href="https://frostyard.github.io/docs/">Docs</a> <img src="https://frostyard.github.io/img/a.png"> <script src="//cdn.example.com/x.js"></script> <a href="//example.org/">x</a> <a href="https://example.com/">y</a> <sup><a href="https://frostyard.github.io/blog/post/#fn:1">1</a></sup>`
+	if got := absoluteURLs(in, "https://frostyard.github.io", "https://frostyard.github.io/blog/post/"); got != want {
+		t.Errorf("absoluteURLs =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFeedUpdated(t *testing.T) {
+	lastmod := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	dated := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	if got := feedUpdated([]feedItem{{}, {Date: dated}}, lastmod); !got.Equal(dated) {
+		t.Errorf("feedUpdated with dated items = %v, want %v", got, dated)
+	}
+	// Undated items, as in term feeds of docs pages, must not use the build time
+	if got := feedUpdated([]feedItem{{}, {}}, lastmod); !got.Equal(lastmod) {
+		t.Errorf("feedUpdated with undated items = %v, want %v", got, lastmod)
+	}
+}
+
+func TestFeedFilesEmptyBlog(t *testing.T) {
+	lastmod := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	index := &content.Page{Path: "/blog/", SourcePath: "content/blog/_index.md", ParsedLastmod: lastmod}
+
+	render := func(site *content.Site) map[string]string {
+		out := make(map[string]string)
+		for _, file := range feedFiles(config.Default(), site, lastmods{}) {
+			data, err := file.Render()
+			if err != nil {
+				t.Fatalf("rendering %s: %v", file.Path, err)
+			}
+			out[file.Path] = string(data)
+		}
+		return out
+	}
+
+	// A blog without posts is dated by its _index.md
+	files := render(&content.Site{Pages: []*content.Page{index}})
+	if rss := files["/blog/feed.xml"]; !strings.Contains(rss, "<lastBuildDate>Sat, 01 Mar 2025 12:00:00 +0000</lastBuildDate>") {
+		t.Errorf("feed.xml should take its date from _index.md:\n%s", rss)
+	}
+	if atom := files["/blog/atom.xml"]; !strings.Contains(atom, "<updated>2025-03-01T12:00:00Z</updated>") {
+		t.Errorf("atom.xml should take its date from _index.md:\n%s", atom)
+	}
+
+	// Without one, the date is left out rather than written as year 1
+	files = render(&content.Site{})
+	for _, path := range []string{"/blog/feed.xml", "/blog/atom.xml"} {
+		if strings.Contains(files[path], "0001") || strings.Contains(files[path], "lastBuildDate") || strings.Contains(files[path], "<updated>") {
+			t.Errorf("%s should have no date:\n%s", path, files[path])
+		}
+	}
+}
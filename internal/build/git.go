@@ -0,0 +1,51 @@
+package build
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// gitLastModified returns the committer date of the most recent commit that
+// touched each file in the git repository containing dir, keyed by absolute
+// path. It returns an empty map if git is unavailable or dir is not inside a
+// repository.
+func gitLastModified(dir string) map[string]time.Time {
+	dates := make(map[string]time.Time)
+
+	top, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return dates
+	}
+	topLevel := strings.TrimSpace(string(top))
+
+	// Each commit prints a "@@<date>" marker line followed by the files it
+	// touched. Commits are newest first, so the first date seen for a file wins.
+	out, err := exec.Command("git", "-C", topLevel, "-c", "core.quotepath=off",
+		"log", "--format=@@%cI", "--name-only", "--no-renames").Output()
+	if err != nil {
+		return dates
+	}
+
+	var current time.Time
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if date, ok := strings.CutPrefix(line, "@@"); ok {
+			current, _ = time.Parse(time.RFC3339, date)
+			continue
+		}
+		if line == "" || current.IsZero() {
+			continue
+		}
+		path := filepath.Join(topLevel, filepath.FromSlash(line))
+		if _, seen := dates[path]; !seen {
+			dates[path] = current
+		}
+	}
+
+	return dates
+}
@@ -0,0 +1,401 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frostyard/site/internal/content"
+)
+
+// Builder runs builds for one configuration and keeps the parsed content
+// between them, so that the dev server can rebuild incrementally.
+type Builder struct {
+	cfg      Config
+	renderer *content.Renderer // Renders the markdown of every page
+
+	pages   map[string]*content.Page // Every parsed page, including drafts, keyed by absolute source file
+	failed  []string                 // Changed paths of an update that failed, retried by the next one
+	site    *content.Site            // Site assembled by the last successful build
+	sidebar string                   // Signature of the section tree shown in the docs sidebar
+	outputs map[string]bool          // URL paths of every HTML page written by the last build
+
+	lastmodsOnce sync.Once
+	lastmods     lastmods // File lastmods, read from git once; see fileLastmods
+}
+
+// NewBuilder returns a Builder for cfg. Nothing is built until Build or Rebuild is called.
+func NewBuilder(cfg Config) *Builder {
+	opts := append([]content.RendererOption{
+		content.WithMarkdown(cfg.Site.Markdown),
+		content.WithDiagrams(cfg.Site.Diagrams),
+	}, cfg.Markdown...)
+	return &Builder{cfg: cfg, renderer: content.NewRenderer(opts...)}
+}
+
+// Rebuild updates the output for files that were created, modified or
+// removed since the last build. Markdown changes re-parse only those files
+// and re-render the affected pages: the changed pages themselves, every
+// sidebar page when the section tree changed, all blog posts and index pages
+// when a post changed, and taxonomy pages when terms changed. Changed static
+// files are copied individually. Any other change (templates, input.css) or
+// a Rebuild without a previous Build runs a full Build.
+func (b *Builder) Rebuild(changed []string) error {
+	if b.site == nil {
+		return b.Build()
+	}
+
+	start := time.Now()
+
+	var markdown, static []string
+	for _, path := range changed {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("resolving %s: %w", path, err)
+		}
+		switch {
+		case IsWithin(b.cfg.ContentDir, abs):
+			markdown = append(markdown, abs)
+		case IsWithin(b.cfg.StaticDir, abs):
+			static = append(static, abs)
+		default:
+			fmt.Printf("%s changed, running full build\n", path)
+			return b.Build()
+		}
+	}
+
+	for _, path := range static {
+		full, err := b.syncStatic(path)
+		if err != nil {
+			return err
+		}
+		if full {
+			return b.Build()
+		}
+	}
+
+	rendered, err := b.rebuildContent(markdown)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Rebuilt %d pages, %d static files in %s\n", rendered, len(static), time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// rebuildContent re-parses the changed markdown paths and re-renders the
+// pages that depend on them. It returns the number of pages rendered.
+func (b *Builder) rebuildContent(paths []string) (int, error) {
+	if len(paths) == 0 {
+		return 0, nil
+	}
+	cfg := b.cfg
+
+	before, after, err := b.updatePages(paths)
+	if err != nil {
+		return 0, err
+	}
+
+	// Old pages keep their derived fields (Terms) because NewSite only resets
+	// the pages it is given, so they can be inspected after the rebuild.
+	site := b.newSite()
+
+	changed := make(map[*content.Page]bool)
+	postsChanged, termsChanged := false, false
+	for _, page := range after {
+		changed[page] = true
+	}
+	for _, page := range append(before, after...) {
+		if strings.HasPrefix(page.Path, "/blog/") {
+			postsChanged = true
+		}
+		if len(page.Terms) > 0 {
+			termsChanged = true
+		}
+	}
+	sidebarChanged := sidebarSignature(site.Sections) != b.sidebar
+
+	rendered, err := renderTasks(siteTasks(cfg.Site, site), cfg.OutputDir, cfg.Jobs, func(task renderTask) bool {
+		switch task.Kind {
+		case pageTask:
+			isPost := strings.HasPrefix(task.Path, "/blog/posts/")
+			usesSidebar := !isPost && task.Path != "/"
+			return changed[task.Page] || (sidebarChanged && usesSidebar) || (postsChanged && isPost)
+		case blogTask:
+			return postsChanged
+		case taxonomyTask:
+			return termsChanged
+		default:
+			return false
+		}
+	})
+	if err != nil {
+		return rendered, err
+	}
+
+	if err := b.removeStaleOutputs(site); err != nil {
+		return rendered, err
+	}
+
+	lm := b.fileLastmods()
+	if err := generateSitemap(cfg, site, lm); err != nil {
+		return rendered, fmt.Errorf("generating sitemap: %w", err)
+	}
+
+	if postsChanged || termsChanged {
+		if err := generateFeeds(cfg.Site, site, cfg.OutputDir, lm); err != nil {
+			return rendered, fmt.Errorf("generating feeds: %w", err)
+		}
+	}
+
+	b.commit(site)
+	return rendered, nil
+}
+
+// updatePages re-parses the changed markdown paths into b.pages. Removed
+// files and directories drop every page below them. It returns the pages that
+// were replaced or removed and the pages that were parsed.
+//
+// b.pages only changes if every page lints, parses and links correctly.
+// Otherwise the paths are kept and updated again with the next change, so
+// that fixing a page by creating the page it links to also picks up the
+// page itself.
+func (b *Builder) updatePages(paths []string) (before, after []*content.Page, err error) {
+	cfg := b.cfg
+
+	if len(b.failed) > 0 {
+		paths = append(b.failed, paths...)
+		slices.Sort(paths)
+		paths = slices.Compact(paths)
+	}
+	b.failed = paths
+	pages := maps.Clone(b.pages)
+
+	for _, path := range paths {
+		for key, old := range pages {
+			if key == path || IsWithin(path, key) {
+				before = append(before, old)
+				delete(pages, key)
+			}
+		}
+
+		info, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+
+		if !info.IsDir() {
+			if !strings.HasSuffix(path, ".md") {
+				continue
+			}
+			if err := Lint(cfg, path); err != nil {
+				return nil, nil, err
+			}
+			page, err := b.renderer.LoadPage(cfg.ContentDir, path)
+			if err != nil {
+				return nil, nil, err
+			}
+			pages[path] = page
+			after = append(after, page)
+			continue
+		}
+
+		// A new directory: parse everything below it
+		err = filepath.Walk(path, func(p string, fi os.FileInfo, err error) error {
+			if err != nil || fi.IsDir() || !strings.HasSuffix(p, ".md") {
+				return err
+			}
+			if err := Lint(cfg, p); err != nil {
+				return err
+			}
+			page, err := b.renderer.LoadPage(cfg.ContentDir, p)
+			if err != nil {
+				return err
+			}
+			pages[p] = page
+			after = append(after, page)
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Check links of all pages, since a rename can break links to the old file
+	sorted := sortedPages(pages)
+	if err := content.CheckLinks(sorted); err != nil {
+		return nil, nil, err
+	}
+	if err := b.checkPublishedLinks(sorted); err != nil {
+		return nil, nil, err
+	}
+	if err := checkTaxonomyPaths(cfg.Site, sorted); err != nil {
+		return nil, nil, err
+	}
+
+	b.pages = pages
+	b.failed = nil
+	return before, after, nil
+}
+
+// syncStatic mirrors one changed path below the static directory into the
+// output directory. It reports whether a full build is needed instead, which
+// is the case when a removed path was a whole directory.
+func (b *Builder) syncStatic(path string) (bool, error) {
+	rel, err := filepath.Rel(b.cfg.StaticDir, path)
+	if err != nil {
+		return false, fmt.Errorf("computing relative path for %s: %w", path, err)
+	}
+	dst := filepath.Join(b.cfg.OutputDir, rel)
+
+	info, err := os.Stat(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if dstInfo, err := os.Stat(dst); err == nil && dstInfo.IsDir() {
+			return true, nil
+		}
+		if err := os.Remove(dst); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return false, fmt.Errorf("removing %s: %w", dst, err)
+		}
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("reading %s: %w", path, err)
+	case info.IsDir():
+		return false, copyDir(path, dst)
+	default:
+		return false, copyFile(path, dst)
+	}
+}
+
+// sortedPages returns the pages, keyed by source file, in source order.
+func sortedPages(byFile map[string]*content.Page) []*content.Page {
+	keys := make([]string, 0, len(byFile))
+	for key := range byFile {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pages := make([]*content.Page, 0, len(keys))
+	for _, key := range keys {
+		pages = append(pages, byFile[key])
+	}
+	return pages
+}
+
+// newSite assembles the site from the builder's parsed pages in source order.
+func (b *Builder) newSite() *content.Site {
+	return content.NewSite(sortedPages(b.pages), b.loadOptions())
+}
+
+// loadOptions returns the options that decide which pages are published.
+func (b *Builder) loadOptions() content.LoadOptions {
+	return content.LoadOptions{
+		Taxonomies: b.cfg.Site.Taxonomies,
+		Drafts:     b.cfg.Drafts,
+		Future:     b.cfg.Future,
+		Expired:    b.cfg.Expired,
+	}
+}
+
+// checkPublishedLinks fails if published pages link to drafts, and prints a
+// warning for each link to a future or expired page.
+func (b *Builder) checkPublishedLinks(pages []*content.Page) error {
+	warnings, err := content.CheckPublishedLinks(pages, b.loadOptions())
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	return err
+}
+
+// fileLastmods returns the lastmods of the builder's files. The git history
+// is walked on the first call only, so that rebuilds stay fast.
+func (b *Builder) fileLastmods() lastmods {
+	b.lastmodsOnce.Do(func() {
+		b.lastmods = newLastmods(b.cfg)
+	})
+	return b.lastmods
+}
+
+// commit records site as the result of a successful build.
+func (b *Builder) commit(site *content.Site) {
+	b.site = site
+	b.sidebar = sidebarSignature(site.Sections)
+	b.outputs = outputPaths(b.cfg, site)
+}
+
+// removeStaleOutputs deletes the HTML of pages that the previous build wrote
+// but site no longer generates (deleted pages, empty terms, fewer blog pages).
+func (b *Builder) removeStaleOutputs(site *content.Site) error {
+	current := outputPaths(b.cfg, site)
+	for path := range b.outputs {
+		if current[path] {
+			continue
+		}
+		outPath := filepath.Join(b.cfg.OutputDir, filepath.FromSlash(path), "index.html")
+		if err := os.Remove(outPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("removing %s: %w", outPath, err)
+		}
+	}
+	return nil
+}
+
+// sourceFile returns the absolute path of page's markdown file.
+func (b *Builder) sourceFile(page *content.Page) string {
+	path := filepath.Join(filepath.Dir(b.cfg.ContentDir), filepath.FromSlash(page.SourcePath))
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// outputPaths returns the URL path of every HTML page a build of site writes.
+func outputPaths(cfg Config, site *content.Site) map[string]bool {
+	paths := make(map[string]bool)
+	for _, task := range siteTasks(cfg.Site, site) {
+		paths[task.Path] = true
+	}
+	return paths
+}
+
+// sidebarSignature summarizes the parts of the section tree that the docs
+// sidebar displays; docs pages must be re-rendered when it changes.
+func sidebarSignature(sections []*content.Section) string {
+	var sb strings.Builder
+	var walk func(secs []*content.Section, depth int)
+	walk = func(secs []*content.Section, depth int) {
+		for _, sec := range secs {
+			fmt.Fprintf(&sb, "%d|%s|%s|%s\n", depth, sec.Title, sec.Path, sec.Icon)
+			for _, p := range sec.Pages {
+				fmt.Fprintf(&sb, "%d>%s|%s\n", depth, p.Title, p.Path)
+			}
+			walk(sec.Subsections, depth+1)
+		}
+	}
+	walk(sections, 0)
+	return sb.String()
+}
+
+// IsWithin reports whether path is dir itself or below it. Relative paths
+// are taken relative to the working directory, so either may be relative.
+func IsWithin(dir, path string) bool {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
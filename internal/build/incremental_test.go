@@ -0,0 +1,159 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/frostyard/site/internal/config"
+)
+
+func TestBuilderRebuild(t *testing.T) {
+	tmpDir := t.TempDir()
+	contentDir := filepath.Join(tmpDir, "content")
+	staticDir := filepath.Join(tmpDir, "static")
+	docsDir := filepath.Join(contentDir, "docs")
+	for _, dir := range []string{docsDir, staticDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write := func(path, data string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(rel ...string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(append([]string{tmpDir, "dist"}, rel...)...))
+		if err != nil {
+			t.Fatalf("reading output: %v", err)
+		}
+		return string(data)
+	}
+
+	intro := filepath.Join(docsDir, "intro.md")
+	guide := filepath.Join(docsDir, "guide.md")
+	asset := filepath.Join(staticDir, "robots.txt")
+	write(filepath.Join(docsDir, "_index.md"), "---\ntitle: \"Docs\"\n---\n")
+	write(intro, "---\ntitle: \"Introduction\"\nweight: 1\n---\n\nFirst draft.\n")
+	write(guide, "---\ntitle: \"Guide\"\nweight: 2\n---\n\nGuide body.\n")
+	write(asset, "User-agent: *\n")
+
+	b := NewBuilder(Config{
+		ContentDir: contentDir,
+		StaticDir:  staticDir,
+		OutputDir:  filepath.Join(tmpDir, "dist"),
+		Root:       tmpDir,
+		Site:       config.Default(),
+	})
+	if err := b.Build(); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	// Mark the guide output so we can tell whether it was re-rendered
+	guideOut := filepath.Join(tmpDir, "dist", "docs", "guide", "index.html")
+	write(guideOut, "untouched")
+
+	// A body-only edit re-renders just that page
+	write(intro, "---\ntitle: \"Introduction\"\nweight: 1\n---\n\nSecond draft.\n")
+	if err := b.Rebuild([]string{intro}); err != nil {
+		t.Fatalf("Rebuild failed: %v", err)
+	}
+	if html := read("docs", "intro", "index.html"); !strings.Contains(html, "Second draft.") {
+		t.Errorf("intro should be re-rendered with the new body")
+	}
+	if html := read("docs", "guide", "index.html"); html != "untouched" {
+		t.Errorf("guide should not be re-rendered for a body-only edit")
+	}
+
+	// A title change alters the sidebar, so every docs page is re-rendered
+	write(intro, "---\ntitle: \"Getting Started\"\nweight: 1\n---\n\nSecond draft.\n")
+	if err := b.Rebuild([]string{intro}); err != nil {
+		t.Fatalf("Rebuild failed: %v", err)
+	}
+	if html := read("docs", "guide", "index.html"); !strings.Contains(html, "Getting Started") {
+		t.Errorf("guide sidebar should show the new intro title")
+	}
+
+	// Deleting a page removes its output
+	if err := os.Remove(guide); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Rebuild([]string{guide}); err != nil {
+		t.Fatalf("Rebuild failed: %v", err)
+	}
+	if _, err := os.Stat(guideOut); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed, got err=%v", guideOut, err)
+	}
+	if html := read("docs", "intro", "index.html"); strings.Contains(html, `href="/docs/guide/"`) {
+		t.Errorf("intro sidebar should no longer link to the deleted guide")
+	}
+	if sitemap := read("sitemap.xml"); strings.Contains(sitemap, "/docs/guide/") {
+		t.Errorf("sitemap should no longer list the deleted guide")
+	}
+
+	// Static files are copied individually
+	write(asset, "User-agent: *\nDisallow: /drafts/\n")
+	if err := b.Rebuild([]string{asset}); err != nil {
+		t.Fatalf("Rebuild failed: %v", err)
+	}
+	if txt := read("robots.txt"); !strings.Contains(txt, "Disallow") {
+		t.Errorf("robots.txt should be copied after it changes")
+	}
+}
+
+func TestBuilderRebuildAfterError(t *testing.T) {
+	tmpDir := t.TempDir()
+	docsDir := filepath.Join(tmpDir, "content", "docs")
+	if err := os.MkdirAll(docsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(path, data string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	intro := filepath.Join(docsDir, "intro.md")
+	setup := filepath.Join(docsDir, "setup.md")
+	write(filepath.Join(docsDir, "_index.md"), "---\ntitle: \"Docs\"\n---\n")
+	write(intro, "---\ntitle: \"Introduction\"\n---\n\nFirst draft.\n")
+
+	b := NewBuilder(Config{
+		ContentDir: filepath.Join(tmpDir, "content"),
+		StaticDir:  filepath.Join(tmpDir, "static"),
+		OutputDir:  filepath.Join(tmpDir, "dist"),
+		Root:       tmpDir,
+		Site:       config.Default(),
+	})
+	if err := b.Build(); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	// Linking to a page that does not exist yet fails and changes nothing
+	write(intro, "---\ntitle: \"Introduction\"\n---\n\nSee [setup](setup.md).\n")
+	if err := b.Rebuild([]string{intro}); err == nil {
+		t.Fatal("Rebuild with a broken link succeeded")
+	}
+	if got := b.site.Pages; len(got) != 2 {
+		t.Fatalf("site has %d pages after a failed rebuild, want 2", len(got))
+	}
+
+	// Creating the missing page also brings in the pending change to intro
+	write(setup, "---\ntitle: \"Setup\"\n---\n\nSteps.\n")
+	if err := b.Rebuild([]string{setup}); err != nil {
+		t.Fatalf("Rebuild failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(tmpDir, "dist", "docs", "intro", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `<a href="/docs/setup/">setup</a>`) {
+		t.Errorf("intro was not re-rendered with its link:\n%s", data)
+	}
+}
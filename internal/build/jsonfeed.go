@@ -0,0 +1,83 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/frostyard/site/internal/config"
+)
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description,omitempty"`
+	Authors     []jsonAuthor   `json:"authors,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedItem struct {
+	ID            string       `json:"id"`
+	URL           string       `json:"url"`
+	Title         string       `json:"title"`
+	Summary       string       `json:"summary,omitempty"`
+	ContentHTML   string       `json:"content_html,omitempty"`
+	ContentText   string       `json:"content_text,omitempty"`
+	DatePublished string       `json:"date_published,omitempty"`
+	Authors       []jsonAuthor `json:"authors,omitempty"`
+	Tags          []string     `json:"tags,omitempty"`
+}
+
+// newJSONFeed builds a JSON Feed 1.1 document for f.
+func newJSONFeed(siteCfg config.Config, f feed, items []feedItem) jsonFeed {
+	out := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: siteCfg.BaseURL + f.Path,
+		FeedURL:     siteCfg.BaseURL + f.Path + "feed.json",
+		Description: f.Description,
+		Authors:     []jsonAuthor{{Name: siteCfg.Name}},
+		Items:       []jsonFeedItem{},
+	}
+
+	for _, item := range items {
+		ji := jsonFeedItem{
+			ID:      item.ID,
+			URL:     item.URL,
+			Title:   item.Title,
+			Summary: item.Description,
+			Tags:    item.Tags,
+		}
+		// Items must carry content_html or content_text
+		if item.Content != "" {
+			ji.ContentHTML = item.Content
+		} else {
+			ji.ContentText = item.Description
+		}
+		if !item.Date.IsZero() {
+			ji.DatePublished = item.Date.Format(time.RFC3339)
+		}
+		if item.Author != "" {
+			ji.Authors = []jsonAuthor{{Name: item.Author}}
+		}
+		out.Items = append(out.Items, ji)
+	}
+
+	return out
+}
+
+// marshalJSONFeed returns feed as an indented JSON document.
+func marshalJSONFeed(feed jsonFeed) ([]byte, error) {
+	data, err := json.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling JSON feed: %w", err)
+	}
+
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,120 @@
+package build
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/frostyard/site/internal/content"
+)
+
+// Load parses the content and assembles the site without writing any output,
+// so that pages can be rendered on request with RenderPath.
+func (b *Builder) Load() error {
+	if err := Lint(b.cfg); err != nil {
+		return err
+	}
+	parsed, err := b.renderer.ParseDir(b.cfg.ContentDir, b.cfg.Jobs)
+	if err != nil {
+		return fmt.Errorf("loading content: %w", err)
+	}
+	if err := b.checkPublishedLinks(parsed); err != nil {
+		return fmt.Errorf("loading content: %w", err)
+	}
+	if err := checkTaxonomyPaths(b.cfg.Site, parsed); err != nil {
+		return err
+	}
+	b.pages = make(map[string]*content.Page, len(parsed))
+	for _, page := range parsed {
+		b.pages[b.sourceFile(page)] = page
+	}
+	b.failed = nil
+	b.commit(b.newSite())
+
+	fmt.Printf("Loaded %d pages, %d blog posts\n", len(b.site.Pages), len(b.site.Posts))
+	return nil
+}
+
+// Reload updates the loaded site for markdown files that were created,
+// modified or removed, re-parsing only those files. Changes outside the
+// content directory are ignored, since nothing derived from them is kept in
+// memory. Like Rebuild, it falls back to Load if nothing was loaded yet.
+func (b *Builder) Reload(changed []string) error {
+	if b.site == nil {
+		return b.Load()
+	}
+
+	var markdown []string
+	for _, path := range changed {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("resolving %s: %w", path, err)
+		}
+		if IsWithin(b.cfg.ContentDir, abs) {
+			markdown = append(markdown, abs)
+		}
+	}
+	if len(markdown) == 0 {
+		return nil
+	}
+
+	if _, _, err := b.updatePages(markdown); err != nil {
+		return err
+	}
+	b.commit(b.newSite())
+	return nil
+}
+
+// RenderPath renders the HTML page at urlPath (e.g., "/docs/intro/") from the
+// loaded site. It reports false if no page is generated at that path. Where
+// several pages share a path, the one a full build writes wins.
+func (b *Builder) RenderPath(urlPath string) (string, bool, error) {
+	if b.site == nil {
+		return "", false, nil
+	}
+
+	tasks := siteTasks(b.cfg.Site, b.site)
+	for i := len(tasks) - 1; i >= 0; i-- {
+		if tasks[i].Path == urlPath {
+			html, err := tasks[i].Render()
+			return html, true, err
+		}
+	}
+
+	return "", false, nil
+}
+
+// RenderFile renders the feed or sitemap file at urlPath (e.g.,
+// "/blog/feed.xml" or "/sitemap.xml") from the loaded site. It reports false
+// if no such file is generated.
+func (b *Builder) RenderFile(urlPath string) ([]byte, bool, error) {
+	if b.site == nil {
+		return nil, false, nil
+	}
+
+	lm := b.fileLastmods()
+	if urlPath == "/sitemap.xml" {
+		data, err := sitemapXML(b.cfg, b.site, lm)
+		return data, true, err
+	}
+	for _, file := range feedFiles(b.cfg.Site, b.site, lm) {
+		if file.Path == urlPath {
+			data, err := file.Render()
+			return data, true, err
+		}
+	}
+
+	return nil, false, nil
+}
+
+// GenerateCSS runs Tailwind CSS and writes the code highlighting styles into
+// the output directory without rendering any pages. Serving from memory uses
+// it with a scratch output directory.
+func (b *Builder) GenerateCSS() error {
+	if err := tailwind(b.cfg.Root, b.cfg.OutputDir); err != nil {
+		return fmt.Errorf("running tailwind: %w", err)
+	}
+	if err := writeHighlightCSS(b.cfg.Site, b.cfg.OutputDir); err != nil {
+		return fmt.Errorf("writing highlight CSS: %w", err)
+	}
+	return nil
+}
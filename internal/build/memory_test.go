@@ -0,0 +1,108 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/frostyard/site/internal/config"
+)
+
+func TestBuilderRenderPath(t *testing.T) {
+	tmpDir := t.TempDir()
+	contentDir := filepath.Join(tmpDir, "content")
+	docsDir := filepath.Join(contentDir, "docs")
+	postsDir := filepath.Join(contentDir, "blog", "posts")
+	for _, dir := range []string{docsDir, postsDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write := func(path, data string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	intro := filepath.Join(docsDir, "intro.md")
+	write(filepath.Join(docsDir, "_index.md"), "---\ntitle: \"Docs\"\n---\n")
+	write(intro, "---\ntitle: \"Introduction\"\n---\n\nFirst draft.\n")
+	write(filepath.Join(contentDir, "blog", "_index.md"), "---\ntitle: \"Blog\"\n---\n")
+	write(filepath.Join(postsDir, "hello.md"), "---\ntitle: \"Hello\"\ndate: \"2026-01-01\"\ntags: [\"release\"]\n---\n\nHi.\n")
+
+	outputDir := filepath.Join(tmpDir, "dist")
+	b := NewBuilder(Config{
+		ContentDir: contentDir,
+		StaticDir:  filepath.Join(tmpDir, "static"),
+		OutputDir:  outputDir,
+		Root:       tmpDir,
+		Site:       config.Default(),
+	})
+	if err := b.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	renderPath := func(urlPath string) string {
+		t.Helper()
+		html, ok, err := b.RenderPath(urlPath)
+		if err != nil {
+			t.Fatalf("RenderPath(%q) failed: %v", urlPath, err)
+		}
+		if !ok {
+			t.Fatalf("RenderPath(%q) found no page", urlPath)
+		}
+		return html
+	}
+
+	if html := renderPath("/docs/intro/"); !strings.Contains(html, "First draft.") {
+		t.Errorf("docs page should contain its body")
+	}
+	if html := renderPath("/blog/"); !strings.Contains(html, "Hello") {
+		t.Errorf("blog index should list the post")
+	}
+	if html := renderPath("/tags/release/"); !strings.Contains(html, "Hello") {
+		t.Errorf("term page should list the post")
+	}
+	if html := renderPath("/downloads/"); !strings.Contains(html, "Downloads") {
+		t.Errorf("static page should render")
+	}
+	if _, ok, err := b.RenderPath("/missing/"); ok || err != nil {
+		t.Errorf("RenderPath(/missing/) = %v, %v; want not found", ok, err)
+	}
+
+	write(intro, "---\ntitle: \"Introduction\"\n---\n\nSecond draft.\n")
+	if err := b.Reload([]string{intro}); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if html := renderPath("/docs/intro/"); !strings.Contains(html, "Second draft.") {
+		t.Errorf("docs page should reflect the reloaded body")
+	}
+
+	// Feeds and the sitemap, which every page links to, render on request too
+	for urlPath, want := range map[string]string{
+		"/blog/feed.xml":         "<title>Hello</title>",
+		"/blog/atom.xml":         "<title>Hello</title>",
+		"/blog/feed.json":        `"title": "Hello"`,
+		"/tags/release/feed.xml": "<title>Hello</title>",
+		"/sitemap.xml":           "<loc>https://frostyard.github.io/docs/intro/</loc>",
+	} {
+		data, ok, err := b.RenderFile(urlPath)
+		if err != nil || !ok {
+			t.Errorf("RenderFile(%q) = %v, %v", urlPath, ok, err)
+			continue
+		}
+		if !strings.Contains(string(data), want) {
+			t.Errorf("RenderFile(%q) missing %q:\n%s", urlPath, want, data)
+		}
+	}
+	if _, ok, err := b.RenderFile("/missing.xml"); ok || err != nil {
+		t.Errorf("RenderFile(/missing.xml) = %v, %v; want not found", ok, err)
+	}
+
+	if _, err := os.Stat(outputDir); !os.IsNotExist(err) {
+		t.Errorf("Load and RenderPath should not write %s, got err=%v", outputDir, err)
+	}
+}
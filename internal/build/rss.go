@@ -6,66 +6,118 @@ import (
 	"os"
 	"path/filepath"
 
-	"github.com/frostyard/site/internal/content"
+	"github.com/frostyard/site/internal/config"
 )
 
 type rssFeed struct {
-	XMLName xml.Name   `xml:"rss"`
-	Version string     `xml:"version,attr"`
-	Channel rssChannel `xml:"channel"`
+	XMLName      xml.Name   `xml:"rss"`
+	Version      string     `xml:"version,attr"`
+	XMLNSAtom    string     `xml:"xmlns:atom,attr"`
+	XMLNSContent string     `xml:"xmlns:content,attr"`
+	XMLNSDC      string     `xml:"xmlns:dc,attr"`
+	Channel      rssChannel `xml:"channel"`
 }
 
 type rssChannel struct {
-	Title       string    `xml:"title"`
-	Link        string    `xml:"link"`
-	Description string    `xml:"description"`
-	Items       []rssItem `xml:"item"`
+	Title         string      `xml:"title"`
+	Link          string      `xml:"link"`
+	Description   string      `xml:"description"`
+	AtomLink      rssAtomLink `xml:"atom:link"`
+	LastBuildDate string      `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem   `xml:"item"`
+}
+
+// rssAtomLink is the self-reference recommended for RSS feeds.
+type rssAtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
 }
 
 type rssItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+	Creator     string   `xml:"dc:creator,omitempty"`
+	Categories  []string `xml:"category"`
+	Content     *cdata   `xml:"content:encoded,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// cdata wraps text that is emitted as a CDATA section.
+type cdata struct {
+	Value string `xml:",cdata"`
 }
 
-func generateRSS(site *content.Site, outputDir string) error {
-	feed := rssFeed{
-		Version: "2.0",
+const rssDateFormat = "Mon, 02 Jan 2006 15:04:05 -0700"
+
+// newRSSFeed builds an RSS 2.0 channel for f.
+func newRSSFeed(siteCfg config.Config, f feed, items []feedItem) rssFeed {
+	out := rssFeed{
+		Version:      "2.0",
+		XMLNSAtom:    "http://www.w3.org/2005/Atom",
+		XMLNSContent: "http://purl.org/rss/1.0/modules/content/",
+		XMLNSDC:      "http://purl.org/dc/elements/1.1/",
 		Channel: rssChannel{
-			Title:       "Frostyard Blog",
-			Link:        siteURL + "/blog/",
-			Description: "Updates from the Frostyard ecosystem",
+			Title:       f.Title,
+			Link:        siteCfg.BaseURL + f.Path,
+			Description: f.Description,
+			AtomLink: rssAtomLink{
+				Href: siteCfg.BaseURL + f.Path + "feed.xml",
+				Rel:  "self",
+				Type: "application/rss+xml",
+			},
 		},
 	}
+	if !f.Updated.IsZero() {
+		out.Channel.LastBuildDate = f.Updated.Format(rssDateFormat)
+	}
 
-	for _, post := range site.Posts {
-		item := rssItem{
-			Title:       post.Title,
-			Link:        siteURL + post.Path,
-			Description: post.Description,
+	for _, item := range items {
+		ri := rssItem{
+			Title:       item.Title,
+			Link:        item.URL,
+			Description: item.Description,
+			GUID:        rssGUID{IsPermaLink: true, Value: item.ID},
+			Creator:     item.Author,
+			Categories:  item.Tags,
+		}
+		if !item.Date.IsZero() {
+			ri.PubDate = item.Date.Format(rssDateFormat)
 		}
-		if !post.ParsedDate.IsZero() {
-			item.PubDate = post.ParsedDate.Format("Mon, 02 Jan 2006 15:04:05 -0700")
+		if item.Content != "" {
+			ri.Content = &cdata{Value: item.Content}
 		}
-		feed.Channel.Items = append(feed.Channel.Items, item)
+		out.Channel.Items = append(out.Channel.Items, ri)
 	}
 
+	return out
+}
+
+// marshalRSS returns feed as an XML document.
+func marshalRSS(feed rssFeed) ([]byte, error) {
 	data, err := xml.MarshalIndent(feed, "", "  ")
 	if err != nil {
-		return fmt.Errorf("marshaling RSS feed: %w", err)
+		return nil, fmt.Errorf("marshaling RSS feed: %w", err)
 	}
 
-	out := xml.Header + string(data)
+	return []byte(xml.Header + string(data)), nil
+}
 
-	blogDir := filepath.Join(outputDir, "blog")
-	if err := os.MkdirAll(blogDir, 0o755); err != nil {
-		return fmt.Errorf("creating blog directory: %w", err)
+// writeFeedFile writes data to outPath, creating directories as needed.
+func writeFeedFile(outPath string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+		return fmt.Errorf("creating directory for %s: %w", outPath, err)
 	}
 
-	outPath := filepath.Join(blogDir, "feed.xml")
-	if err := os.WriteFile(outPath, []byte(out), 0o644); err != nil {
-		return fmt.Errorf("writing RSS feed: %w", err)
+	if err := os.WriteFile(outPath, data, 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", outPath, err)
 	}
 
 	return nil
@@ -5,13 +5,15 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/frostyard/site/internal/content"
+	"github.com/frostyard/site/internal/render"
 )
 
-const siteURL = "https://frostyard.github.io"
-
 type urlSet struct {
 	XMLName xml.Name `xml:"urlset"`
 	Xmlns   string   `xml:"xmlns,attr"`
@@ -19,36 +21,184 @@ type urlSet struct {
 }
 
 type urlEntry struct {
-	XMLName xml.Name `xml:"url"`
-	Loc     string   `xml:"loc"`
-	LastMod string   `xml:"lastmod"`
+	XMLName    xml.Name `xml:"url"`
+	Loc        string   `xml:"loc"`
+	LastMod    string   `xml:"lastmod,omitempty"`
+	ChangeFreq string   `xml:"changefreq,omitempty"`
+	Priority   string   `xml:"priority,omitempty"`
+}
+
+// sitemapEntry accumulates the sitemap data for one URL path.
+type sitemapEntry struct {
+	LastMod     time.Time
+	ChangeFreq  string
+	Priority    float64
+	PrioritySet bool
+}
+
+// lastmods tells when source files last changed. A file's lastmod is the
+// date of its last git commit, falling back to the frontmatter `lastmod` of
+// pages and then the file's modification time.
+type lastmods struct {
+	contentBase string               // Directory that page source paths are relative to
+	git         map[string]time.Time // From gitLastModified
+}
+
+// newLastmods returns the lastmods of the files of cfg.
+func newLastmods(cfg Config) lastmods {
+	return lastmods{
+		contentBase: filepath.Dir(cfg.ContentDir),
+		git:         gitLastModified(cfg.Root),
+	}
+}
+
+// file returns the lastmod of the file at path, or fallback if git does not
+// know it.
+func (l lastmods) file(path string, fallback time.Time) time.Time {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fallback
+	}
+	// git reports symlink-free paths (e.g., /private/tmp on macOS)
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	if t, ok := l.git[abs]; ok {
+		return t
+	}
+	return fallback
 }
 
-func generateSitemap(site *content.Site, outputDir string) error {
-	today := time.Now().Format("2006-01-02")
+// page returns the lastmod of page's source file.
+func (l lastmods) page(page *content.Page) time.Time {
+	fallback := page.ParsedLastmod
+	if fallback.IsZero() {
+		fallback = page.ModTime
+	}
+	return l.file(filepath.Join(l.contentBase, filepath.FromSlash(page.SourcePath)), fallback)
+}
+
+// newest returns the latest lastmod of pages.
+func (l lastmods) newest(pages []*content.Page) time.Time {
+	var latest time.Time
+	for _, p := range pages {
+		if t := l.page(p); t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
+
+// generateSitemap writes sitemapXML to sitemap.xml in the output directory.
+func generateSitemap(cfg Config, site *content.Site, lm lastmods) error {
+	data, err := sitemapXML(cfg, site, lm)
+	if err != nil {
+		return err
+	}
+	outPath := filepath.Join(cfg.OutputDir, "sitemap.xml")
+	if err := os.WriteFile(outPath, data, 0o644); err != nil {
+		return fmt.Errorf("writing sitemap: %w", err)
+	}
+	return nil
+}
+
+// sitemapXML returns the sitemap covering every generated page: markdown
+// pages (unless they set `sitemap: false`), blog index pages, taxonomy pages
+// and static templ pages. Listing pages take the lastmod of the newest page
+// they list.
+func sitemapXML(cfg Config, site *content.Site, lm lastmods) ([]byte, error) {
+	entries := make(map[string]*sitemapEntry)
+	excluded := make(map[string]bool)
+	touch := func(path string, lastmod time.Time) *sitemapEntry {
+		entry, ok := entries[path]
+		if !ok {
+			entry = &sitemapEntry{}
+			entries[path] = entry
+		}
+		if lastmod.After(entry.LastMod) {
+			entry.LastMod = lastmod
+		}
+		return entry
+	}
+
+	for _, sp := range staticPages {
+		source := filepath.Join(cfg.Root, filepath.FromSlash(sp.Source))
+		var mtime time.Time
+		if info, err := os.Stat(source); err == nil {
+			mtime = info.ModTime()
+		}
+		touch(sp.Path, lm.file(source, mtime))
+	}
+
+	for _, page := range site.Pages {
+		if page.Sitemap.Exclude {
+			excluded[page.Path] = true
+			continue
+		}
+		entry := touch(page.Path, lm.page(page))
+		entry.ChangeFreq = page.Sitemap.ChangeFreq
+		entry.Priority = page.Sitemap.Priority
+		entry.PrioritySet = page.Sitemap.PrioritySet
+	}
+
+	perPage := max(cfg.Site.Paginate, 1)
+	for n := 1; n <= blogPageCount(cfg.Site, site); n++ {
+		start := (n - 1) * perPage
+		end := min(start+perPage, len(site.Posts))
+		touch(render.BlogPagePath(n), lm.newest(site.Posts[start:end]))
+	}
+
+	for _, tax := range site.Taxonomies {
+		if len(tax.Terms) == 0 {
+			continue
+		}
+		var all []*content.Page
+		for _, term := range tax.Terms {
+			touch(term.Path, lm.newest(term.Pages))
+			all = append(all, term.Pages...)
+		}
+		touch(tax.Path, lm.newest(all))
+	}
 
 	set := urlSet{
 		Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
 	}
 
-	for _, page := range site.Pages {
-		set.URLs = append(set.URLs, urlEntry{
-			Loc:     siteURL + page.Path,
-			LastMod: today,
-		})
+	for path, entry := range entries {
+		if excluded[path] {
+			continue
+		}
+		url := urlEntry{
+			Loc:        cfg.Site.BaseURL + path,
+			ChangeFreq: entry.ChangeFreq,
+		}
+		if !entry.LastMod.IsZero() {
+			url.LastMod = entry.LastMod.UTC().Format("2006-01-02")
+		}
+		if entry.PrioritySet {
+			url.Priority = formatPriority(entry.Priority)
+		}
+		set.URLs = append(set.URLs, url)
 	}
 
+	sort.Slice(set.URLs, func(i, j int) bool {
+		return set.URLs[i].Loc < set.URLs[j].Loc
+	})
+
 	data, err := xml.MarshalIndent(set, "", "  ")
 	if err != nil {
-		return fmt.Errorf("marshaling sitemap: %w", err)
+		return nil, fmt.Errorf("marshaling sitemap: %w", err)
 	}
 
-	out := xml.Header + string(data)
-	outPath := filepath.Join(outputDir, "sitemap.xml")
+	return []byte(xml.Header + string(data)), nil
+}
 
-	if err := os.WriteFile(outPath, []byte(out), 0o644); err != nil {
-		return fmt.Errorf("writing sitemap: %w", err)
+// formatPriority writes p with as many decimals as it has, but at least one,
+// so 0.85 stays 0.85 and 1 becomes 1.0.
+func formatPriority(p float64) string {
+	s := strconv.FormatFloat(p, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
 	}
-
-	return nil
+	return s
 }
@@ -0,0 +1,124 @@
+package build
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frostyard/site/internal/config"
+)
+
+func TestGenerateSitemap(t *testing.T) {
+	tmpDir := t.TempDir()
+	contentDir := filepath.Join(tmpDir, "content")
+
+	files := map[string]string{
+		"docs/_index.md":       "---\ntitle: \"Docs\"\nlastmod: \"2025-06-01\"\nsitemap:\n  priority: 0.8\n  changefreq: weekly\n---\n",
+		"docs/hidden.md":       "---\ntitle: \"Hidden\"\nsitemap: false\n---\n",
+		"docs/archive.md":      "---\ntitle: \"Archive\"\nsitemap:\n  priority: 0.0\n---\n",
+		"docs/guide.md":        "---\ntitle: \"Guide\"\nsitemap:\n  priority: 0.85\n---\n",
+		"blog/posts/hello.md":  "---\ntitle: \"Hello\"\ndate: \"2026-01-15\"\nlastmod: \"2026-01-20\"\ntags: [news]\n---\n",
+		"blog/posts/second.md": "---\ntitle: \"Second\"\ndate: \"2026-02-01\"\n---\n",
+	}
+	for rel, data := range files {
+		path := filepath.Join(contentDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outputDir := filepath.Join(tmpDir, "dist")
+	cfg := Config{
+		ContentDir: contentDir,
+		StaticDir:  filepath.Join(tmpDir, "static"),
+		OutputDir:  outputDir,
+		Root:       tmpDir,
+		Site:       config.Default(),
+	}
+	cfg.Site.Paginate = 1
+	if err := Build(cfg); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, "sitemap.xml"))
+	if err != nil {
+		t.Fatalf("expected sitemap.xml: %v", err)
+	}
+	sitemap := string(data)
+
+	for _, path := range []string{"/", "/downloads/", "/community/", "/blog/", "/blog/page/2/", "/tags/", "/tags/news/", "/docs/"} {
+		if !strings.Contains(sitemap, "<loc>https://frostyard.github.io"+path+"</loc>") {
+			t.Errorf("sitemap missing %s", path)
+		}
+	}
+	if strings.Contains(sitemap, "/docs/hidden/") {
+		t.Error("sitemap contains page with sitemap: false")
+	}
+
+	docsEntry := `<loc>https://frostyard.github.io/docs/</loc>
+    <lastmod>2025-06-01</lastmod>
+    <changefreq>weekly</changefreq>
+    <priority>0.8</priority>`
+	if !strings.Contains(sitemap, docsEntry) {
+		t.Errorf("sitemap /docs/ entry should use frontmatter lastmod, changefreq and priority, got:\n%s", sitemap)
+	}
+
+	// Priorities keep the precision they were written with
+	if !strings.Contains(sitemap, "<priority>0.85</priority>") {
+		t.Errorf("sitemap /docs/guide/ entry should keep priority 0.85, got:\n%s", sitemap)
+	}
+	// An explicit priority of 0.0 is kept, not treated as unset
+	if !strings.Contains(sitemap, "<priority>0.0</priority>") {
+		t.Errorf("sitemap /docs/archive/ entry should keep priority 0.0, got:\n%s", sitemap)
+	}
+
+	// The tag page is as fresh as the newest page it lists
+	tagEntry := "<loc>https://frostyard.github.io/tags/news/</loc>\n    <lastmod>2026-01-20</lastmod>"
+	if !strings.Contains(sitemap, tagEntry) {
+		t.Errorf("sitemap /tags/news/ entry should use the tagged post's lastmod, got:\n%s", sitemap)
+	}
+}
+
+func TestGitLastModified(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "page.md"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+			"GIT_COMMITTER_DATE=2025-04-03T10:00:00Z", "GIT_AUTHOR_DATE=2025-04-03T10:00:00Z",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("add", "page.md")
+	git("commit", "-q", "-m", "add page")
+
+	dates := gitLastModified(dir)
+
+	path, err := filepath.EvalSymlinks(filepath.Join(dir, "page.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2025, 4, 3, 10, 0, 0, 0, time.UTC)
+	if got, ok := dates[path]; !ok || !got.Equal(want) {
+		t.Errorf("gitLastModified()[%q] = %v, %v; want %v", path, got, ok, want)
+	}
+}
@@ -0,0 +1,168 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/frostyard/site/internal/config"
+	"github.com/frostyard/site/internal/content"
+	"github.com/frostyard/site/internal/parallel"
+	"github.com/frostyard/site/internal/render"
+)
+
+// taskKind identifies what a renderTask renders.
+type taskKind int
+
+const (
+	pageTask     taskKind = iota // A markdown page
+	blogTask                     // A blog index page
+	taxonomyTask                 // A taxonomy index or term listing page
+	staticTask                   // A static templ page
+)
+
+// renderTask renders one HTML page of the site.
+type renderTask struct {
+	Kind   taskKind
+	Path   string        // URL path the page is written to
+	Page   *content.Page // Source page of a pageTask, nil otherwise
+	Render func() (string, error)
+}
+
+// siteTasks lists every HTML page of site: markdown pages, blog index pages,
+// taxonomy pages, then static templ pages. When several tasks share a path,
+// the last one wins.
+func siteTasks(siteCfg config.Config, site *content.Site) []renderTask {
+	var tasks []renderTask
+
+	for _, page := range site.Pages {
+		// The blog index is rendered separately with its post listing
+		if page.Path == "/blog/" {
+			continue
+		}
+		tasks = append(tasks, renderTask{
+			Kind: pageTask,
+			Path: page.Path,
+			Page: page,
+			Render: func() (string, error) {
+				return pageHTML(siteCfg, page, site)
+			},
+		})
+	}
+
+	for n := 1; n <= blogPageCount(siteCfg, site); n++ {
+		tasks = append(tasks, renderTask{
+			Kind: blogTask,
+			Path: render.BlogPagePath(n),
+			Render: func() (string, error) {
+				return blogPageHTML(siteCfg, site, n)
+			},
+		})
+	}
+
+	// Taxonomies without any terms are skipped
+	for _, tax := range site.Taxonomies {
+		if len(tax.Terms) == 0 {
+			continue
+		}
+		tasks = append(tasks, renderTask{
+			Kind: taxonomyTask,
+			Path: tax.Path,
+			Render: func() (string, error) {
+				return render.RenderTaxonomy(siteCfg, tax)
+			},
+		})
+		for _, term := range tax.Terms {
+			tasks = append(tasks, renderTask{
+				Kind: taxonomyTask,
+				Path: term.Path,
+				Render: func() (string, error) {
+					return render.RenderTerm(siteCfg, tax, term)
+				},
+			})
+		}
+	}
+
+	for _, sp := range staticPages {
+		tasks = append(tasks, renderTask{
+			Kind: staticTask,
+			Path: sp.Path,
+			Render: func() (string, error) {
+				return sp.Render(siteCfg)
+			},
+		})
+	}
+
+	return tasks
+}
+
+// checkTaxonomyPaths reports a taxonomy whose pages, below /<name>/, would
+// replace markdown pages, the blog index or static pages in the output.
+func checkTaxonomyPaths(siteCfg config.Config, pages []*content.Page) error {
+	type owner struct{ path, source string }
+	owners := []owner{{render.BlogPagePath(1), "the blog index"}}
+	for _, page := range pages {
+		owners = append(owners, owner{page.Path, page.SourcePath})
+	}
+	for _, sp := range staticPages {
+		owners = append(owners, owner{sp.Path, sp.Source})
+	}
+
+	for _, tax := range siteCfg.Taxonomies {
+		root := "/" + content.Slugify(tax.Name) + "/"
+		for _, o := range owners {
+			if strings.HasPrefix(o.path, root) {
+				return fmt.Errorf("taxonomy %q: its pages below %s would replace %s (%s); rename the taxonomy", tax.Name, root, o.path, o.source)
+			}
+		}
+	}
+	return nil
+}
+
+// renderTasks renders the tasks selected by include (all of them if include
+// is nil) on up to jobs goroutines and writes them to outputDir. A path is
+// always written by its last task, even if only an earlier task at that path
+// was selected, so the output matches a full build. It returns the number of
+// pages written; errors from all failed tasks are joined in task order.
+func renderTasks(tasks []renderTask, outputDir string, jobs int, include func(renderTask) bool) (int, error) {
+	last := make(map[string]int)
+	selected := make(map[string]bool)
+	for i, task := range tasks {
+		last[task.Path] = i
+		if include == nil || include(task) {
+			selected[task.Path] = true
+		}
+	}
+
+	var todo []renderTask
+	for i, task := range tasks {
+		if last[task.Path] == i && selected[task.Path] {
+			todo = append(todo, task)
+		}
+	}
+
+	errs := make([]error, len(todo))
+	parallel.Run(len(todo), jobs, func(i int) {
+		task := todo[i]
+		html, err := task.Render()
+		if err != nil {
+			errs[i] = fmt.Errorf("rendering %s: %w", task.Path, err)
+			return
+		}
+		errs[i] = writeHTML(outputDir, task.Path, html)
+	})
+
+	return len(todo), errors.Join(errs...)
+}
+
+// pageHTML renders a markdown page with the layout for its location.
+func pageHTML(siteCfg config.Config, page *content.Page, site *content.Site) (string, error) {
+	switch {
+	case strings.HasPrefix(page.Path, "/blog/posts/"):
+		return render.RenderBlogPost(siteCfg, page)
+	case page.Path == "/":
+		return render.RenderLandingPage(siteCfg, page.ContentWithFootnotes())
+	default:
+		return render.RenderDocsPage(siteCfg, page, site)
+	}
+}
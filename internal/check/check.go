@@ -0,0 +1,218 @@
+// Package check validates the links in a rendered site.
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/frostyard/site/internal/content"
+	"golang.org/x/net/html"
+)
+
+// Config describes the rendered site to check.
+type Config struct {
+	OutputDir  string        // Rendered site (e.g., "dist")
+	ContentDir string        // Markdown sources, used to locate links in reports
+	Site       *content.Site // Pages of the rendered site; if nil, links are reported in the HTML
+}
+
+// BrokenLink is a link whose target does not exist.
+type BrokenLink struct {
+	Source string // Markdown source of the page, or the HTML file if the link is not in it (e.g., "content/docs/status.md")
+	Line   int    // 1-based line in Source
+	Page   string // URL path of the page containing the link
+	Href   string // Link as written
+	Reason string // Why the link is broken
+}
+
+func (l BrokenLink) String() string {
+	return fmt.Sprintf("%s:%d: %s (%s)", l.Source, l.Line, l.Href, l.Reason)
+}
+
+// htmlPage is one rendered HTML file and the links and anchors in it.
+type htmlPage struct {
+	File  string // Path relative to the output directory's parent (e.g., "dist/docs/index.html")
+	Path  string // URL path (e.g., "/docs/")
+	IDs   map[string]bool
+	Links []link
+}
+
+// link is an <a href> found in a rendered page.
+type link struct {
+	Href string
+	Line int // 1-based line in the HTML file
+}
+
+// Internal checks every internal <a href> in the HTML files below
+// cfg.OutputDir: the target page must exist and, if the link has a
+// #fragment, the target page must contain an element with that id (such as
+// a heading). Links with a scheme (https:, mailto:, ...) are not checked.
+// Broken links are returned sorted by source file and line.
+func Internal(cfg Config) ([]BrokenLink, error) {
+	pages, files, err := loadOutput(cfg.OutputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	byPath := make(map[string]*htmlPage, len(pages))
+	for _, p := range pages {
+		byPath[p.Path] = p
+	}
+
+	sources := newSourceIndex(cfg)
+
+	var broken []BrokenLink
+	for _, p := range pages {
+		for _, l := range p.Links {
+			reason := checkInternal(byPath, files, p, l.Href)
+			if reason == "" {
+				continue
+			}
+			bl := BrokenLink{
+				Source: p.File,
+				Line:   l.Line,
+				Page:   p.Path,
+				Href:   l.Href,
+				Reason: reason,
+			}
+			if source, line, ok := sources.locate(p.Path, l.Href); ok {
+				bl.Source, bl.Line = source, line
+			}
+			broken = append(broken, bl)
+		}
+	}
+
+	sortBroken(broken)
+
+	return broken, nil
+}
+
+// sortBroken sorts broken links by source file and line.
+func sortBroken(broken []BrokenLink) {
+	sort.SliceStable(broken, func(i, j int) bool {
+		if broken[i].Source != broken[j].Source {
+			return broken[i].Source < broken[j].Source
+		}
+		return broken[i].Line < broken[j].Line
+	})
+}
+
+// checkInternal returns why href on page p is broken, or "" if it is fine
+// or not an internal link. files holds the URL paths of all output files.
+func checkInternal(byPath map[string]*htmlPage, files map[string]bool, p *htmlPage, href string) string {
+	u, err := url.Parse(href)
+	if err != nil {
+		return "malformed URL"
+	}
+	if u.Scheme != "" || u.Host != "" {
+		return ""
+	}
+
+	base := &url.URL{Path: p.Path}
+	target := base.ResolveReference(u).Path
+
+	tp, ok := byPath[target]
+	if !ok {
+		// A directory without the trailing slash is redirected to its index
+		tp, ok = byPath[target+"/"]
+	}
+	if !ok {
+		// Other files (images, feeds) only need to exist
+		if u.Fragment == "" && files[target] {
+			return ""
+		}
+		return "page not found"
+	}
+
+	if u.Fragment != "" && !tp.IDs[u.Fragment] {
+		return fmt.Sprintf("no #%s on %s", u.Fragment, tp.Path)
+	}
+	return ""
+}
+
+// loadOutput parses every .html file below outputDir. It also returns the
+// URL paths of all files, HTML or not.
+func loadOutput(outputDir string) ([]*htmlPage, map[string]bool, error) {
+	if _, err := os.Stat(outputDir); err != nil {
+		return nil, nil, fmt.Errorf("reading output directory (run a build first): %w", err)
+	}
+
+	var pages []*htmlPage
+	files := make(map[string]bool)
+	err := filepath.Walk(outputDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(outputDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		files["/"+rel] = true
+
+		if !strings.HasSuffix(rel, ".html") {
+			return nil
+		}
+
+		urlPath := "/" + rel
+		if path.Base(rel) == "index.html" {
+			urlPath = strings.TrimSuffix(urlPath, "index.html")
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", p, err)
+		}
+
+		page := parseHTML(data)
+		page.File = filepath.ToSlash(filepath.Join(filepath.Base(outputDir), filepath.FromSlash(rel)))
+		page.Path = urlPath
+		pages = append(pages, page)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("walking output directory: %w", err)
+	}
+
+	return pages, files, nil
+}
+
+// parseHTML collects the element ids and <a href> links of an HTML document.
+func parseHTML(data []byte) *htmlPage {
+	page := &htmlPage{IDs: make(map[string]bool)}
+	z := html.NewTokenizer(bytes.NewReader(data))
+	line := 1
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			// io.EOF, or malformed HTML that cannot be tokenized further
+			return page
+		}
+
+		raw := z.Raw()
+		if tt == html.StartTagToken || tt == html.SelfClosingTagToken {
+			name, hasAttr := z.TagName()
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				switch {
+				case string(key) == "id":
+					page.IDs[string(val)] = true
+				case string(key) == "name" && string(name) == "a":
+					page.IDs[string(val)] = true
+				case string(key) == "href" && string(name) == "a":
+					page.Links = append(page.Links, link{Href: string(val), Line: line})
+				}
+			}
+		}
+		line += bytes.Count(raw, []byte("\n"))
+	}
+}
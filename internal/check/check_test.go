@@ -0,0 +1,80 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/frostyard/site/internal/content"
+)
+
+func writeFile(t *testing.T, dir, relPath, data string) {
+	t.Helper()
+	path := filepath.Join(dir, relPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInternal(t *testing.T) {
+	tmp := t.TempDir()
+
+	writeFile(t, tmp, "content/docs/intro.md", `---
+title: "Intro"
+---
+
+See [setup](/docs/setup/#install) and [usage](/docs/setup/#usage).
+
+Also [missing](/docs/missing/) and [relative](../setup/).
+
+Twice: [missing](/docs/missing/).
+`)
+
+	writeFile(t, tmp, "dist/docs/intro/index.html", `<html><body>
+<nav><a href="/nowhere/">Nav</a><a href="https://example.com/">Ext</a><a href="mailto:x@example.com">Mail</a></nav>
+<p><a href="/docs/setup/#install">setup</a> <a href="/docs/setup/#usage">usage</a></p>
+<p><a href="/docs/missing/">missing</a> <a href="../setup/">relative</a></p>
+<p><a href="/docs/missing/">missing</a> <a href="/img/logo.png">logo</a> <a href="/docs/setup">no slash</a> <a href="#top">top</a></p>
+</body></html>`)
+	writeFile(t, tmp, "dist/docs/setup/index.html", `<html><body><h2 id="install">Install</h2></body></html>`)
+	writeFile(t, tmp, "dist/img/logo.png", "png")
+
+	site := &content.Site{Pages: []*content.Page{
+		{Path: "/docs/intro/", SourcePath: "content/docs/intro.md"},
+	}}
+
+	broken, err := Internal(Config{
+		OutputDir:  filepath.Join(tmp, "dist"),
+		ContentDir: filepath.Join(tmp, "content"),
+		Site:       site,
+	})
+	if err != nil {
+		t.Fatalf("Internal returned error: %v", err)
+	}
+
+	want := []BrokenLink{
+		{Source: "content/docs/intro.md", Line: 5, Page: "/docs/intro/", Href: "/docs/setup/#usage", Reason: "no #usage on /docs/setup/"},
+		{Source: "content/docs/intro.md", Line: 7, Page: "/docs/intro/", Href: "/docs/missing/", Reason: "page not found"},
+		{Source: "content/docs/intro.md", Line: 9, Page: "/docs/intro/", Href: "/docs/missing/", Reason: "page not found"},
+		{Source: "dist/docs/intro/index.html", Line: 2, Page: "/docs/intro/", Href: "/nowhere/", Reason: "page not found"},
+		{Source: "dist/docs/intro/index.html", Line: 5, Page: "/docs/intro/", Href: "#top", Reason: "no #top on /docs/intro/"},
+	}
+	if len(broken) != len(want) {
+		t.Fatalf("got %d broken links, want %d:\n%v", len(broken), len(want), broken)
+	}
+	for i := range want {
+		if broken[i] != want[i] {
+			t.Errorf("broken[%d] = %+v, want %+v", i, broken[i], want[i])
+		}
+	}
+}
+
+func TestInternalMissingOutput(t *testing.T) {
+	_, err := Internal(Config{OutputDir: filepath.Join(t.TempDir(), "dist")})
+	if err == nil {
+		t.Fatal("Internal should fail when the output directory does not exist")
+	}
+}
@@ -0,0 +1,295 @@
+package check
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frostyard/site/internal/parallel"
+)
+
+// ExternalOptions configures External.
+type ExternalOptions struct {
+	Client        *http.Client  // HTTP client; nil uses one with Timeout
+	Timeout       time.Duration // Per-request timeout of the default client
+	Workers       int           // Concurrent requests; all CPUs if < 1
+	Rate          float64       // Maximum requests per second across all workers; 0 means unlimited
+	Retries       int           // Retries after a network error, 429 or 5xx response
+	Backoff       time.Duration // Wait before the first retry, doubled for each further one; defaults to 1s
+	MaxRetryAfter time.Duration // Longest Retry-After waited for, defaulting to 60s; longer ones report the URL as rate limited
+	Allow         []string      // URL prefixes that are never checked
+	CacheFile     string        // JSON file of earlier results; empty disables the cache
+	CacheTTL      time.Duration // How long cached results of working URLs are reused
+}
+
+// cacheEntry is the cached result of checking one URL.
+type cacheEntry struct {
+	Status  int       `json:"status"`
+	Checked time.Time `json:"checked"`
+}
+
+// External checks every http and https <a href> in the HTML files below
+// cfg.OutputDir. Each distinct URL (without its fragment) is requested once
+// with HEAD, falling back to GET if the server rejects HEAD; responses with
+// a status below 400 are fine. Broken links are returned with their source
+// locations, sorted like Internal's.
+func External(cfg Config, opts ExternalOptions) ([]BrokenLink, error) {
+	pages, _, err := loadOutput(cfg.OutputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect the distinct URLs to check
+	var urls []string
+	seen := make(map[string]bool)
+	for _, p := range pages {
+		for _, l := range p.Links {
+			target, ok := externalURL(l.Href, opts.Allow)
+			if ok && !seen[target] {
+				seen[target] = true
+				urls = append(urls, target)
+			}
+		}
+	}
+	sort.Strings(urls)
+
+	cache, err := loadCache(opts.CacheFile)
+	if err != nil {
+		return nil, err
+	}
+
+	c := newExternalChecker(opts)
+	results := make([]string, len(urls))
+	now := time.Now()
+	var mu sync.Mutex
+	parallel.Run(len(urls), opts.Workers, func(i int) {
+		mu.Lock()
+		entry, ok := cache[urls[i]]
+		mu.Unlock()
+		if ok && statusReason(entry.Status) == "" && now.Sub(entry.Checked) < opts.CacheTTL {
+			return
+		}
+
+		status, err := c.check(urls[i])
+		if err == nil {
+			results[i] = statusReason(status)
+		} else {
+			results[i] = err.Error()
+		}
+
+		// Only working URLs are cached, so that a fixed link or a passing
+		// rate limit or outage is noticed on the next run
+		mu.Lock()
+		if results[i] == "" {
+			cache[urls[i]] = cacheEntry{Status: status, Checked: time.Now()}
+		} else {
+			delete(cache, urls[i])
+		}
+		mu.Unlock()
+	})
+	c.stop()
+
+	if err := saveCache(opts.CacheFile, cache); err != nil {
+		return nil, err
+	}
+
+	reasons := make(map[string]string, len(urls))
+	for i, u := range urls {
+		if results[i] != "" {
+			reasons[u] = results[i]
+		}
+	}
+
+	sources := newSourceIndex(cfg)
+	var broken []BrokenLink
+	for _, p := range pages {
+		for _, l := range p.Links {
+			target, ok := externalURL(l.Href, opts.Allow)
+			if !ok || reasons[target] == "" {
+				continue
+			}
+			bl := BrokenLink{
+				Source: p.File,
+				Line:   l.Line,
+				Page:   p.Path,
+				Href:   l.Href,
+				Reason: reasons[target],
+			}
+			if source, line, ok := sources.locate(p.Path, l.Href); ok {
+				bl.Source, bl.Line = source, line
+			}
+			broken = append(broken, bl)
+		}
+	}
+	sortBroken(broken)
+
+	return broken, nil
+}
+
+// externalURL returns href without its fragment if it is an http or https
+// URL that is not allowlisted.
+func externalURL(href string, allow []string) (string, bool) {
+	u, err := url.Parse(href)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", false
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	target := u.String()
+	for _, prefix := range allow {
+		if strings.HasPrefix(target, prefix) {
+			return "", false
+		}
+	}
+	return target, true
+}
+
+// statusReason describes an HTTP status as a broken link reason, or returns
+// "" if the status is fine.
+func statusReason(status int) string {
+	if status < 400 {
+		return ""
+	}
+	return fmt.Sprintf("HTTP %d %s", status, http.StatusText(status))
+}
+
+// externalChecker requests URLs, sharing one rate limit between workers.
+type externalChecker struct {
+	client        *http.Client
+	retries       int
+	backoff       time.Duration
+	maxRetryAfter time.Duration
+	ticker        *time.Ticker // nil if the rate is unlimited
+}
+
+func newExternalChecker(opts ExternalOptions) *externalChecker {
+	c := &externalChecker{
+		client:        opts.Client,
+		retries:       opts.Retries,
+		backoff:       opts.Backoff,
+		maxRetryAfter: opts.MaxRetryAfter,
+	}
+	if c.client == nil {
+		c.client = &http.Client{Timeout: opts.Timeout}
+	}
+	if c.backoff <= 0 {
+		c.backoff = time.Second
+	}
+	if c.maxRetryAfter <= 0 {
+		c.maxRetryAfter = time.Minute
+	}
+	if opts.Rate > 0 {
+		// Rates above 1e9 would round the interval down to 0, which
+		// NewTicker rejects
+		c.ticker = time.NewTicker(max(time.Duration(float64(time.Second)/opts.Rate), 1))
+	}
+	return c
+}
+
+func (c *externalChecker) stop() {
+	if c.ticker != nil {
+		c.ticker.Stop()
+	}
+}
+
+// check returns the final HTTP status of target, retrying transient failures.
+// A server asking to wait longer than maxRetryAfter is not waited for, so
+// that one host cannot hold up the whole check.
+func (c *externalChecker) check(target string) (int, error) {
+	wait := c.backoff
+	for attempt := 0; ; attempt++ {
+		status, retryAfter, err := c.request(target)
+		transient := err != nil || status == http.StatusTooManyRequests || status >= 500
+		if !transient || attempt >= c.retries {
+			return status, err
+		}
+		if retryAfter > c.maxRetryAfter {
+			return status, fmt.Errorf("rate limited: %s, retry after %v", statusReason(status), retryAfter)
+		}
+		time.Sleep(max(wait, retryAfter))
+		wait *= 2
+	}
+}
+
+// request sends HEAD, then GET if the server does not accept HEAD for target.
+// It returns the status and any Retry-After delay of the last response.
+func (c *externalChecker) request(target string) (int, time.Duration, error) {
+	status, retryAfter, err := c.do(http.MethodHead, target)
+	if err == nil && status >= 400 && status != http.StatusTooManyRequests {
+		// Many servers reject or mishandle HEAD; only trust GET's answer
+		status, retryAfter, err = c.do(http.MethodGet, target)
+	}
+	return status, retryAfter, err
+}
+
+func (c *externalChecker) do(method, target string) (int, time.Duration, error) {
+	if c.ticker != nil {
+		<-c.ticker.C
+	}
+
+	req, err := http.NewRequest(method, target, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	req.Header.Set("User-Agent", "frostyard-linkcheck")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	resp.Body.Close()
+
+	var retryAfter time.Duration
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		retryAfter = time.Duration(secs) * time.Second
+	}
+	return resp.StatusCode, retryAfter, nil
+}
+
+// loadCache reads the result cache; a missing file is an empty cache.
+func loadCache(path string) (map[string]cacheEntry, error) {
+	cache := make(map[string]cacheEntry)
+	if path == "" {
+		return cache, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading link cache: %w", err)
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("parsing link cache %s: %w", path, err)
+	}
+	return cache, nil
+}
+
+// saveCache writes the result cache, creating its directory as needed.
+func saveCache(path string, cache map[string]cacheEntry) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling link cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating directory for %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing link cache: %w", err)
+	}
+	return nil
+}
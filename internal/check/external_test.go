@@ -0,0 +1,177 @@
+package check
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/frostyard/site/internal/content"
+)
+
+func TestExternal(t *testing.T) {
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		n := requests[r.URL.Path]
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/ok":
+		case "/gone":
+			w.WriteHeader(http.StatusNotFound)
+		case "/nohead":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/flaky":
+			if n == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		default:
+			t.Errorf("unexpected request for %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	tmp := t.TempDir()
+	writeFile(t, tmp, "content/docs/links.md", fmt.Sprintf(`---
+title: "Links"
+---
+
+[ok](%[1]s/ok#section) [flaky](%[1]s/flaky)
+
+[gone](%[1]s/gone)
+`, srv.URL))
+	writeFile(t, tmp, "dist/docs/links/index.html", fmt.Sprintf(`<html><body>
+<a href="%[1]s/ok#section">ok</a> <a href="%[1]s/ok">ok again</a> <a href="%[1]s/flaky">flaky</a>
+<a href="%[1]s/gone">gone</a> <a href="%[1]s/nohead">nohead</a> <a href="%[1]s/skip/me">skip</a>
+<a href="/docs/">internal</a> <a href="mailto:x@example.com">mail</a>
+</body></html>`, srv.URL))
+
+	cfg := Config{
+		OutputDir:  filepath.Join(tmp, "dist"),
+		ContentDir: filepath.Join(tmp, "content"),
+		Site: &content.Site{Pages: []*content.Page{
+			{Path: "/docs/links/", SourcePath: "content/docs/links.md"},
+		}},
+	}
+	opts := ExternalOptions{
+		Client:    srv.Client(),
+		Workers:   4,
+		Rate:      1000,
+		Retries:   1,
+		Backoff:   time.Millisecond,
+		Allow:     []string{srv.URL + "/skip/"},
+		CacheFile: filepath.Join(tmp, ".cache", "linkcheck.json"),
+		CacheTTL:  time.Hour,
+	}
+
+	broken, err := External(cfg, opts)
+	if err != nil {
+		t.Fatalf("External returned error: %v", err)
+	}
+	want := BrokenLink{
+		Source: "content/docs/links.md",
+		Line:   7,
+		Page:   "/docs/links/",
+		Href:   srv.URL + "/gone",
+		Reason: "HTTP 404 Not Found",
+	}
+	if len(broken) != 1 || broken[0] != want {
+		t.Fatalf("broken = %+v, want [%+v]", broken, want)
+	}
+
+	mu.Lock()
+	if requests["/ok"] != 1 {
+		t.Errorf("/ok requested %d times, want once for both links", requests["/ok"])
+	}
+	if requests["/nohead"] != 2 {
+		t.Errorf("/nohead requested %d times, want HEAD then GET", requests["/nohead"])
+	}
+	if requests["/flaky"] != 2 {
+		t.Errorf("/flaky requested %d times, want one retry", requests["/flaky"])
+	}
+	for path := range requests {
+		delete(requests, path)
+	}
+	mu.Unlock()
+
+	// A second run answers working URLs from the cache, but checks the broken
+	// one again, since it may have been fixed
+	broken, err = External(cfg, opts)
+	if err != nil {
+		t.Fatalf("External returned error: %v", err)
+	}
+	if len(broken) != 1 {
+		t.Errorf("cached run found %d broken links, want 1", len(broken))
+	}
+	mu.Lock()
+	if len(requests) != 1 || requests["/gone"] == 0 {
+		t.Errorf("cached run made requests %v, want only /gone", requests)
+	}
+	delete(requests, "/gone")
+	mu.Unlock()
+
+	// An expired cache is refreshed
+	opts.CacheTTL = 0
+	if _, err := External(cfg, opts); err != nil {
+		t.Fatalf("External returned error: %v", err)
+	}
+	mu.Lock()
+	if requests["/ok"] != 1 {
+		t.Errorf("/ok requested %d times after the cache expired, want 1", requests["/ok"])
+	}
+	mu.Unlock()
+}
+
+func TestExternalCheckerHighRate(t *testing.T) {
+	// An interval below 1ns must not reach time.NewTicker as 0, which panics
+	c := newExternalChecker(ExternalOptions{Rate: 1e12})
+	defer c.stop()
+	if c.ticker == nil {
+		t.Fatal("no ticker for a positive rate")
+	}
+}
+
+func TestExternalCheckerLongRetryAfter(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		w.Header().Set("Retry-After", "86400")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	c := newExternalChecker(ExternalOptions{Client: srv.Client(), Retries: 3, Backoff: time.Millisecond})
+	defer c.stop()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.check(srv.URL + "/busy")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "rate limited") {
+			t.Errorf("check error = %v, want the URL reported as rate limited", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("check waited for a Retry-After of a day")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 1 {
+		t.Errorf("server got %d requests, want 1 without retries", requests)
+	}
+}
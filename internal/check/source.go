@@ -0,0 +1,67 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/frostyard/site/internal/content"
+)
+
+// sourceIndex finds where links are written in the markdown source of pages.
+type sourceIndex struct {
+	baseDir string                   // Directory that page SourcePaths are relative to
+	pages   map[string]*content.Page // Pages by URL path
+	lines   map[string][]string      // Source lines by SourcePath, read on demand
+	seen    map[string]int           // Occurrences of each page+href located so far
+}
+
+func newSourceIndex(cfg Config) *sourceIndex {
+	idx := &sourceIndex{
+		baseDir: filepath.Dir(cfg.ContentDir),
+		pages:   make(map[string]*content.Page),
+		lines:   make(map[string][]string),
+		seen:    make(map[string]int),
+	}
+	if cfg.Site != nil {
+		for _, page := range cfg.Site.Pages {
+			idx.pages[page.Path] = page
+		}
+	}
+	return idx
+}
+
+// locate returns the source file and line of href in the markdown source of
+// the page at urlPath. Repeated calls for the same page and href return
+// successive occurrences. It reports false if the page has no markdown source
+// or href does not appear in it (e.g., a link from a template).
+func (idx *sourceIndex) locate(urlPath, href string) (string, int, bool) {
+	page, ok := idx.pages[urlPath]
+	if !ok {
+		return "", 0, false
+	}
+
+	lines, ok := idx.lines[page.SourcePath]
+	if !ok {
+		data, err := os.ReadFile(filepath.Join(idx.baseDir, filepath.FromSlash(page.SourcePath)))
+		if err == nil {
+			lines = strings.Split(string(data), "\n")
+		}
+		idx.lines[page.SourcePath] = lines
+	}
+
+	key := urlPath + " " + href
+	skip := idx.seen[key]
+	for i, line := range lines {
+		if !strings.Contains(line, href) {
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		idx.seen[key]++
+		return page.SourcePath, i + 1, true
+	}
+	return "", 0, false
+}
@@ -0,0 +1,237 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/chroma/v2/styles"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the site configuration file at the project root.
+const FileName = "frostyard.yaml"
+
+// Config holds site-wide settings loaded from frostyard.yaml.
+type Config struct {
+	BaseURL     string     `yaml:"baseURL"`     // Absolute site URL without trailing slash
+	Name        string     `yaml:"name"`        // Site name shown in titles and the nav bar
+	Description string     `yaml:"description"` // Default meta description and footer tagline
+	Nav         []NavLink  `yaml:"nav"`         // Main navigation links
+	Feed        Feed       `yaml:"feed"`        // Blog feed metadata
+	Paginate    int        `yaml:"paginate"`    // Blog posts per index page
+	Taxonomies  []Taxonomy `yaml:"taxonomies"`  // Frontmatter fields that get term listing pages
+	LinkCheck   LinkCheck  `yaml:"linkCheck"`   // External link checking (frostyard check --external)
+	Params      []string   `yaml:"params"`      // Custom frontmatter keys allowed on any page
+	TOC         TOC        `yaml:"toc"`         // Table of contents beside docs pages
+	Highlight   Highlight  `yaml:"highlight"`   // Code highlighting styles
+	Markdown    Markdown   `yaml:"markdown"`    // Optional markdown syntax
+	Diagrams    Diagrams   `yaml:"diagrams"`    // How diagram code blocks are drawn
+}
+
+// NavLink is a single entry in the main navigation.
+type NavLink struct {
+	Label string `yaml:"label"`
+	Path  string `yaml:"path"`
+}
+
+// Taxonomy declares a frontmatter field (a string or list of strings) whose
+// values are collected into term pages at /<name>/ and /<name>/<term>/.
+type Taxonomy struct {
+	Name     string `yaml:"name"`     // Frontmatter key (e.g., "tags")
+	Title    string `yaml:"title"`    // Plural display name (e.g., "Tags"); defaults to Name
+	Singular string `yaml:"singular"` // Singular display name (e.g., "Tag"); defaults to Title
+}
+
+// Feed holds metadata for the blog feed.
+type Feed struct {
+	Title       string `yaml:"title"`
+	Description string `yaml:"description"`
+	PerTerm     bool   `yaml:"perTerm"`     // Also write feeds for every taxonomy term
+	FullContent bool   `yaml:"fullContent"` // Include the full rendered post in feed entries
+}
+
+// TOC configures the table of contents beside docs pages. Pages can override
+// the levels with their own toc frontmatter.
+type TOC struct {
+	MinLevel int `yaml:"minLevel"` // Shallowest heading level listed (2 lists h2 and below)
+	MaxLevel int `yaml:"maxLevel"` // Deepest heading level listed
+}
+
+// Highlight picks the Chroma styles (https://xyproto.github.io/splash/docs/)
+// of code blocks in the light and dark theme.
+type Highlight struct {
+	Light string `yaml:"light"`
+	Dark  string `yaml:"dark"`
+}
+
+// Markdown turns optional markdown syntax on or off for the whole site.
+type Markdown struct {
+	Footnotes       bool `yaml:"footnotes"`       // [^1] references to notes listed after the page
+	DefinitionLists bool `yaml:"definitionLists"` // A term line followed by ": definition" lines
+	Typographer     bool `yaml:"typographer"`     // Curly quotes, em dashes and ellipses
+	Attributes      bool `yaml:"attributes"`      // {.class #id key=value} lines after blocks
+	Abbreviations   bool `yaml:"abbreviations"`   // *[ABBR]: Expansion definitions
+}
+
+// Diagrams picks the renderer of each diagram language. DiagramCommand runs
+// the language's program (dot, mmdc or d2) at build time, and fails the build
+// if it is not installed; the other choices need no external tools.
+type Diagrams struct {
+	Graphviz string        `yaml:"graphviz"` // "builtin" (Go renderer) or "command" (dot -Tsvg)
+	Mermaid  string        `yaml:"mermaid"`  // "browser" (mermaid.js) or "command" (mmdc)
+	D2       string        `yaml:"d2"`       // "source" (shown as text) or "command" (d2)
+	Timeout  time.Duration `yaml:"timeout"`  // Limit on each run of a command (e.g., "30s")
+}
+
+// DiagramCommand is the Diagrams choice that renders with an external program.
+const DiagramCommand = "command"
+
+// LinkCheck configures the external link checker.
+type LinkCheck struct {
+	Allow    []string      `yaml:"allow"`    // URL prefixes that are never checked
+	Workers  int           `yaml:"workers"`  // Concurrent requests
+	Rate     float64       `yaml:"rate"`     // Maximum requests per second; 0 means unlimited
+	Retries  int           `yaml:"retries"`  // Retries after a network error, 429 or 5xx response
+	Timeout  time.Duration `yaml:"timeout"`  // Per-request timeout (e.g., "10s")
+	CacheTTL time.Duration `yaml:"cacheTTL"` // How long cached results are reused (e.g., "24h"); 0 disables the cache
+}
+
+// Default returns the configuration used when no frostyard.yaml is present.
+func Default() Config {
+	return Config{
+		BaseURL:     "https://frostyard.github.io",
+		Name:        "Frostyard",
+		Description: "Secure, reproducible Linux images",
+		Nav: []NavLink{
+			{Label: "Docs", Path: "/docs/"},
+			{Label: "Blog", Path: "/blog/"},
+			{Label: "Downloads", Path: "/downloads/"},
+			{Label: "Community", Path: "/community/"},
+		},
+		Feed: Feed{
+			Title:       "Frostyard Blog",
+			Description: "Updates from the Frostyard ecosystem",
+			PerTerm:     true,
+			FullContent: true,
+		},
+		Paginate: 10,
+		Taxonomies: []Taxonomy{
+			{Name: "tags", Title: "Tags", Singular: "Tag"},
+		},
+		TOC:       TOC{MinLevel: 2, MaxLevel: 3},
+		Highlight: Highlight{Light: "github", Dark: "dracula"},
+		Markdown: Markdown{
+			Footnotes:       true,
+			DefinitionLists: true,
+			Typographer:     true,
+			Attributes:      true,
+			Abbreviations:   true,
+		},
+		Diagrams: Diagrams{
+			Graphviz: "builtin",
+			Mermaid:  "browser",
+			D2:       "source",
+			Timeout:  30 * time.Second,
+		},
+		LinkCheck: LinkCheck{
+			Workers:  8,
+			Rate:     5,
+			Retries:  2,
+			Timeout:  10 * time.Second,
+			CacheTTL: 24 * time.Hour,
+		},
+	}
+}
+
+// Load reads the configuration file at path. Fields missing from the file keep
+// their default values. If the file does not exist, Default() is returned.
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return cfg, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	if cfg.BaseURL == "" {
+		return cfg, fmt.Errorf("%s: baseURL must not be empty", path)
+	}
+	if cfg.Paginate < 1 {
+		return cfg, fmt.Errorf("%s: paginate must be at least 1, got %d", path, cfg.Paginate)
+	}
+
+	if toc := cfg.TOC; toc.MinLevel < 1 || toc.MaxLevel > 6 || toc.MinLevel > toc.MaxLevel {
+		return cfg, fmt.Errorf("%s: toc: levels must satisfy 1 <= minLevel <= maxLevel <= 6, got %d and %d", path, toc.MinLevel, toc.MaxLevel)
+	}
+
+	for _, style := range []string{cfg.Highlight.Light, cfg.Highlight.Dark} {
+		if _, ok := styles.Registry[style]; !ok {
+			return cfg, fmt.Errorf("%s: highlight: unknown style %q", path, style)
+		}
+	}
+
+	d := cfg.Diagrams
+	for _, choice := range []struct{ lang, value, other string }{
+		{"graphviz", d.Graphviz, "builtin"},
+		{"mermaid", d.Mermaid, "browser"},
+		{"d2", d.D2, "source"},
+	} {
+		if choice.value != choice.other && choice.value != DiagramCommand {
+			return cfg, fmt.Errorf("%s: diagrams: %s must be %q or %q, got %q", path, choice.lang, choice.other, DiagramCommand, choice.value)
+		}
+	}
+	if d.Timeout <= 0 {
+		return cfg, fmt.Errorf("%s: diagrams: timeout must be positive", path)
+	}
+
+	lc := cfg.LinkCheck
+	if lc.Workers < 1 || lc.Rate < 0 || lc.Retries < 0 || lc.Timeout <= 0 || lc.CacheTTL < 0 {
+		return cfg, fmt.Errorf("%s: linkCheck: workers and timeout must be positive; rate, retries and cacheTTL must not be negative", path)
+	}
+
+	seen := make(map[string]bool)
+	for i, name := range cfg.Params {
+		if name == "" {
+			return cfg, fmt.Errorf("%s: params[%d]: name must not be empty", path, i)
+		}
+		if seen[name] {
+			return cfg, fmt.Errorf("%s: duplicate param %q", path, name)
+		}
+		seen[name] = true
+	}
+
+	seen = make(map[string]bool)
+	for i := range cfg.Taxonomies {
+		tax := &cfg.Taxonomies[i]
+		if tax.Name == "" {
+			return cfg, fmt.Errorf("%s: taxonomies[%d]: name must not be empty", path, i)
+		}
+		if seen[tax.Name] {
+			return cfg, fmt.Errorf("%s: duplicate taxonomy %q", path, tax.Name)
+		}
+		seen[tax.Name] = true
+		if tax.Title == "" {
+			tax.Title = tax.Name
+		}
+		if tax.Singular == "" {
+			tax.Singular = tax.Title
+		}
+	}
+
+	return cfg, nil
+}
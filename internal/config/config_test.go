@@ -0,0 +1,228 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadMissingFile(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), FileName))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	def := Default()
+	if cfg.BaseURL != def.BaseURL {
+		t.Errorf("BaseURL = %q, want %q", cfg.BaseURL, def.BaseURL)
+	}
+	if cfg.Name != def.Name {
+		t.Errorf("Name = %q, want %q", cfg.Name, def.Name)
+	}
+	if len(cfg.Nav) != len(def.Nav) {
+		t.Errorf("len(Nav) = %d, want %d", len(cfg.Nav), len(def.Nav))
+	}
+}
+
+func TestLoadOverridesDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	data := `baseURL: "https://staging.example.com/"
+name: "Frostyard Staging"
+nav:
+  - label: "Docs"
+    path: "/docs/"
+feed:
+  title: "Staging Blog"
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	if cfg.BaseURL != "https://staging.example.com" {
+		t.Errorf("BaseURL = %q, want trailing slash trimmed", cfg.BaseURL)
+	}
+	if cfg.Name != "Frostyard Staging" {
+		t.Errorf("Name = %q, want %q", cfg.Name, "Frostyard Staging")
+	}
+	if len(cfg.Nav) != 1 || cfg.Nav[0].Label != "Docs" {
+		t.Errorf("Nav = %v, want single Docs link", cfg.Nav)
+	}
+	if cfg.Feed.Title != "Staging Blog" {
+		t.Errorf("Feed.Title = %q, want %q", cfg.Feed.Title, "Staging Blog")
+	}
+	// Unset fields keep their defaults
+	if cfg.Description != Default().Description {
+		t.Errorf("Description = %q, want default %q", cfg.Description, Default().Description)
+	}
+	if cfg.Feed.Description != Default().Feed.Description {
+		t.Errorf("Feed.Description = %q, want default %q", cfg.Feed.Description, Default().Feed.Description)
+	}
+}
+
+func TestLoadRejectsUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte("baseUrl: \"https://example.com\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(path); err == nil {
+		t.Error("Load returned nil error for unknown key, want error")
+	}
+}
+
+func TestLoadTaxonomyDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	data := `taxonomies:
+  - name: tags
+    title: Tags
+    singular: Tag
+  - name: category
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(cfg.Taxonomies) != 2 {
+		t.Fatalf("len(Taxonomies) = %d, want 2", len(cfg.Taxonomies))
+	}
+	category := cfg.Taxonomies[1]
+	if category.Title != "category" || category.Singular != "category" {
+		t.Errorf("category = %+v, want Title and Singular defaulted to name", category)
+	}
+
+	dup := "taxonomies:\n  - name: tags\n  - name: tags\n"
+	if err := os.WriteFile(path, []byte(dup), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load returned nil error for duplicate taxonomy, want error")
+	}
+}
+
+func TestLoadLinkCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	data := `linkCheck:
+  allow: ["http://localhost"]
+  timeout: 5s
+  cacheTTL: 1h
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	lc := cfg.LinkCheck
+	if lc.Timeout != 5*time.Second || lc.CacheTTL != time.Hour {
+		t.Errorf("Timeout, CacheTTL = %v, %v; want 5s, 1h", lc.Timeout, lc.CacheTTL)
+	}
+	if len(lc.Allow) != 1 || lc.Allow[0] != "http://localhost" {
+		t.Errorf("Allow = %v, want [http://localhost]", lc.Allow)
+	}
+	if lc.Workers != Default().LinkCheck.Workers {
+		t.Errorf("Workers = %d, want default %d", lc.Workers, Default().LinkCheck.Workers)
+	}
+
+	if err := os.WriteFile(path, []byte("linkCheck:\n  rate: -1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load returned nil error for negative rate, want error")
+	}
+}
+
+func TestLoadParams(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte("params: [status, min_version]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(cfg.Params) != 2 || cfg.Params[1] != "min_version" {
+		t.Errorf("Params = %v, want [status min_version]", cfg.Params)
+	}
+
+	if err := os.WriteFile(path, []byte("params: [status, status]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load returned nil error for duplicate param, want error")
+	}
+}
+
+func TestLoadHighlight(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte("highlight:\n  light: monokailight\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.Highlight.Light != "monokailight" || cfg.Highlight.Dark != "dracula" {
+		t.Errorf("Highlight = %+v, want monokailight and the default dracula", cfg.Highlight)
+	}
+
+	if err := os.WriteFile(path, []byte("highlight:\n  dark: no-such-style\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load returned nil error for an unknown style, want error")
+	}
+}
+
+func TestLoadMarkdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte("markdown:\n  typographer: false\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	want := Default().Markdown
+	want.Typographer = false
+	if cfg.Markdown != want {
+		t.Errorf("Markdown = %+v, want %+v", cfg.Markdown, want)
+	}
+}
+
+func TestLoadDiagrams(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte("diagrams:\n  mermaid: command\n  timeout: 5s\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	want := Default().Diagrams
+	want.Mermaid = DiagramCommand
+	want.Timeout = 5 * time.Second
+	if cfg.Diagrams != want {
+		t.Errorf("Diagrams = %+v, want %+v", cfg.Diagrams, want)
+	}
+
+	for _, data := range []string{"diagrams:\n  graphviz: dot\n", "diagrams:\n  timeout: 0s\n"} {
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("Load returned nil error for %q, want error", data)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package content
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// kindAbbreviation is the AST node kind of abbreviations in text.
+var kindAbbreviation = ast.NewNodeKind("Abbreviation")
+
+// abbreviationNode is a use of a defined abbreviation, holding its text.
+type abbreviationNode struct {
+	ast.BaseInline
+	Title string // The expansion
+}
+
+func (n *abbreviationNode) Kind() ast.NodeKind { return kindAbbreviation }
+
+func (n *abbreviationNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Title": n.Title}, nil)
+}
+
+// kindAbbreviationDefinition is the AST node kind of abbreviation
+// definitions, which the transformer removes.
+var kindAbbreviationDefinition = ast.NewNodeKind("AbbreviationDefinition")
+
+type abbreviationDefinitionNode struct {
+	ast.BaseBlock
+}
+
+func (n *abbreviationDefinitionNode) Kind() ast.NodeKind { return kindAbbreviationDefinition }
+
+func (n *abbreviationDefinitionNode) IsRaw() bool { return true }
+
+func (n *abbreviationDefinitionNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// abbreviationExtension adds PHP Markdown Extra abbreviations to goldmark.
+// A definition line anywhere on the page wraps each whole-word use of the
+// abbreviation in an <abbr> with the expansion as its title:
+//
+//	*[ESP]: EFI System Partition
+type abbreviationExtension struct{}
+
+func (abbreviationExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(abbreviationParser{}, 250)),
+		parser.WithASTTransformers(util.Prioritized(abbreviationTransformer{}, 650)),
+	)
+	m.Renderer().AddOptions(
+		renderer.WithNodeRenderers(util.Prioritized(abbreviationRenderer{}, 100)),
+	)
+}
+
+// abbreviationsKey holds the abbreviations defined on the page, mapped to
+// their expansions.
+var abbreviationsKey = parser.NewContextKey()
+
+var abbreviationDefRE = regexp.MustCompile(`^\*\[([^\]]+)\]:[ \t]*(.*?)\s*$`)
+
+// abbreviationParser parses *[ABBR]: Expansion lines.
+type abbreviationParser struct{}
+
+func (abbreviationParser) Trigger() []byte { return []byte{'*'} }
+
+func (abbreviationParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, seg := reader.PeekLine()
+	m := abbreviationDefRE.FindSubmatch(line)
+	if m == nil {
+		return nil, parser.NoChildren
+	}
+	abbr := strings.TrimSpace(string(m[1]))
+	if abbr == "" {
+		return nil, parser.NoChildren
+	}
+
+	defs, _ := pc.Get(abbreviationsKey).(map[string]string)
+	if defs == nil {
+		defs = map[string]string{}
+		pc.Set(abbreviationsKey, defs)
+	}
+	defs[abbr] = string(m[2])
+
+	node := &abbreviationDefinitionNode{}
+	node.Lines().Append(seg)
+	reader.Advance(len(bytes.TrimRight(line, "\r\n")))
+	return node, parser.NoChildren
+}
+
+func (abbreviationParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	return parser.Close
+}
+
+func (abbreviationParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (abbreviationParser) CanInterruptParagraph() bool { return false }
+
+func (abbreviationParser) CanAcceptIndentedLine() bool { return false }
+
+// abbreviationTransformer removes the abbreviation definitions and wraps the
+// uses of the abbreviations in text, outside code.
+type abbreviationTransformer struct{}
+
+func (abbreviationTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	var defNodes []ast.Node
+	var texts []*ast.Text
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *abbreviationDefinitionNode:
+			defNodes = append(defNodes, n)
+		case *ast.CodeSpan, *ast.CodeBlock, *ast.FencedCodeBlock, *ast.HTMLBlock, *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			texts = append(texts, n)
+		}
+		return ast.WalkContinue, nil
+	})
+	for _, n := range defNodes {
+		n.Parent().RemoveChild(n.Parent(), n)
+	}
+
+	defs, _ := pc.Get(abbreviationsKey).(map[string]string)
+	if len(defs) == 0 {
+		return
+	}
+
+	// Longest first, so that "ESP32" wins over "ESP"
+	abbrs := make([]string, 0, len(defs))
+	for abbr := range defs {
+		abbrs = append(abbrs, abbr)
+	}
+	sort.Slice(abbrs, func(i, j int) bool { return len(abbrs[i]) > len(abbrs[j]) })
+	quoted := make([]string, len(abbrs))
+	for i, abbr := range abbrs {
+		quoted[i] = regexp.QuoteMeta(abbr)
+	}
+	re := regexp.MustCompile(strings.Join(quoted, "|"))
+
+	source := reader.Source()
+	for _, t := range texts {
+		wrapAbbreviations(t, source, re, defs)
+	}
+}
+
+// wrapAbbreviations splits t around whole-word matches of re, wrapping each
+// match in an abbreviationNode.
+func wrapAbbreviations(t *ast.Text, source []byte, re *regexp.Regexp, defs map[string]string) {
+	value := t.Segment.Value(source)
+	parent := t.Parent()
+	start := 0
+	for _, m := range re.FindAllIndex(value, -1) {
+		if !wordBoundary(value, m[0], m[1]) {
+			continue
+		}
+		if m[0] > start {
+			parent.InsertBefore(parent, t, ast.NewTextSegment(text.NewSegment(t.Segment.Start+start, t.Segment.Start+m[0])))
+		}
+		seg := text.NewSegment(t.Segment.Start+m[0], t.Segment.Start+m[1])
+		abbr := &abbreviationNode{Title: defs[string(value[m[0]:m[1]])]}
+		abbr.AppendChild(abbr, ast.NewTextSegment(seg))
+		parent.InsertBefore(parent, t, abbr)
+		start = m[1]
+	}
+	if start == 0 {
+		return
+	}
+	// The rest of t keeps its line break
+	t.Segment = t.Segment.WithStart(t.Segment.Start + start)
+	if t.Segment.Len() == 0 && !t.SoftLineBreak() && !t.HardLineBreak() {
+		parent.RemoveChild(parent, t)
+	}
+}
+
+// wordBoundary reports whether value[start:end] is not part of a longer word.
+func wordBoundary(value []byte, start, end int) bool {
+	if r, _ := utf8.DecodeLastRune(value[:start]); start > 0 && isWordRune(r) {
+		return false
+	}
+	if r, _ := utf8.DecodeRune(value[end:]); end < len(value) && isWordRune(r) {
+		return false
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// abbreviationRenderer renders abbreviations as <abbr> with a title.
+type abbreviationRenderer struct{}
+
+func (abbreviationRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindAbbreviation, renderAbbreviation)
+}
+
+func renderAbbreviation(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*abbreviationNode)
+	if !entering {
+		_, _ = w.WriteString("</abbr>")
+		return ast.WalkContinue, nil
+	}
+	_, _ = w.WriteString(`<abbr title="`)
+	_, _ = w.Write(util.EscapeHTML([]byte(n.Title)))
+	_, _ = w.WriteString(`">`)
+	return ast.WalkContinue, nil
+}
@@ -0,0 +1,43 @@
+package content
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAbbreviations(t *testing.T) {
+	input := `---
+title: "Abbreviations"
+---
+
+## Mount the ESP
+
+The ESP and ESP32 are unrelated; ESPs and ` + "`ESP`" + ` are left alone.
+The UKI lives on the ESP.
+
+*[ESP]: EFI System Partition
+*[ESP32]: A "microcontroller"
+*[UKI]: Unified Kernel Image
+`
+	page, err := ParsePage([]byte(input), "content/docs/abbr.md")
+	if err != nil {
+		t.Fatalf("ParsePage: %v", err)
+	}
+	html := string(page.Content)
+
+	for _, want := range []string{
+		`Mount the <abbr title="EFI System Partition">ESP</abbr><a class="heading-anchor"`,
+		`<p>The <abbr title="EFI System Partition">ESP</abbr> and <abbr title="A &quot;microcontroller&quot;">ESP32</abbr> are unrelated; ESPs and <code>ESP</code> are left alone.` + "\n" +
+			`The <abbr title="Unified Kernel Image">UKI</abbr> lives on the <abbr title="EFI System Partition">ESP</abbr>.</p>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %q in:\n%s", want, html)
+		}
+	}
+	if strings.Contains(html, "*[") {
+		t.Errorf("definitions left in:\n%s", html)
+	}
+	if len(page.Headings) != 1 || page.Headings[0].Text != "Mount the ESP" || page.Headings[0].ID != "mount-the-esp" {
+		t.Errorf("headings = %+v", page.Headings)
+	}
+}
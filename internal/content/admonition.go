@@ -0,0 +1,221 @@
+package content
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// admonitionTypes maps the admonition types accepted in markdown to the type
+// they render as. The canonical five are GitHub's alert types.
+var admonitionTypes = map[string]string{
+	"note":      "note",
+	"tip":       "tip",
+	"important": "important",
+	"warning":   "warning",
+	"caution":   "caution",
+	"info":      "note",
+	"danger":    "caution",
+}
+
+// admonitionIcons are the inline SVG icons shown before each variant's title.
+var admonitionIcons = map[string]string{
+	"note":      `<circle cx="10" cy="10" r="7.5"/><path d="M10 9v4.5M10 6.5v.01"/>`,
+	"tip":       `<path d="M8 17h4M7.5 14.5h5M10 2.5a5 5 0 0 0-3 9v3h6v-3a5 5 0 0 0-3-9z"/>`,
+	"important": `<path d="M3 3.5h14v10H8.5L5 16.5v-3H3z"/><path d="M10 6v3.5M10 11.5v.01"/>`,
+	"warning":   `<path d="M10 2.5 18 16.5H2z"/><path d="M10 7.5v4M10 14v.01"/>`,
+	"caution":   `<path d="M7 2.5h6l4.5 4.5v6L13 17.5H7L2.5 13V7z"/><path d="M10 6v4.5M10 13.5v.01"/>`,
+}
+
+// kindAdmonition is the AST node kind of admonitions.
+var kindAdmonition = ast.NewNodeKind("Admonition")
+
+// admonitionNode is a callout block (note, tip, important, warning or
+// caution) holding other blocks.
+type admonitionNode struct {
+	ast.BaseBlock
+	Variant string // One of the values of admonitionTypes
+	Title   string // Custom title; empty for the variant's name
+	Fence   int    // Number of colons in the opening fence; 0 for alerts
+}
+
+func (n *admonitionNode) Kind() ast.NodeKind { return kindAdmonition }
+
+func (n *admonitionNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Variant": n.Variant, "Title": n.Title}, nil)
+}
+
+// admonitionExtension adds admonitions to goldmark, written either as GitHub
+// alerts or as container blocks:
+//
+//	> [!WARNING]
+//	> Updating the boot entry can fail on some firmware.
+//
+//	:::note Optional title
+//	Content, which may hold any markdown.
+//	:::
+//
+// Container blocks nest: an inner one may use the same fence as the outer,
+// or a shorter one (with the outer written as ::::note ... ::::).
+type admonitionExtension struct{}
+
+func (admonitionExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(admonitionParser{}, 750)),
+		parser.WithASTTransformers(util.Prioritized(alertTransformer{}, 300)),
+	)
+	m.Renderer().AddOptions(
+		renderer.WithNodeRenderers(util.Prioritized(admonitionRenderer{}, 100)),
+	)
+}
+
+var (
+	containerOpenRE  = regexp.MustCompile(`^(:{3,})\s*([A-Za-z]+)(?:\s+(.*?))?\s*$`)
+	containerCloseRE = regexp.MustCompile(`^\s*(:{3,})\s*$`)
+	alertMarkerRE    = regexp.MustCompile(`(?i)^\s*\[!(note|tip|important|warning|caution)\]\s*$`)
+)
+
+// admonitionParser parses :::type container blocks, closed by a line holding
+// a fence of the same length.
+type admonitionParser struct{}
+
+func (admonitionParser) Trigger() []byte { return []byte{':'} }
+
+func (admonitionParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, _ := reader.PeekLine()
+	m := containerOpenRE.FindSubmatch(line)
+	if m == nil {
+		return nil, parser.NoChildren
+	}
+	typ, ok := admonitionTypes[strings.ToLower(string(m[2]))]
+	if !ok {
+		return nil, parser.NoChildren
+	}
+
+	reader.Advance(len(bytes.TrimRight(line, "\r\n")))
+	return &admonitionNode{Variant: typ, Title: string(m[3]), Fence: len(m[1])}, parser.HasChildren
+}
+
+func (admonitionParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	line, _ := reader.PeekLine()
+	m := containerCloseRE.FindSubmatch(line)
+	if m != nil && len(m[1]) == node.(*admonitionNode).Fence && !innerBlockTakes(node, len(m[1]), pc) {
+		reader.Advance(len(bytes.TrimRight(line, "\r\n")))
+		return parser.Close
+	}
+	return parser.Continue | parser.HasChildren
+}
+
+func (admonitionParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (admonitionParser) CanInterruptParagraph() bool { return true }
+
+func (admonitionParser) CanAcceptIndentedLine() bool { return false }
+
+// innerBlockTakes reports whether a block opened inside node owns the closing
+// fence line: a code block, whose content it is, or a nested admonition with
+// a fence of the same length, which it closes.
+func innerBlockTakes(node ast.Node, fence int, pc parser.Context) bool {
+	opened := pc.OpenedBlocks()
+	for i := range opened {
+		if opened[i].Node != node {
+			continue
+		}
+		for _, inner := range opened[i+1:] {
+			switch n := inner.Node.(type) {
+			case *ast.FencedCodeBlock, *ast.CodeBlock:
+				return true
+			case *admonitionNode:
+				if n.Fence == fence {
+					return true
+				}
+			}
+		}
+		break
+	}
+	return false
+}
+
+// alertTransformer turns blockquotes whose first line is a GitHub alert
+// marker such as [!WARNING] into admonitions.
+type alertTransformer struct{}
+
+func (alertTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+
+	var quotes []*ast.Blockquote
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if q, ok := n.(*ast.Blockquote); ok && entering {
+			quotes = append(quotes, q)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, quote := range quotes {
+		para, ok := quote.FirstChild().(*ast.Paragraph)
+		if !ok || para.Lines().Len() == 0 {
+			continue
+		}
+		first := para.Lines().At(0)
+		m := alertMarkerRE.FindSubmatch(first.Value(source))
+		if m == nil {
+			continue
+		}
+
+		// Drop the marker line from the paragraph, and the paragraph if
+		// nothing else is in it
+		for child := para.FirstChild(); child != nil; {
+			t, ok := child.(*ast.Text)
+			if !ok || t.Segment.Start >= first.Stop {
+				break
+			}
+			next := child.NextSibling()
+			para.RemoveChild(para, child)
+			child = next
+		}
+		if para.ChildCount() == 0 {
+			quote.RemoveChild(quote, para)
+		}
+
+		node := &admonitionNode{Variant: strings.ToLower(string(m[1]))}
+		for child := quote.FirstChild(); child != nil; {
+			next := child.NextSibling()
+			node.AppendChild(node, child)
+			child = next
+		}
+		quote.Parent().ReplaceChild(quote.Parent(), quote, node)
+	}
+}
+
+// admonitionRenderer renders admonitions as a titled, typed <div>, styled in
+// input.css.
+type admonitionRenderer struct{}
+
+func (admonitionRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindAdmonition, renderAdmonition)
+}
+
+func renderAdmonition(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*admonitionNode)
+	if !entering {
+		_, _ = w.WriteString("</div>\n")
+		return ast.WalkContinue, nil
+	}
+
+	title := n.Title
+	if title == "" {
+		title = strings.ToUpper(n.Variant[:1]) + n.Variant[1:]
+	}
+	_, _ = fmt.Fprintf(w, `<div class="admonition admonition-%s" role="note">`+"\n", n.Variant)
+	_, _ = fmt.Fprintf(w, `<p class="admonition-title"><svg class="admonition-icon" viewBox="0 0 20 20" fill="none" stroke="currentColor" stroke-width="1.5" stroke-linecap="round" stroke-linejoin="round" aria-hidden="true">%s</svg>%s</p>`+"\n",
+		admonitionIcons[n.Variant], util.EscapeHTML([]byte(title)))
+	return ast.WalkContinue, nil
+}
@@ -0,0 +1,101 @@
+package content
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAdmonitions(t *testing.T) {
+	input := `> [!WARNING]
+> Updates can pick the **wrong** boot entry.
+
+> [!note]
+> Lowercase markers work too.
+
+> A plain quote.
+
+:::tip Try this
+Run ` + "`nbc status`" + ` first.
+
+- one
+- two
+:::
+
+:::danger
+Aliases render as their canonical type.
+:::
+
+:::unknown
+Not an admonition.
+:::
+`
+	page, err := ParsePage([]byte(input), "content/docs/status.md")
+	if err != nil {
+		t.Fatalf("ParsePage returned error: %v", err)
+	}
+	html := string(page.Content)
+
+	for _, want := range []string{
+		`<div class="admonition admonition-warning" role="note">`,
+		`</svg>Warning</p>` + "\n" + `<p>Updates can pick the <strong>wrong</strong> boot entry.</p>` + "\n</div>",
+		`<div class="admonition admonition-note" role="note">`,
+		"<blockquote>\n<p>A plain quote.</p>\n</blockquote>",
+		`</svg>Try this</p>` + "\n" + `<p>Run <code>nbc status</code> first.</p>`,
+		"<li>two</li>\n</ul>\n</div>",
+		`<div class="admonition admonition-caution" role="note">`,
+		"<p>:::unknown",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %q in:\n%s", want, html)
+		}
+	}
+	if strings.Contains(html, "[!") {
+		t.Errorf("alert marker left in output:\n%s", html)
+	}
+}
+
+func TestParseAdmonitionNesting(t *testing.T) {
+	input := `:::note Outer
+Before.
+
+` + "```text\n:::\n```" + `
+
+:::tip
+Inner.
+:::
+
+After.
+:::
+
+::::warning
+:::caution
+Shorter inner fence.
+:::
+Still in the warning.
+::::
+
+Outside.
+`
+	page, err := ParsePage([]byte(input), "content/docs/nested.md")
+	if err != nil {
+		t.Fatalf("ParsePage returned error: %v", err)
+	}
+	html := string(page.Content)
+
+	for _, want := range []string{
+		`<span class="cl">:::` + "\n</span>",
+		"<p>Inner.</p>\n</div>\n<p>After.</p>\n</div>",
+		"<p>Shorter inner fence.</p>\n</div>\n<p>Still in the warning.</p>\n</div>",
+		"</div>\n<p>Outside.</p>",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %q in:\n%s", want, html)
+		}
+	}
+	if got := strings.Count(html, `<div class="admonition`); got != 4 {
+		t.Errorf("%d admonitions, want 4:\n%s", got, html)
+	}
+	if strings.Contains(html, "<p>:::") {
+		t.Errorf("fence rendered as text:\n%s", html)
+	}
+}
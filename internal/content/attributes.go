@@ -0,0 +1,130 @@
+package content
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// attributeListExtension adds attribute lists to goldmark: a {.class #id
+// key=value} line (or kramdown's {: ...}) right after a paragraph applies to
+// that paragraph, and one on its own after another block applies to that
+// block:
+//
+//	A paragraph with a class.
+//	{.lead}
+//
+//	- a list
+//	- with an id
+//
+//	{#steps}
+//
+// Headings take attributes on their own line already, as in ## Title {#id}.
+// An id that another element of the page already has fails the page.
+type attributeListExtension struct{}
+
+func (attributeListExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithASTTransformers(util.Prioritized(attributeListTransformer{}, 600)),
+	)
+}
+
+// parseAttributeList parses line as an attribute list, reporting false if it
+// is anything else.
+func parseAttributeList(line []byte) (parser.Attributes, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) < 2 || line[0] != '{' || line[len(line)-1] != '}' {
+		return nil, false
+	}
+	if line[1] == ':' {
+		line = append([]byte{'{'}, line[2:]...)
+	}
+	reader := text.NewReader(line)
+	attrs, ok := parser.ParseAttributes(reader)
+	if !ok || len(attrs) == 0 {
+		return nil, false
+	}
+	if rest, _ := reader.PeekLine(); len(bytes.TrimSpace(rest)) > 0 {
+		return nil, false
+	}
+	return attrs, true
+}
+
+// attributeListTransformer applies the attribute lists that end paragraphs,
+// dropping them from the text. It runs after headingIDTransformer, so the
+// heading IDs in pc.IDs() are final.
+type attributeListTransformer struct{}
+
+func (attributeListTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	ids, _ := pc.IDs().(*headingIDs)
+
+	var paras []*ast.Paragraph
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if p, ok := n.(*ast.Paragraph); ok && entering {
+			paras = append(paras, p)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, para := range paras {
+		lines := para.Lines()
+		if lines.Len() == 0 {
+			continue
+		}
+		last := lines.At(lines.Len() - 1)
+		attrs, ok := parseAttributeList(last.Value(source))
+		if !ok {
+			continue
+		}
+
+		// On its own, the list belongs to the block before
+		target := ast.Node(para)
+		if lines.Len() == 1 {
+			target = para.PreviousSibling()
+			if target == nil {
+				continue
+			}
+			para.Parent().RemoveChild(para.Parent(), para)
+		} else {
+			dropLineInlines(para, last)
+		}
+		for _, attr := range attrs {
+			if id, ok := attr.Value.([]byte); ok && string(attr.Name) == "id" && ids != nil {
+				current, _ := target.AttributeString("id")
+				if own, _ := current.([]byte); ids.used[string(id)] && !bytes.Equal(own, id) {
+					line := bytes.Count(source[:last.Start], []byte("\n")) + 1
+					setMarkdownError(pc, line, fmt.Sprintf("attribute list: id %q is already used on this page", id))
+					continue
+				}
+				ids.used[string(id)] = true
+			}
+			target.SetAttribute(attr.Name, attr.Value)
+		}
+	}
+}
+
+// dropLineInlines removes the inlines of para from line on. The line starts
+// with a text node, since inline parsing splits text at line ends.
+func dropLineInlines(para *ast.Paragraph, line text.Segment) {
+	child := para.FirstChild()
+	for child != nil {
+		if t, ok := child.(*ast.Text); ok && t.Segment.Start >= line.Start {
+			break
+		}
+		child = child.NextSibling()
+	}
+	for child != nil {
+		next := child.NextSibling()
+		para.RemoveChild(para, child)
+		child = next
+	}
+	if t, ok := para.LastChild().(*ast.Text); ok {
+		t.SetSoftLineBreak(false)
+	}
+}
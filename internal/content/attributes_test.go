@@ -0,0 +1,87 @@
+package content
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAttributeLists(t *testing.T) {
+	input := `---
+title: "Attributes"
+---
+
+A lead paragraph
+over two lines.
+{.lead #intro}
+
+- one
+- two
+
+{: .steps data-step="2"}
+
+Braces {like this} stay.
+
+{.aside}
+`
+	page, err := ParsePage([]byte(input), "content/docs/attrs.md")
+	if err != nil {
+		t.Fatalf("ParsePage: %v", err)
+	}
+	html := string(page.Content)
+
+	for _, want := range []string{
+		"<p class=\"lead\" id=\"intro\">A lead paragraph\nover two lines.</p>",
+		"<ul class=\"steps\" data-step=\"2\">\n<li>one</li>",
+		"<p class=\"aside\">Braces {like this} stay.</p>",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %q in:\n%s", want, html)
+		}
+	}
+	if strings.Contains(html, "{.") || strings.Contains(html, "{:") {
+		t.Errorf("attribute lists left in:\n%s", html)
+	}
+}
+
+func TestParseAttributeListDuplicateID(t *testing.T) {
+	for _, input := range []string{
+		"# A\n\nPara\n{#a}\n",
+		"## Install\n\nText\n{#install}\n",
+		"Para\n{#x}\n\nOther\n{#x}\n",
+		"Para\n{#search}\n",
+	} {
+		_, err := ParsePage([]byte(input), "content/docs/attrs.md")
+		if err == nil || !strings.Contains(err.Error(), "is already used on this page") {
+			t.Errorf("ParsePage(%q) error = %v, want a duplicate id error", input, err)
+		}
+	}
+
+	input := "## Install\n\nText\n{#install-steps}\n"
+	page, err := ParsePage([]byte(input), "content/docs/attrs.md")
+	if err != nil {
+		t.Fatalf("ParsePage: %v", err)
+	}
+	if !strings.Contains(string(page.Content), `<p id="install-steps">Text</p>`) {
+		t.Errorf("content = %s", page.Content)
+	}
+}
+
+func TestParseAttributeList(t *testing.T) {
+	tests := []struct {
+		line string
+		ok   bool
+	}{
+		{"{.lead}", true},
+		{"  {#id .a .b}  ", true},
+		{"{: .kramdown}", true},
+		{"{}", false},
+		{"{like this}", false},
+		{"{.lead} trailing", false},
+		{"text {.lead}", false},
+	}
+	for _, tt := range tests {
+		if _, ok := parseAttributeList([]byte(tt.line)); ok != tt.ok {
+			t.Errorf("parseAttributeList(%q) ok = %v, want %v", tt.line, ok, tt.ok)
+		}
+	}
+}
@@ -8,24 +8,57 @@ import (
 // Page represents a parsed markdown page.
 type Page struct {
 	// Frontmatter fields
-	Title       string   `yaml:"title"`
-	Description string   `yaml:"description"`
-	Section     string   `yaml:"section"`
-	Weight      int      `yaml:"weight"`
-	Draft       bool     `yaml:"draft"`
-	Icon        string   `yaml:"icon"`
-	Date        string   `yaml:"date"`
-	Author      string   `yaml:"author"`
-	Tags        []string `yaml:"tags"`
+	Title       string         `yaml:"title"`
+	Description string         `yaml:"description"`
+	Section     string         `yaml:"section"`
+	Weight      int            `yaml:"weight"`
+	Draft       bool           `yaml:"draft"`
+	Icon        string         `yaml:"icon"`
+	Date        string         `yaml:"date"`
+	Author      string         `yaml:"author"`
+	Tags        TermNames      `yaml:"tags"`
+	Lastmod     string         `yaml:"lastmod"`
+	PublishDate string         `yaml:"publishDate"`
+	ExpiryDate  string         `yaml:"expiryDate"`
+	Sitemap     SitemapOptions `yaml:"sitemap"`
+	TOC         TOCOptions     `yaml:"toc"`
+	Math        bool           `yaml:"math"`
 
 	// Computed fields
-	Content    template.HTML // Rendered HTML from markdown
-	Path       string        // URL path (e.g., "/docs/tools/nbc/")
-	SourcePath string        // Filesystem path to the .md file
-	Slug       string        // URL-friendly name derived from filename
-	IsIndex    bool          // True if this is an _index.md file
-	ParsedDate time.Time     // Parsed from Date string
-	Headings   []Heading     // Extracted headings for TOC
+	Content       template.HTML      // Rendered HTML from markdown, without the footnotes
+	Footnotes     template.HTML      // Rendered footnote list; empty if the page has no footnotes
+	Path          string             // URL path (e.g., "/docs/tools/nbc/")
+	SourcePath    string             // Filesystem path to the .md file
+	Slug          string             // URL-friendly name derived from filename
+	IsIndex       bool               // True if this is an _index.md file
+	ParsedDate    time.Time          // Parsed from Date string
+	ParsedLastmod time.Time          // Parsed from Lastmod string
+	ParsedPublish time.Time          // Parsed from PublishDate string
+	ParsedExpiry  time.Time          // Parsed from ExpiryDate string
+	ModTime       time.Time          // Modification time of the source file
+	Headings      []Heading          // Extracted headings for TOC
+	PrevPost      *Page              // Next older blog post (nil for the oldest post and non-posts)
+	NextPost      *Page              // Next newer blog post (nil for the newest post and non-posts)
+	Terms         map[string][]*Term // Taxonomy terms used by this page, keyed by taxonomy name
+	Params        map[string]any     `yaml:"-"` // All frontmatter, including custom params
+	Mermaid       bool               // Has mermaid diagrams for mermaid.js to render in the browser
+
+	links []pageLink // Links to other .md files, checked by CheckLinks
+}
+
+// PublishTime returns when the page is published: its publishDate, falling
+// back to its date. The zero time means the page is always published.
+func (p *Page) PublishTime() time.Time {
+	if !p.ParsedPublish.IsZero() {
+		return p.ParsedPublish
+	}
+	return p.ParsedDate
+}
+
+// ContentWithFootnotes returns Content followed by Footnotes, for places
+// that show the whole page body in one piece, such as blog posts and feeds.
+func (p *Page) ContentWithFootnotes() template.HTML {
+	return p.Content + p.Footnotes
 }
 
 // Heading represents a heading extracted from markdown for TOC generation.
@@ -45,11 +78,40 @@ type Section struct {
 	Subsections []*Section
 	IndexPage   *Page // The _index.md page for this section
 	Weight      int
+	Params      map[string]any // All frontmatter of the _index.md page
+}
+
+// Taxonomy groups pages by the values of one frontmatter field (e.g., tags).
+type Taxonomy struct {
+	Name     string  // Frontmatter key (e.g., "tags")
+	Title    string  // Plural display name (e.g., "Tags")
+	Singular string  // Singular display name (e.g., "Tag")
+	Path     string  // URL path of the taxonomy's index page (e.g., "/tags/")
+	Terms    []*Term // Terms sorted by slug
+}
+
+// Term is a single taxonomy value (e.g., one tag) and the pages that use it.
+type Term struct {
+	Name  string  // Display name, as first written in frontmatter
+	Slug  string  // URL-friendly name
+	Path  string  // URL path of the term's listing page (e.g., "/tags/release/")
+	Pages []*Page // Pages using this term, newest first
 }
 
 // Site holds all parsed content for the site.
 type Site struct {
-	Pages    []*Page
-	Sections []*Section
-	Posts    []*Page // Blog posts, sorted by date descending
+	Pages      []*Page
+	Sections   []*Section
+	Posts      []*Page     // Blog posts, sorted by date descending
+	Taxonomies []*Taxonomy // In the order they are configured
+}
+
+// Taxonomy returns the taxonomy with the given name, or nil if it is not configured.
+func (s *Site) Taxonomy(name string) *Taxonomy {
+	for _, tax := range s.Taxonomies {
+		if tax.Name == name {
+			return tax
+		}
+	}
+	return nil
 }
@@ -0,0 +1,268 @@
+package content
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// DiagramRenderer turns the source of a diagram into HTML, usually an inline
+// SVG. Renderers are used by concurrent page parses and must be safe for
+// concurrent use.
+type DiagramRenderer interface {
+	RenderDiagram(source []byte) (string, error)
+}
+
+// CommandRenderer renders diagrams with an external program, which reads
+// the diagram on stdin and writes SVG to stdout.
+type CommandRenderer struct {
+	Command string
+	Args    []string
+	Timeout time.Duration // Limit on each run; 0 for none
+}
+
+func (r CommandRenderer) RenderDiagram(source []byte) (string, error) {
+	path, err := exec.LookPath(r.Command)
+	if err != nil {
+		return "", fmt.Errorf("%s is not installed", r.Command)
+	}
+
+	ctx := context.Background()
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, r.Args...)
+	cmd.Stdin = bytes.NewReader(source)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Children left behind (mmdc's browser) may hold the output pipes open
+	cmd.WaitDelay = time.Second
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("%s did not finish within %s", r.Command, r.Timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %s", r.Command, msg)
+		}
+		return "", fmt.Errorf("running %s: %w", r.Command, err)
+	}
+
+	// Drop the XML declaration and doctype some programs write
+	svg := stdout.Bytes()
+	start := bytes.Index(svg, []byte("<svg"))
+	if start < 0 {
+		return "", fmt.Errorf("%s wrote no SVG", r.Command)
+	}
+	return string(bytes.TrimSpace(svg[start:])), nil
+}
+
+var (
+	svgIDRE       = regexp.MustCompile(`\sid="([^"]+)"`)
+	svgStyleRE    = regexp.MustCompile(`(?s)<style\b[^>]*>.*?</style>`)
+	svgSelectorRE = regexp.MustCompile(`#([\w-]+)`)
+	svgRefRE      = regexp.MustCompile(`url\(\s*['"]?#([^'")\s]+)|\s(?:xlink:)?href="#([^"]+)"`)
+)
+
+// prefixSVGIDs adds prefix to the IDs defined in svg and to the references to
+// them: url(#id), href="#id" and xlink:href="#id" anywhere, and #id selectors
+// in its stylesheets. Other text, such as a "#main" label, is left alone.
+func prefixSVGIDs(svg, prefix string) string {
+	ids := make(map[string]bool)
+	for _, m := range svgIDRE.FindAllStringSubmatch(svg, -1) {
+		ids[m[1]] = true
+	}
+	if len(ids) == 0 {
+		return svg
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range svgStyleRE.FindAllStringIndex(svg, -1) {
+		b.WriteString(prefixSVGRefs(svgRefRE, svg[last:loc[0]], prefix, ids))
+		b.WriteString(prefixSVGRefs(svgSelectorRE, svg[loc[0]:loc[1]], prefix, ids))
+		last = loc[1]
+	}
+	b.WriteString(prefixSVGRefs(svgRefRE, svg[last:], prefix, ids))
+
+	return svgIDRE.ReplaceAllStringFunc(b.String(), func(attr string) string {
+		i := strings.Index(attr, `id="`) + len(`id="`)
+		return attr[:i] + prefix + attr[i:]
+	})
+}
+
+// prefixSVGRefs adds prefix to the IDs in s that re captures, if they are
+// among ids.
+func prefixSVGRefs(re *regexp.Regexp, s, prefix string, ids map[string]bool) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(s, -1) {
+		for g := 2; g < len(m); g += 2 {
+			if m[g] >= 0 && ids[s[m[g]:m[g+1]]] {
+				b.WriteString(s[last:m[g]])
+				b.WriteString(prefix)
+				last = m[g]
+			}
+		}
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// ClientRenderer leaves diagrams as their source in a <pre> element with
+// Class, for a script in the browser to render.
+type ClientRenderer struct {
+	Class string
+}
+
+func (r ClientRenderer) RenderDiagram(source []byte) (string, error) {
+	return fmt.Sprintf(`<pre class="%s">%s</pre>`, r.Class, html.EscapeString(string(source))), nil
+}
+
+// mermaidClass marks diagrams that mermaid.js renders in the browser.
+const mermaidClass = "mermaid"
+
+// diagramCommands are the programs WithDiagrams runs for the diagram
+// languages set to config.DiagramCommand.
+var diagramCommands = map[string]CommandRenderer{
+	"graphviz": {Command: "dot", Args: []string{"-Tsvg"}},
+	"mermaid":  {Command: "mmdc", Args: []string{"--input", "-", "--output", "-", "--outputFormat", "svg"}},
+	"d2":       {Command: "d2", Args: []string{"-", "-"}},
+}
+
+// The default renderers need no external programs; see WithDiagrams.
+var (
+	diagramsMu sync.RWMutex
+	diagrams   = map[string]DiagramRenderer{
+		"mermaid":  ClientRenderer{Class: mermaidClass},
+		"d2":       ClientRenderer{Class: "diagram-source"},
+		"graphviz": dotRenderer{},
+		"dot":      dotRenderer{},
+	}
+)
+
+// RegisterDiagramRenderer makes fenced code blocks in lang render as
+// diagrams with r, replacing any renderer registered for lang before.
+func RegisterDiagramRenderer(lang string, r DiagramRenderer) {
+	diagramsMu.Lock()
+	defer diagramsMu.Unlock()
+	diagrams[lang] = r
+}
+
+func lookupDiagramRenderer(lang string) (DiagramRenderer, bool) {
+	diagramsMu.RLock()
+	defer diagramsMu.RUnlock()
+	r, ok := diagrams[lang]
+	return r, ok
+}
+
+// kindDiagram is the AST node kind of diagrams.
+var kindDiagram = ast.NewNodeKind("Diagram")
+
+// diagramNode is a fenced code block rendered as a diagram.
+type diagramNode struct {
+	ast.BaseBlock
+	Lang string
+	HTML string
+}
+
+func (n *diagramNode) Kind() ast.NodeKind { return kindDiagram }
+
+func (n *diagramNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Lang": n.Lang}, nil)
+}
+
+// diagramExtension renders fenced code blocks in a diagram language, such
+// as ```mermaid, with the language's DiagramRenderer instead of highlighting
+// them as code.
+type diagramExtension struct{}
+
+func (diagramExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithASTTransformers(util.Prioritized(diagramTransformer{}, 400)),
+	)
+	m.Renderer().AddOptions(
+		renderer.WithNodeRenderers(util.Prioritized(diagramRenderer{}, 100)),
+	)
+}
+
+// diagramTransformer replaces diagram code blocks with rendered diagrams.
+// Programs reuse the same SVG IDs (such as mmdc's my-svg) for every diagram,
+// so the IDs of each diagram get a prefix made of a hash of its source and
+// its position on the page.
+type diagramTransformer struct{}
+
+func (diagramTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+
+	var blocks []*ast.FencedCodeBlock
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if b, ok := n.(*ast.FencedCodeBlock); ok && entering {
+			blocks = append(blocks, b)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	n := 0
+	for _, block := range blocks {
+		lang := string(block.Language(source))
+		r, ok := contextRenderer(pc).diagramRenderer(lang)
+		if !ok || block.Lines().Len() == 0 {
+			continue
+		}
+
+		var code bytes.Buffer
+		for i := 0; i < block.Lines().Len(); i++ {
+			segment := block.Lines().At(i)
+			code.Write(segment.Value(source))
+		}
+		out, err := r.RenderDiagram(code.Bytes())
+		if err != nil {
+			// The fence is the line before the first line of code
+			line := bytes.Count(source[:block.Lines().At(0).Start], []byte("\n"))
+			setMarkdownError(pc, line, fmt.Sprintf("%s diagram: %v", lang, err))
+			continue
+		}
+
+		n++
+		sum := sha256.Sum256(code.Bytes())
+		prefix := "diagram-" + hex.EncodeToString(sum[:4]) + "-" + strconv.Itoa(n) + "-"
+		block.Parent().ReplaceChild(block.Parent(), block, &diagramNode{Lang: lang, HTML: prefixSVGIDs(out, prefix)})
+	}
+}
+
+// diagramRenderer renders diagrams in a <figure>, styled in input.css.
+type diagramRenderer struct{}
+
+func (diagramRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindDiagram, renderDiagram)
+}
+
+func renderDiagram(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*diagramNode)
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	_, _ = fmt.Fprintf(w, `<figure class="diagram diagram-%s">`+"\n%s\n</figure>\n", n.Lang, n.HTML)
+	return ast.WalkSkipChildren, nil
+}
@@ -0,0 +1,120 @@
+package content
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testDiagramRenderer struct{}
+
+func (testDiagramRenderer) RenderDiagram(source []byte) (string, error) {
+	if strings.Contains(string(source), "fail") {
+		return "", errors.New("bad diagram")
+	}
+	return "<svg>" + strings.TrimSpace(string(source)) + "</svg>", nil
+}
+
+func init() {
+	RegisterDiagramRenderer("test-diagram", testDiagramRenderer{})
+}
+
+func TestParseDiagrams(t *testing.T) {
+	input := "---\ntitle: Diagrams\n---\n\n```test-diagram\na -> b\n```\n\n```go\nfunc main() {}\n```\n"
+	page, err := ParsePage([]byte(input), "content/docs/a.md")
+	if err != nil {
+		t.Fatalf("ParsePage returned error: %v", err)
+	}
+	html := string(page.Content)
+
+	if want := "<figure class=\"diagram diagram-test-diagram\">\n<svg>a -> b</svg>\n</figure>"; !strings.Contains(html, want) {
+		t.Errorf("expected %q in:\n%s", want, html)
+	}
+	if !strings.Contains(html, "<pre") || !strings.Contains(html, "main") {
+		t.Errorf("expected the go block to stay a code block:\n%s", html)
+	}
+	if page.Mermaid {
+		t.Error("Mermaid = true for a page without mermaid diagrams")
+	}
+}
+
+func TestParseDiagramError(t *testing.T) {
+	input := "---\ntitle: Diagrams\n---\n\nText.\n\n```test-diagram\nfail\n```\n"
+	_, err := ParsePage([]byte(input), "content/docs/a.md")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if want := "line 7: test-diagram diagram: bad diagram"; !strings.Contains(err.Error(), want) {
+		t.Errorf("error = %q, want it to contain %q", err, want)
+	}
+}
+
+func TestCommandRenderer(t *testing.T) {
+	// A shell copying stdin with builtins, since the tests may run with an
+	// empty PATH
+	r := CommandRenderer{Command: "/bin/sh", Args: []string{"-c", `while IFS= read -r l; do printf '%s\n' "$l"; done`}}
+	out, err := r.RenderDiagram([]byte("<?xml version=\"1.0\"?>\n<svg><g/></svg>\n"))
+	if err != nil {
+		t.Fatalf("RenderDiagram returned error: %v", err)
+	}
+	if want := "<svg><g/></svg>"; out != want {
+		t.Errorf("RenderDiagram = %q, want %q", out, want)
+	}
+
+	r.Command = "frostyard-no-such-renderer"
+	if _, err := r.RenderDiagram([]byte("graph TD")); err == nil || !strings.Contains(err.Error(), "not installed") {
+		t.Errorf("error = %v, want not installed", err)
+	}
+}
+
+type echoDiagram struct{}
+
+func (echoDiagram) RenderDiagram(source []byte) (string, error) {
+	return strings.TrimSpace(string(source)), nil
+}
+
+func TestParseDiagramIDs(t *testing.T) {
+	RegisterDiagramRenderer("test-echo", echoDiagram{})
+	svg := `<svg id="my-svg"><style>#my-svg .node{fill:#fff}</style><marker id="arrow"/><path marker-end="url(#arrow)"/><use href="#arrow"/><use xlink:href="#main"/><g id="main"><text>#main and #arrow</text></g></svg>`
+	input := "```test-echo\n" + svg + "\n```\n\n```test-echo\n" + svg + "\n```\n"
+	page, err := ParsePage([]byte(input), "content/docs/a.md")
+	if err != nil {
+		t.Fatalf("ParsePage returned error: %v", err)
+	}
+	html := string(page.Content)
+
+	start := strings.Index(html, `<svg id="`) + len(`<svg id="`)
+	p := html[start : start+strings.Index(html[start:], "my-svg")]
+	want := `<svg id="` + p + `my-svg"><style>#` + p + `my-svg .node{fill:#fff}</style><marker id="` + p + `arrow"/><path marker-end="url(#` + p + `arrow)"/><use href="#` + p + `arrow"/><use xlink:href="#` + p + `main"/><g id="` + p + `main"><text>#main and #arrow</text></g></svg>`
+	if !strings.Contains(html, want) {
+		t.Errorf("expected %q in:\n%s", want, html)
+	}
+	// The same diagram twice on a page still gets distinct IDs
+	if got := strings.Count(html, `id="`+p+`arrow"`); got != 1 {
+		t.Errorf("id %q appears %d times, want once:\n%s", p+"arrow", got, html)
+	}
+	if got := strings.Count(html, `<marker id="diagram-`); got != 2 {
+		t.Errorf("%d prefixed markers, want 2:\n%s", got, html)
+	}
+}
+
+func TestCommandRendererTimeout(t *testing.T) {
+	r := CommandRenderer{Command: "/bin/sh", Args: []string{"-c", "while :; do :; done"}, Timeout: 50 * time.Millisecond}
+	_, err := r.RenderDiagram([]byte("graph TD"))
+	if err == nil || !strings.Contains(err.Error(), "did not finish within 50ms") {
+		t.Errorf("error = %v, want a timeout", err)
+	}
+}
+
+func TestParseMermaidClientSide(t *testing.T) {
+	RegisterDiagramRenderer("test-mermaid", ClientRenderer{Class: mermaidClass})
+	input := "---\ntitle: Mermaid\n---\n\n```test-mermaid\ngraph TD\n  A-->B\n```\n"
+	page, err := ParsePage([]byte(input), "content/docs/a.md")
+	if err != nil {
+		t.Fatalf("ParsePage returned error: %v", err)
+	}
+	if !page.Mermaid {
+		t.Errorf("Mermaid = false for a page with a client-side mermaid diagram:\n%s", page.Content)
+	}
+}
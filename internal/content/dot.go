@@ -0,0 +1,1083 @@
+package content
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// dotRenderer renders Graphviz DOT diagrams as SVG without Graphviz. It
+// supports the common subset of the language: graph and digraph, node and
+// edge statements with attributes, default attributes, subgraphs (drawn
+// without their boxes) and the rankdir, label, shape, style, color,
+// fillcolor, fontcolor and dir attributes. Nodes are laid out in ranks,
+// following the edges, like Graphviz's dot.
+type dotRenderer struct{}
+
+func (dotRenderer) RenderDiagram(source []byte) (string, error) {
+	g, err := parseDOT(string(source))
+	if err != nil {
+		return "", err
+	}
+	if len(g.nodes) == 0 {
+		return "", fmt.Errorf("dot: graph has no nodes")
+	}
+	return layoutDOT(g).svg(), nil
+}
+
+// dotGraph is a parsed DOT graph.
+type dotGraph struct {
+	directed bool
+	attrs    map[string]string
+	nodes    []*dotNode
+	byID     map[string]*dotNode
+	edges    []*dotEdge
+}
+
+type dotNode struct {
+	id    string
+	attrs map[string]string
+}
+
+type dotEdge struct {
+	from, to *dotNode
+	attrs    map[string]string
+}
+
+// dotToken is a token of the DOT language. Kind is 'i' for IDs, '-' for
+// edge operators and the character itself for punctuation.
+type dotToken struct {
+	kind  byte
+	value string
+	line  int
+}
+
+var dotNumeralRE = regexp.MustCompile(`^-?(\.[0-9]+|[0-9]+(\.[0-9]*)?)`)
+
+// lexDOT splits src into tokens, dropping comments.
+func lexDOT(src string) ([]dotToken, error) {
+	var tokens []dotToken
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '#' || strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("dot: line %d: unterminated comment", line)
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+		case strings.HasPrefix(src[i:], "->") || strings.HasPrefix(src[i:], "--"):
+			tokens = append(tokens, dotToken{kind: '-', value: src[i : i+2], line: line})
+			i += 2
+		case strings.ContainsRune("{}[];,=:", rune(c)):
+			tokens = append(tokens, dotToken{kind: c, value: string(c), line: line})
+			i++
+		case c == '"':
+			var b strings.Builder
+			start := line
+			i++
+			for ; i < len(src) && src[i] != '"'; i++ {
+				if src[i] == '\\' && i+1 < len(src) && src[i+1] == '"' {
+					i++
+				} else if src[i] == '\n' {
+					line++
+				}
+				b.WriteByte(src[i])
+			}
+			if i >= len(src) {
+				return nil, fmt.Errorf("dot: line %d: unterminated string", start)
+			}
+			i++
+			tokens = append(tokens, dotToken{kind: 'i', value: b.String(), line: start})
+		case c == '<':
+			// HTML-like labels are drawn as their text
+			depth, j := 0, i
+			for ; j < len(src); j++ {
+				if src[j] == '<' {
+					depth++
+				} else if src[j] == '>' {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("dot: line %d: unterminated HTML string", line)
+			}
+			value := html.UnescapeString(htmlTagRE.ReplaceAllString(src[i+1:j], ""))
+			tokens = append(tokens, dotToken{kind: 'i', value: value, line: line})
+			line += strings.Count(src[i:j], "\n")
+			i = j + 1
+		default:
+			if m := dotNumeralRE.FindString(src[i:]); m != "" {
+				tokens = append(tokens, dotToken{kind: 'i', value: m, line: line})
+				i += len(m)
+				continue
+			}
+			j := i
+			for j < len(src) {
+				r, size := utf8.DecodeRuneInString(src[j:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				j += size
+			}
+			if j == i {
+				return nil, fmt.Errorf("dot: line %d: unexpected %q", line, c)
+			}
+			tokens = append(tokens, dotToken{kind: 'i', value: src[i:j], line: line})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+var htmlTagRE = regexp.MustCompile(`<[^>]*>`)
+
+// dotParser is a recursive descent parser for DOT.
+type dotParser struct {
+	tokens []dotToken
+	pos    int
+	graph  *dotGraph
+}
+
+// dotScope holds the attributes and default node and edge attributes of a
+// graph or subgraph.
+type dotScope struct {
+	graph, node, edge map[string]string
+}
+
+func parseDOT(src string) (*dotGraph, error) {
+	tokens, err := lexDOT(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &dotParser{
+		tokens: tokens,
+		graph:  &dotGraph{attrs: map[string]string{}, byID: map[string]*dotNode{}},
+	}
+
+	if p.keyword("strict") {
+		p.pos++
+	}
+	switch {
+	case p.keyword("digraph"):
+		p.graph.directed = true
+	case p.keyword("graph"):
+	default:
+		return nil, p.errorf("expected graph or digraph")
+	}
+	p.pos++
+	if t := p.peek(); t.kind == 'i' {
+		p.pos++
+	}
+	if err := p.expect('{'); err != nil {
+		return nil, err
+	}
+	if _, err := p.stmts(dotScope{graph: p.graph.attrs, node: map[string]string{}, edge: map[string]string{}}); err != nil {
+		return nil, err
+	}
+	if err := p.expect('}'); err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, p.errorf("unexpected %q after the graph", p.peek().value)
+	}
+	return p.graph, nil
+}
+
+func (p *dotParser) peek() dotToken {
+	if p.pos >= len(p.tokens) {
+		line := 1
+		if len(p.tokens) > 0 {
+			line = p.tokens[len(p.tokens)-1].line
+		}
+		return dotToken{line: line}
+	}
+	return p.tokens[p.pos]
+}
+
+// keyword reports whether the next token is the case-insensitive keyword.
+func (p *dotParser) keyword(kw string) bool {
+	t := p.peek()
+	return t.kind == 'i' && strings.EqualFold(t.value, kw)
+}
+
+func (p *dotParser) expect(kind byte) error {
+	if p.peek().kind != kind {
+		return p.errorf("expected %q", kind)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *dotParser) errorf(format string, args ...any) error {
+	t := p.peek()
+	msg := fmt.Sprintf(format, args...)
+	if t.kind == 0 {
+		return fmt.Errorf("dot: line %d: %s at end of input", t.line, msg)
+	}
+	return fmt.Errorf("dot: line %d: %s, found %q", t.line, msg, t.value)
+}
+
+// stmts parses statements up to a closing brace and returns the nodes they
+// mention, so that edges can connect to a whole subgraph.
+func (p *dotParser) stmts(scope dotScope) ([]*dotNode, error) {
+	var nodes []*dotNode
+	for p.peek().kind != '}' && p.peek().kind != 0 {
+		stmtNodes, err := p.stmt(&scope)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, stmtNodes...)
+		if p.peek().kind == ';' || p.peek().kind == ',' {
+			p.pos++
+		}
+	}
+	return nodes, nil
+}
+
+func (p *dotParser) stmt(scope *dotScope) ([]*dotNode, error) {
+	switch {
+	case p.keyword("graph") || p.keyword("node") || p.keyword("edge"):
+		kind := strings.ToLower(p.peek().value)
+		p.pos++
+		attrs, err := p.attrList()
+		if err != nil {
+			return nil, err
+		}
+		target := scope.graph
+		if kind == "node" {
+			target = scope.node
+		} else if kind == "edge" {
+			target = scope.edge
+		}
+		for k, v := range attrs {
+			target[k] = v
+		}
+		return nil, nil
+	case p.peek().kind == 'i' && !p.keyword("subgraph") && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].kind == '=':
+		key := p.peek().value
+		p.pos += 2
+		if p.peek().kind != 'i' {
+			return nil, p.errorf("expected a value for %s", key)
+		}
+		scope.graph[key] = p.peek().value
+		p.pos++
+		return nil, nil
+	}
+
+	left, err := p.operand(scope)
+	if err != nil {
+		return nil, err
+	}
+	all := left
+	var edges [][2][]*dotNode
+	for p.peek().kind == '-' {
+		op := p.peek().value
+		if (op == "->") != p.graph.directed {
+			return nil, p.errorf("wrong edge operator for this graph")
+		}
+		p.pos++
+		right, err := p.operand(scope)
+		if err != nil {
+			return nil, err
+		}
+		edges = append(edges, [2][]*dotNode{left, right})
+		all = append(all, right...)
+		left = right
+	}
+
+	attrs, err := p.attrList()
+	if err != nil {
+		return nil, err
+	}
+	if len(edges) == 0 {
+		// A node statement; its attributes apply to the node
+		for _, n := range all {
+			for k, v := range attrs {
+				n.attrs[k] = v
+			}
+		}
+		return all, nil
+	}
+	for _, e := range edges {
+		for _, from := range e[0] {
+			for _, to := range e[1] {
+				edgeAttrs := make(map[string]string, len(scope.edge)+len(attrs))
+				for k, v := range scope.edge {
+					edgeAttrs[k] = v
+				}
+				for k, v := range attrs {
+					edgeAttrs[k] = v
+				}
+				p.graph.edges = append(p.graph.edges, &dotEdge{from: from, to: to, attrs: edgeAttrs})
+			}
+		}
+	}
+	return all, nil
+}
+
+// operand parses a node ID (with an optional port, which is ignored) or a
+// subgraph.
+func (p *dotParser) operand(scope *dotScope) ([]*dotNode, error) {
+	if p.keyword("subgraph") || p.peek().kind == '{' {
+		if p.keyword("subgraph") {
+			p.pos++
+			if p.peek().kind == 'i' {
+				p.pos++
+			}
+		}
+		if err := p.expect('{'); err != nil {
+			return nil, err
+		}
+		inner := dotScope{graph: map[string]string{}, node: copyAttrs(scope.node), edge: copyAttrs(scope.edge)}
+		nodes, err := p.stmts(inner)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect('}'); err != nil {
+			return nil, err
+		}
+		return nodes, nil
+	}
+
+	t := p.peek()
+	if t.kind != 'i' {
+		return nil, p.errorf("expected a node")
+	}
+	p.pos++
+	for p.peek().kind == ':' {
+		p.pos++
+		if p.peek().kind != 'i' {
+			return nil, p.errorf("expected a port")
+		}
+		p.pos++
+	}
+
+	n, ok := p.graph.byID[t.value]
+	if !ok {
+		n = &dotNode{id: t.value, attrs: copyAttrs(scope.node)}
+		p.graph.byID[t.value] = n
+		p.graph.nodes = append(p.graph.nodes, n)
+	}
+	return []*dotNode{n}, nil
+}
+
+// attrList parses any number of [key=value, ...] lists.
+func (p *dotParser) attrList() (map[string]string, error) {
+	attrs := map[string]string{}
+	for p.peek().kind == '[' {
+		p.pos++
+		for p.peek().kind != ']' {
+			if p.peek().kind != 'i' {
+				return nil, p.errorf("expected an attribute")
+			}
+			key := p.peek().value
+			p.pos++
+			if err := p.expect('='); err != nil {
+				return nil, err
+			}
+			if p.peek().kind != 'i' {
+				return nil, p.errorf("expected a value for %s", key)
+			}
+			attrs[key] = p.peek().value
+			p.pos++
+			if p.peek().kind == ',' || p.peek().kind == ';' {
+				p.pos++
+			}
+		}
+		p.pos++
+	}
+	return attrs, nil
+}
+
+func copyAttrs(attrs map[string]string) map[string]string {
+	c := make(map[string]string, len(attrs))
+	for k, v := range attrs {
+		c[k] = v
+	}
+	return c
+}
+
+// Layout sizes, in pixels.
+const (
+	dotFontSize   = 14
+	dotLineHeight = 18
+	dotNodeSep    = 28 // Between neighbouring nodes of a rank
+	dotRankSep    = 48 // Between ranks
+	dotMargin     = 12
+	dotArrowSize  = 9
+)
+
+// dotBox is a node of the layout. Boxes without a node route edges that
+// span several ranks.
+type dotBox struct {
+	node  *dotNode
+	shape string
+	lines []string
+	rank  int
+	order float64
+	w, h  float64 // Size in the final drawing
+	x, y  float64 // Center in the final drawing
+}
+
+// dotLayout is a graph with positions for its nodes and edges.
+type dotLayout struct {
+	graph  *dotGraph
+	boxes  map[*dotNode]*dotBox
+	paths  [][]*dotBox // Per edge, the boxes it passes through, in edge direction
+	width  float64
+	height float64
+	title  []string
+}
+
+// layoutDOT places the nodes of g in ranks so that edges point down (or
+// right, with rankdir=LR), orders each rank to reduce crossings and
+// positions nodes near their neighbours.
+func layoutDOT(g *dotGraph) *dotLayout {
+	l := &dotLayout{graph: g, boxes: make(map[*dotNode]*dotBox, len(g.nodes))}
+	horizontal := strings.EqualFold(g.attrs["rankdir"], "LR") || strings.EqualFold(g.attrs["rankdir"], "RL")
+
+	for _, n := range g.nodes {
+		l.boxes[n] = newDOTBox(n)
+	}
+
+	// Rank by longest path, ignoring the edges that close a cycle
+	back := dotBackEdges(g)
+	indeg := make(map[*dotNode]int)
+	out := make(map[*dotNode][]*dotNode)
+	for i, e := range g.edges {
+		if e.from == e.to {
+			continue
+		}
+		from, to := e.from, e.to
+		if back[i] {
+			from, to = to, from
+		}
+		out[from] = append(out[from], to)
+		indeg[to]++
+	}
+	var queue []*dotNode
+	for _, n := range g.nodes {
+		if indeg[n] == 0 {
+			queue = append(queue, n)
+		}
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, m := range out[n] {
+			if r := l.boxes[n].rank + 1; r > l.boxes[m].rank {
+				l.boxes[m].rank = r
+			}
+			if indeg[m]--; indeg[m] == 0 {
+				queue = append(queue, m)
+			}
+		}
+	}
+
+	maxRank := 0
+	for _, b := range l.boxes {
+		maxRank = max(maxRank, b.rank)
+	}
+	ranks := make([][]*dotBox, maxRank+1)
+	for _, n := range g.nodes {
+		b := l.boxes[n]
+		ranks[b.rank] = append(ranks[b.rank], b)
+	}
+
+	// Route edges through a box on every rank they cross
+	up := map[*dotBox][]*dotBox{}
+	down := map[*dotBox][]*dotBox{}
+	for i, e := range g.edges {
+		from, to := l.boxes[e.from], l.boxes[e.to]
+		if back[i] {
+			from, to = to, from
+		}
+		path := []*dotBox{from}
+		if from != to {
+			for r := from.rank + 1; r < to.rank; r++ {
+				v := &dotBox{rank: r}
+				ranks[r] = append(ranks[r], v)
+				path = append(path, v)
+			}
+			path = append(path, to)
+			for j := 1; j < len(path); j++ {
+				down[path[j-1]] = append(down[path[j-1]], path[j])
+				up[path[j]] = append(up[path[j]], path[j-1])
+			}
+		}
+		if back[i] {
+			for a, b := 0, len(path)-1; a < b; a, b = a+1, b-1 {
+				path[a], path[b] = path[b], path[a]
+			}
+		}
+		l.paths = append(l.paths, path)
+	}
+
+	// Reduce crossings by sorting ranks by the mean order of neighbours
+	for _, rank := range ranks {
+		for i, b := range rank {
+			b.order = float64(i)
+		}
+	}
+	for sweep := 0; sweep < 8; sweep++ {
+		neighbours := up
+		indices := make([]int, 0, len(ranks))
+		for r := 1; r < len(ranks); r++ {
+			indices = append(indices, r)
+		}
+		if sweep%2 == 1 {
+			neighbours = down
+			indices = indices[:0]
+			for r := len(ranks) - 2; r >= 0; r-- {
+				indices = append(indices, r)
+			}
+		}
+		for _, r := range indices {
+			rank := ranks[r]
+			bary := make(map[*dotBox]float64, len(rank))
+			for _, b := range rank {
+				bary[b] = b.order
+				if ns := neighbours[b]; len(ns) > 0 {
+					sum := 0.0
+					for _, n := range ns {
+						sum += n.order
+					}
+					bary[b] = sum / float64(len(ns))
+				}
+			}
+			sort.SliceStable(rank, func(i, j int) bool { return bary[rank[i]] < bary[rank[j]] })
+			for i, b := range rank {
+				b.order = float64(i)
+			}
+		}
+	}
+
+	// Sizes along the rank (across edges) and between ranks (along edges)
+	across := func(b *dotBox) float64 {
+		if horizontal {
+			return b.h
+		}
+		return b.w
+	}
+	along := func(b *dotBox) float64 {
+		if horizontal {
+			return b.w
+		}
+		return b.h
+	}
+	sep := func(a, b *dotBox) float64 {
+		if a.node == nil || b.node == nil {
+			return (across(a)+across(b))/2 + dotNodeSep/2
+		}
+		return (across(a)+across(b))/2 + dotNodeSep
+	}
+
+	// Positions across ranks: place each node near the mean position of its
+	// neighbours, keeping the order and spacing of the rank
+	pos := map[*dotBox]float64{}
+	for _, rank := range ranks {
+		p := 0.0
+		for i, b := range rank {
+			if i > 0 {
+				p += sep(rank[i-1], b)
+			}
+			pos[b] = p
+		}
+	}
+	for pass := 0; pass < 4; pass++ {
+		neighbours := up
+		order := make([]int, len(ranks))
+		for i := range order {
+			order[i] = i
+		}
+		if pass%2 == 1 {
+			neighbours = down
+			for i := range order {
+				order[i] = len(ranks) - 1 - i
+			}
+		}
+		for _, r := range order {
+			rank := ranks[r]
+			want := make([]float64, len(rank))
+			for i, b := range rank {
+				want[i] = pos[b]
+				if ns := neighbours[b]; len(ns) > 0 {
+					sum := 0.0
+					for _, n := range ns {
+						sum += pos[n]
+					}
+					want[i] = sum / float64(len(ns))
+				}
+			}
+			// Average a left-to-right and a right-to-left packing, which
+			// both keep the spacing
+			left := make([]float64, len(rank))
+			right := make([]float64, len(rank))
+			for i := range rank {
+				left[i] = want[i]
+				if i > 0 {
+					left[i] = math.Max(want[i], left[i-1]+sep(rank[i-1], rank[i]))
+				}
+			}
+			for i := len(rank) - 1; i >= 0; i-- {
+				right[i] = want[i]
+				if i < len(rank)-1 {
+					right[i] = math.Min(want[i], right[i+1]-sep(rank[i], rank[i+1]))
+				}
+			}
+			for i, b := range rank {
+				pos[b] = (left[i] + right[i]) / 2
+			}
+		}
+	}
+
+	minPos, maxPos := math.Inf(1), math.Inf(-1)
+	for b, p := range pos {
+		minPos = math.Min(minPos, p-across(b)/2)
+		maxPos = math.Max(maxPos, p+across(b)/2)
+	}
+
+	// Positions along edges: ranks are as thick as their largest node
+	rankSep := float64(dotRankSep)
+	for _, e := range g.edges {
+		if e.attrs["label"] != "" {
+			rankSep += dotLineHeight
+			break
+		}
+	}
+	offset := float64(dotMargin)
+	for _, rank := range ranks {
+		thickness := 0.0
+		for _, b := range rank {
+			thickness = math.Max(thickness, along(b))
+		}
+		for _, b := range rank {
+			a, c := offset+thickness/2, pos[b]-minPos+dotMargin
+			if horizontal {
+				b.x, b.y = a, c
+			} else {
+				b.x, b.y = c, a
+			}
+		}
+		offset += thickness + rankSep
+	}
+	extentAlong := offset - rankSep + dotMargin
+	extentAcross := maxPos - minPos + 2*dotMargin
+
+	if horizontal {
+		l.width, l.height = extentAlong, extentAcross
+	} else {
+		l.width, l.height = extentAcross, extentAlong
+	}
+	if strings.EqualFold(g.attrs["rankdir"], "BT") || strings.EqualFold(g.attrs["rankdir"], "RL") {
+		for _, b := range l.allBoxes() {
+			if horizontal {
+				b.x = l.width - b.x
+			} else {
+				b.y = l.height - b.y
+			}
+		}
+	}
+
+	// Self loops and edge labels may stick out to the right
+	for i, e := range g.edges {
+		right := 0.0
+		if e.from == e.to {
+			b := l.boxes[e.from]
+			right = b.x + b.w/2 + 40
+		}
+		if label := e.attrs["label"]; label != "" {
+			x, _ := l.labelPos(l.paths[i])
+			right = math.Max(right, x+dotTextWidth(dotLabelLines(label)))
+		}
+		l.width = math.Max(l.width, right+dotMargin)
+	}
+
+	if label := g.attrs["label"]; label != "" {
+		l.title = dotLabelLines(label)
+		l.height += float64(len(l.title)*dotLineHeight) + dotMargin
+		l.width = math.Max(l.width, dotTextWidth(l.title)+2*dotMargin)
+	}
+	return l
+}
+
+// allBoxes returns the boxes of nodes and edge routes.
+func (l *dotLayout) allBoxes() []*dotBox {
+	seen := map[*dotBox]bool{}
+	var boxes []*dotBox
+	for _, n := range l.graph.nodes {
+		seen[l.boxes[n]] = true
+		boxes = append(boxes, l.boxes[n])
+	}
+	for _, path := range l.paths {
+		for _, b := range path {
+			if !seen[b] {
+				seen[b] = true
+				boxes = append(boxes, b)
+			}
+		}
+	}
+	return boxes
+}
+
+// dotBackEdges returns the indices of the edges of g that close a cycle,
+// found by a depth-first search in node order.
+func dotBackEdges(g *dotGraph) map[int]bool {
+	out := map[*dotNode][]int{}
+	for i, e := range g.edges {
+		out[e.from] = append(out[e.from], i)
+	}
+	const (
+		unvisited = iota
+		active
+		done
+	)
+	state := map[*dotNode]int{}
+	back := map[int]bool{}
+	var visit func(n *dotNode)
+	visit = func(n *dotNode) {
+		state[n] = active
+		for _, i := range out[n] {
+			to := g.edges[i].to
+			switch state[to] {
+			case active:
+				back[i] = true
+			case unvisited:
+				visit(to)
+			}
+		}
+		state[n] = done
+	}
+	for _, n := range g.nodes {
+		if state[n] == unvisited {
+			visit(n)
+		}
+	}
+	return back
+}
+
+func newDOTBox(n *dotNode) *dotBox {
+	label, ok := n.attrs["label"]
+	if !ok {
+		label = n.id
+	}
+	b := &dotBox{node: n, shape: strings.ToLower(n.attrs["shape"]), lines: dotLabelLines(label)}
+
+	textW := dotTextWidth(b.lines)
+	textH := float64(len(b.lines) * dotLineHeight)
+	square := b.shape == "square"
+	switch b.shape {
+	case "box", "rect", "rectangle", "square", "record", "mrecord", "cylinder", "note", "tab", "folder", "component":
+		b.shape = "box"
+		b.w, b.h = textW+24, textH+16
+	case "plaintext", "plain", "none":
+		b.shape = "none"
+		b.w, b.h = textW+8, textH+4
+	case "circle", "doublecircle":
+		d := math.Max(math.Hypot(textW, textH)+8, 36)
+		b.w, b.h = d, d
+	case "diamond":
+		b.w, b.h = math.Max(textW*2, 48), math.Max(textH*2+8, 36)
+	case "point":
+		b.lines = nil
+		b.w, b.h = 8, 8
+	default:
+		b.shape = "ellipse"
+		b.w, b.h = math.Max(textW*1.42+12, 54), math.Max(textH*1.42+8, 36)
+	}
+	if square {
+		b.w = math.Max(b.w, b.h)
+		b.h = b.w
+	}
+	return b
+}
+
+// dotLabelLines splits a label at the \n, \l and \r escapes of DOT.
+func dotLabelLines(label string) []string {
+	label = strings.NewReplacer(`\l`, "\n", `\r`, "\n", `\n`, "\n").Replace(label)
+	return strings.Split(strings.TrimSuffix(label, "\n"), "\n")
+}
+
+// dotTextWidth estimates the width of the widest line at dotFontSize.
+func dotTextWidth(lines []string) float64 {
+	widest := 0.0
+	for _, line := range lines {
+		w := 0.0
+		for _, r := range line {
+			switch {
+			case r > unicode.MaxLatin1:
+				w += dotFontSize
+			case unicode.IsUpper(r) || r == 'm' || r == 'w':
+				w += dotFontSize * 0.7
+			case r == 'i' || r == 'l' || r == 'j' || r == '.' || r == ',' || r == ' ':
+				w += dotFontSize * 0.3
+			default:
+				w += dotFontSize * 0.55
+			}
+		}
+		widest = math.Max(widest, w)
+	}
+	return widest
+}
+
+// clip returns where the line from the center of b towards (x, y) leaves
+// the shape of b.
+func (b *dotBox) clip(x, y float64) (float64, float64) {
+	dx, dy := x-b.x, y-b.y
+	if b.node == nil || (dx == 0 && dy == 0) {
+		return b.x, b.y
+	}
+	hw, hh := b.w/2, b.h/2
+	var t float64
+	switch b.shape {
+	case "box", "none":
+		t = math.Inf(1)
+		if dx != 0 {
+			t = hw / math.Abs(dx)
+		}
+		if dy != 0 {
+			t = math.Min(t, hh/math.Abs(dy))
+		}
+	case "diamond":
+		t = 1 / (math.Abs(dx)/hw + math.Abs(dy)/hh)
+	default:
+		t = 1 / math.Hypot(dx/hw, dy/hh)
+	}
+	return b.x + dx*t, b.y + dy*t
+}
+
+// svg draws the layout. Strokes and text use currentColor, so diagrams
+// follow the page's light or dark theme unless the graph sets colors.
+func (l *dotLayout) svg() string {
+	var b strings.Builder
+	label := l.graph.attrs["label"]
+	if label == "" {
+		label = "Diagram"
+	}
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %s %s" width="%s" height="%s" role="img" aria-label="%s" font-family="ui-sans-serif, system-ui, sans-serif" font-size="%d">`+"\n",
+		dotNum(l.width), dotNum(l.height), dotNum(l.width), dotNum(l.height), html.EscapeString(strings.Join(dotLabelLines(label), " ")), dotFontSize)
+
+	for i, e := range l.graph.edges {
+		l.svgEdge(&b, e, l.paths[i])
+	}
+	for _, n := range l.graph.nodes {
+		l.svgNode(&b, l.boxes[n])
+	}
+	if len(l.title) > 0 {
+		y := l.height - dotMargin - float64(len(l.title)*dotLineHeight)/2
+		dotText(&b, l.width/2, y, l.title, "currentColor", "middle")
+	}
+	b.WriteString("</svg>")
+	return b.String()
+}
+
+func (l *dotLayout) svgNode(b *strings.Builder, box *dotBox) {
+	attrs := box.node.attrs
+	stroke := dotColor(attrs["color"], "currentColor")
+	fill := "none"
+	if strings.Contains(attrs["style"], "filled") {
+		fill = dotColor(attrs["fillcolor"], dotColor(attrs["color"], "#e2e8f0"))
+	}
+	dash := dotDash(attrs["style"])
+	common := fmt.Sprintf(`fill="%s" stroke="%s" stroke-width="1.5"%s`, fill, stroke, dash)
+	if strings.Contains(attrs["style"], "invis") {
+		return
+	}
+
+	x, y, hw, hh := box.x, box.y, box.w/2, box.h/2
+	switch box.shape {
+	case "box":
+		rx := ""
+		if strings.Contains(attrs["style"], "rounded") {
+			rx = ` rx="6"`
+		}
+		fmt.Fprintf(b, `<rect x="%s" y="%s" width="%s" height="%s"%s %s/>`+"\n", dotNum(x-hw), dotNum(y-hh), dotNum(box.w), dotNum(box.h), rx, common)
+	case "diamond":
+		fmt.Fprintf(b, `<polygon points="%s,%s %s,%s %s,%s %s,%s" %s/>`+"\n",
+			dotNum(x), dotNum(y-hh), dotNum(x+hw), dotNum(y), dotNum(x), dotNum(y+hh), dotNum(x-hw), dotNum(y), common)
+	case "circle", "doublecircle":
+		fmt.Fprintf(b, `<circle cx="%s" cy="%s" r="%s" %s/>`+"\n", dotNum(x), dotNum(y), dotNum(hw), common)
+		if box.shape == "doublecircle" {
+			fmt.Fprintf(b, `<circle cx="%s" cy="%s" r="%s" fill="none" stroke="%s" stroke-width="1.5"/>`+"\n", dotNum(x), dotNum(y), dotNum(hw-4), stroke)
+		}
+	case "point":
+		fmt.Fprintf(b, `<circle cx="%s" cy="%s" r="%s" fill="%s"/>`+"\n", dotNum(x), dotNum(y), dotNum(hw), stroke)
+	case "ellipse":
+		fmt.Fprintf(b, `<ellipse cx="%s" cy="%s" rx="%s" ry="%s" %s/>`+"\n", dotNum(x), dotNum(y), dotNum(hw), dotNum(hh), common)
+	}
+	dotText(b, x, y, box.lines, dotColor(attrs["fontcolor"], "currentColor"), "middle")
+}
+
+func (l *dotLayout) svgEdge(b *strings.Builder, e *dotEdge, path []*dotBox) {
+	if strings.Contains(e.attrs["style"], "invis") {
+		return
+	}
+	stroke := dotColor(e.attrs["color"], "currentColor")
+	dash := dotDash(e.attrs["style"])
+	dir := e.attrs["dir"]
+	if dir == "" {
+		dir = "none"
+		if l.graph.directed {
+			dir = "forward"
+		}
+	}
+
+	var points [][2]float64
+	if len(path) == 1 {
+		// A self loop on the right side of the node
+		n := path[0]
+		x, y := n.x+n.w/2, n.y
+		if n.shape == "ellipse" || n.shape == "circle" || n.shape == "doublecircle" {
+			x = n.x + n.w/2*0.95
+		}
+		fmt.Fprintf(b, `<path d="M%s,%s C%s,%s %s,%s %s,%s" fill="none" stroke="%s" stroke-width="1.5"%s/>`+"\n",
+			dotNum(x), dotNum(y-8), dotNum(x+40), dotNum(y-28), dotNum(x+40), dotNum(y+28), dotNum(x), dotNum(y+8), stroke, dash)
+		if dir == "forward" || dir == "both" {
+			dotArrow(b, x+12, y+14, x, y+8, stroke)
+		}
+		lx, ly := l.labelPos(path)
+		dotEdgeLabel(b, lx, ly, e.attrs["label"], e.attrs["fontcolor"])
+		return
+	}
+
+	points = dotEdgePoints(path)
+	n := len(points)
+
+	// Leave room for arrowheads
+	head, tail := points[n-1], points[0]
+	if dir == "forward" || dir == "both" {
+		points[n-1] = dotShorten(points[n-1], points[n-2], dotArrowSize)
+	}
+	if dir == "back" || dir == "both" {
+		points[0] = dotShorten(points[0], points[1], dotArrowSize)
+	}
+
+	var d strings.Builder
+	for i, p := range points {
+		if i == 0 {
+			fmt.Fprintf(&d, "M%s,%s", dotNum(p[0]), dotNum(p[1]))
+		} else {
+			fmt.Fprintf(&d, " L%s,%s", dotNum(p[0]), dotNum(p[1]))
+		}
+	}
+	fmt.Fprintf(b, `<path d="%s" fill="none" stroke="%s" stroke-width="1.5"%s/>`+"\n", d.String(), stroke, dash)
+	if dir == "forward" || dir == "both" {
+		dotArrow(b, points[n-1][0], points[n-1][1], head[0], head[1], stroke)
+	}
+	if dir == "back" || dir == "both" {
+		dotArrow(b, points[0][0], points[0][1], tail[0], tail[1], stroke)
+	}
+
+	x, y := l.labelPos(path)
+	dotEdgeLabel(b, x, y, e.attrs["label"], e.attrs["fontcolor"])
+}
+
+// dotEdgePoints returns the points of an edge through path, from and to the
+// outline of its end nodes.
+func dotEdgePoints(path []*dotBox) [][2]float64 {
+	points := make([][2]float64, len(path))
+	for i, box := range path {
+		points[i] = [2]float64{box.x, box.y}
+	}
+	first, last := path[0], path[len(path)-1]
+	n := len(points)
+	points[0][0], points[0][1] = first.clip(points[1][0], points[1][1])
+	points[n-1][0], points[n-1][1] = last.clip(points[n-2][0], points[n-2][1])
+	return points
+}
+
+// labelPos returns where the label of the edge through path starts: right of
+// the middle of the edge, or of the loop for self loops.
+func (l *dotLayout) labelPos(path []*dotBox) (float64, float64) {
+	if len(path) == 1 {
+		return path[0].x + path[0].w/2 + 44, path[0].y
+	}
+	points := dotEdgePoints(path)
+	mid := len(points) / 2
+	return (points[mid-1][0]+points[mid][0])/2 + 6, (points[mid-1][1] + points[mid][1]) / 2
+}
+
+// dotShorten moves p towards q by length.
+func dotShorten(p, q [2]float64, length float64) [2]float64 {
+	dx, dy := q[0]-p[0], q[1]-p[1]
+	dist := math.Hypot(dx, dy)
+	if dist <= length {
+		return p
+	}
+	return [2]float64{p[0] + dx/dist*length, p[1] + dy/dist*length}
+}
+
+// dotArrow draws an arrowhead from (x1, y1) with its tip at (x2, y2).
+func dotArrow(b *strings.Builder, x1, y1, x2, y2 float64, color string) {
+	dx, dy := x2-x1, y2-y1
+	dist := math.Hypot(dx, dy)
+	if dist == 0 {
+		return
+	}
+	px, py := -dy/dist*dotArrowSize/2.5, dx/dist*dotArrowSize/2.5
+	fmt.Fprintf(b, `<polygon points="%s,%s %s,%s %s,%s" fill="%s"/>`+"\n",
+		dotNum(x2), dotNum(y2), dotNum(x1+px), dotNum(y1+py), dotNum(x1-px), dotNum(y1-py), color)
+}
+
+func dotEdgeLabel(b *strings.Builder, x, y float64, label, color string) {
+	if label == "" {
+		return
+	}
+	dotText(b, x, y, dotLabelLines(label), dotColor(color, "currentColor"), "start")
+}
+
+// dotText draws lines of text centered vertically on y, anchored at x as in
+// SVG's text-anchor.
+func dotText(b *strings.Builder, x, y float64, lines []string, color, anchor string) {
+	for i, line := range lines {
+		ly := y + (float64(i)-float64(len(lines)-1)/2)*dotLineHeight
+		fmt.Fprintf(b, `<text x="%s" y="%s" fill="%s" text-anchor="%s" dominant-baseline="central">%s</text>`+"\n",
+			dotNum(x), dotNum(ly), color, anchor, html.EscapeString(line))
+	}
+}
+
+var dotColorRE = regexp.MustCompile(`^(#[0-9A-Fa-f]{3,8}|[A-Za-z]+)$`)
+
+// dotColor returns color if it is a color name or hex color, or def.
+func dotColor(color, def string) string {
+	if dotColorRE.MatchString(color) {
+		return color
+	}
+	return def
+}
+
+func dotDash(style string) string {
+	switch {
+	case strings.Contains(style, "dashed"):
+		return ` stroke-dasharray="6,4"`
+	case strings.Contains(style, "dotted"):
+		return ` stroke-dasharray="1.5,3"`
+	}
+	return ""
+}
+
+// dotNum formats a coordinate with at most one decimal.
+func dotNum(v float64) string {
+	return strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64)
+}
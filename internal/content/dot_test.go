@@ -0,0 +1,157 @@
+package content
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDOT(t *testing.T) {
+	src := `// Update flow
+strict digraph "flow" {
+  rankdir = LR
+  node [shape=box]
+  a [label="Start \"here\""]
+  a -> b -> c [label=next, style=dashed]
+  /* a subgraph */
+  subgraph cluster_x { label="ignored"; node [shape=circle]; d; e }
+  c -> { d e }
+  f [label=<<b>bold</b> &amp; plain>]
+  # trailing comment
+}`
+	g, err := parseDOT(src)
+	if err != nil {
+		t.Fatalf("parseDOT returned error: %v", err)
+	}
+
+	if !g.directed {
+		t.Error("directed = false for a digraph")
+	}
+	if g.attrs["rankdir"] != "LR" {
+		t.Errorf("rankdir = %q, want LR", g.attrs["rankdir"])
+	}
+	if g.attrs["label"] != "" {
+		t.Errorf("subgraph label leaked into the graph: %q", g.attrs["label"])
+	}
+
+	var ids []string
+	for _, n := range g.nodes {
+		ids = append(ids, n.id)
+	}
+	if got, want := strings.Join(ids, " "), "a b c d e f"; got != want {
+		t.Errorf("nodes = %q, want %q", got, want)
+	}
+	if got := g.byID["a"].attrs["label"]; got != `Start "here"` {
+		t.Errorf("a label = %q", got)
+	}
+	if got := g.byID["b"].attrs["shape"]; got != "box" {
+		t.Errorf("b shape = %q, want the node default box", got)
+	}
+	if got := g.byID["d"].attrs["shape"]; got != "circle" {
+		t.Errorf("d shape = %q, want the subgraph default circle", got)
+	}
+	if got := g.byID["f"].attrs["label"]; got != "bold & plain" {
+		t.Errorf("f label = %q, want the text of the HTML label", got)
+	}
+
+	var edges []string
+	for _, e := range g.edges {
+		edges = append(edges, e.from.id+">"+e.to.id+":"+e.attrs["label"])
+	}
+	if got, want := strings.Join(edges, " "), "a>b:next b>c:next c>d: c>e:"; got != want {
+		t.Errorf("edges = %q, want %q", got, want)
+	}
+}
+
+func TestParseDOTErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"not a graph", "flowchart { a }", "dot: line 1: expected graph or digraph"},
+		{"unclosed", "digraph {\n  a -> b\n", "dot: line 2: expected '}' at end of input"},
+		{"wrong operator", "graph {\n  a -> b\n}", "dot: line 2: wrong edge operator for this graph"},
+		{"missing value", "digraph {\n  a [label=]\n}", "dot: line 2: expected a value for label"},
+		{"unterminated string", "digraph {\n  a [label=\"x]\n}", "dot: line 2: unterminated string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseDOT(tt.src)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLayoutDOT(t *testing.T) {
+	g, err := parseDOT(`digraph { a -> b -> c; a -> c; c -> a; c -> c }`)
+	if err != nil {
+		t.Fatalf("parseDOT returned error: %v", err)
+	}
+	l := layoutDOT(g)
+	a, b, c := l.boxes[g.byID["a"]], l.boxes[g.byID["b"]], l.boxes[g.byID["c"]]
+
+	// The cycle back to a must not pull it below c
+	if a.rank != 0 || b.rank != 1 || c.rank != 2 {
+		t.Errorf("ranks = %d %d %d, want 0 1 2", a.rank, b.rank, c.rank)
+	}
+	if !(a.y < b.y && b.y < c.y) {
+		t.Errorf("y = %v %v %v, want increasing along the edges", a.y, b.y, c.y)
+	}
+	// a -> c skips a rank and is routed through a box beside b
+	if got := len(l.paths[2]); got != 3 {
+		t.Errorf("a -> c passes %d boxes, want 3", got)
+	}
+	for _, box := range l.allBoxes() {
+		if box.x-box.w/2 < 0 || box.x+box.w/2 > l.width || box.y-box.h/2 < 0 || box.y+box.h/2 > l.height {
+			t.Errorf("box at (%v, %v) lies outside the %vx%v drawing", box.x, box.y, l.width, l.height)
+		}
+	}
+
+	g, err = parseDOT(`digraph { rankdir=LR; a -> b }`)
+	if err != nil {
+		t.Fatalf("parseDOT returned error: %v", err)
+	}
+	l = layoutDOT(g)
+	if a, b := l.boxes[g.byID["a"]], l.boxes[g.byID["b"]]; !(a.x < b.x) || a.y != b.y {
+		t.Errorf("LR positions a=(%v, %v) b=(%v, %v), want b right of a", a.x, a.y, b.x, b.y)
+	}
+}
+
+func TestDotRendererSVG(t *testing.T) {
+	out, err := dotRenderer{}.RenderDiagram([]byte(`graph "A & B" {
+  label="A & B"
+  x [label="one\ntwo", shape=diamond, style=filled, fillcolor="#bae6fd"]
+  x -- y [label="<link>"]
+}`))
+	if err != nil {
+		t.Fatalf("RenderDiagram returned error: %v", err)
+	}
+
+	for _, want := range []string{
+		`<svg xmlns="http://www.w3.org/2000/svg"`,
+		`aria-label="A &amp; B"`,
+		`<polygon points=`,
+		`fill="#bae6fd"`,
+		`>one</text>`,
+		`>two</text>`,
+		`>&lt;link&gt;</text>`,
+		`</svg>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+	// Undirected edges have no arrowheads; the diamond is the only polygon
+	if got := strings.Count(out, "<polygon"); got != 1 {
+		t.Errorf("found %d polygons, want 1", got)
+	}
+
+	if _, err := (dotRenderer{}).RenderDiagram([]byte("digraph {}")); err == nil {
+		t.Error("expected an error for a graph without nodes")
+	}
+}
@@ -0,0 +1,195 @@
+package content
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// frontmatterError is a syntax error in a frontmatter block, at a line of the
+// content file.
+type frontmatterError struct {
+	Line int
+	Msg  string
+}
+
+func (e *frontmatterError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// parseFrontmatter splits the frontmatter from the markdown body and parses
+// it. The delimiter selects the format, as in Hugo:
+//
+//   - YAML between --- lines
+//   - TOML between +++ lines
+//   - a JSON object opened by a line holding only {
+//
+// Every format is parsed into a YAML mapping node whose line numbers are lines
+// of data, so the rest of the package handles them alike. The node is nil if
+// there is no frontmatter.
+func parseFrontmatter(data []byte) (node *yaml.Node, body []byte, err error) {
+	switch {
+	case hasDelimiter(data, "---"):
+		fm, body, line := splitDelimited(data, "---")
+		if fm == nil {
+			return nil, data, nil
+		}
+		node, err := parseYAMLFrontmatter(fm, line)
+		return node, body, err
+
+	case hasDelimiter(data, "+++"):
+		fm, body, line := splitDelimited(data, "+++")
+		if fm == nil {
+			return nil, data, nil
+		}
+		node, err := parseTOML(fm, line)
+		return node, body, err
+
+	// Only a { alone on the first line opens JSON; a shortcode or an
+	// attribute list there is part of the body
+	case hasDelimiter(data, "{"):
+		end := jsonObjectEnd(data)
+		if end < 0 {
+			return nil, nil, &frontmatterError{Line: 1, Msg: "JSON frontmatter has no closing }"}
+		}
+		node, err := parseJSON(data[:end])
+		return node, skipNewline(data[end:]), err
+	}
+
+	return nil, data, nil
+}
+
+// hasDelimiter reports whether data starts with a line holding only delim.
+func hasDelimiter(data []byte, delim string) bool {
+	rest, ok := bytes.CutPrefix(data, []byte(delim))
+	if !ok {
+		return false
+	}
+	rest = bytes.TrimLeft(rest, " \t")
+	return len(rest) == 0 || rest[0] == '\n' || rest[0] == '\r'
+}
+
+// splitDelimited returns the lines between an opening delim line and the next
+// line starting with delim, the body after it, and the file line on which the
+// frontmatter starts. The frontmatter is nil if there is no closing line.
+func splitDelimited(data []byte, delim string) (frontmatter, body []byte, line int) {
+	nl := bytes.IndexByte(data, '\n')
+	if nl < 0 {
+		return nil, data, 0
+	}
+	rest := data[nl+1:]
+
+	var end int
+	if bytes.HasPrefix(rest, []byte(delim)) {
+		end = 0
+	} else if i := bytes.Index(rest, []byte("\n"+delim)); i >= 0 {
+		end = i + 1
+	} else {
+		return nil, data, 0
+	}
+
+	frontmatter = rest[:end]
+	body = rest[end+len(delim):]
+	// Drop the rest of the closing delimiter line
+	if i := bytes.IndexByte(body, '\n'); i >= 0 && len(bytes.TrimSpace(body[:i])) == 0 {
+		body = body[i+1:]
+	} else if len(bytes.TrimSpace(body)) == 0 {
+		body = nil
+	}
+	if frontmatter == nil {
+		frontmatter = []byte{}
+	}
+	return frontmatter, body, 2
+}
+
+// skipNewline drops the rest of the line at the start of b.
+func skipNewline(b []byte) []byte {
+	if i := bytes.IndexByte(b, '\n'); i >= 0 && len(bytes.TrimSpace(b[:i])) == 0 {
+		return b[i+1:]
+	}
+	return b
+}
+
+// jsonObjectEnd returns the offset just past the } closing the JSON object at
+// the start of data, or -1 if it is not closed.
+func jsonObjectEnd(data []byte) int {
+	depth := 0
+	inString, escaped := false, false
+	for i, c := range data {
+		switch {
+		case inString:
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
+// parseYAMLFrontmatter parses YAML frontmatter that starts on file
+// line firstLine.
+func parseYAMLFrontmatter(fm []byte, firstLine int) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(fm, &doc); err != nil {
+		line, msg := yamlErrorLine(err)
+		return nil, &frontmatterError{Line: line + firstLine - 1, Msg: msg}
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	shiftLines(root, firstLine-1)
+	if root.Kind != yaml.MappingNode {
+		return nil, &frontmatterError{Line: root.Line, Msg: "frontmatter must be a mapping of keys to values"}
+	}
+	return root, nil
+}
+
+// shiftLines adds offset to the line of node and everything below it.
+func shiftLines(node *yaml.Node, offset int) {
+	node.Line += offset
+	for _, child := range node.Content {
+		shiftLines(child, offset)
+	}
+}
+
+// yamlErrorLine splits a YAML syntax error into its line (relative to the
+// document, 1 if unknown) and message.
+func yamlErrorLine(err error) (int, string) {
+	msg := err.Error()
+	m := lineRE.FindStringSubmatch(msg)
+	if m == nil {
+		return 1, strings.TrimPrefix(msg, "yaml: ")
+	}
+	line, _ := strconv.Atoi(m[2])
+	return line, msg[len(m[0]):]
+}
+
+// scalarNode returns a scalar node with an explicit tag, for frontmatter
+// formats other than YAML.
+func scalarNode(tag, value string, line int) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value, Line: line}
+}
+
+// stringNode returns a string node, quoted so that YAML never resolves it to
+// another type.
+func stringNode(s string, line int) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Style: yaml.DoubleQuotedStyle, Value: s, Line: line}
+}
@@ -0,0 +1,253 @@
+package content
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFrontmatterFormats(t *testing.T) {
+	inputs := map[string]string{
+		"yaml": `---
+title: "Install nbc"
+weight: 2
+draft: true
+date: 2025-01-15
+tags: [linux, "install"]
+sitemap:
+  priority: 0.5
+---
+
+# Install
+`,
+		"toml": `+++
+# Imported from Hugo
+title = "Install nbc"
+weight = 2
+draft = true
+date = 2025-01-15
+tags = [
+  'linux',
+  "install", # trailing comma
+]
+
+[sitemap]
+priority = 0.5
++++
+
+# Install
+`,
+		"json": `{
+	"title": "Install nbc",
+	"weight": 2,
+	"draft": true,
+	"date": "2025-01-15",
+	"tags": ["linux", "install"],
+	"sitemap": {"priority": 0.5}
+}
+
+# Install
+`,
+	}
+
+	for format, input := range inputs {
+		page, err := ParsePage([]byte(input), "content/docs/install.md")
+		if err != nil {
+			t.Fatalf("%s: ParsePage returned error: %v", format, err)
+		}
+		if page.Title != "Install nbc" || page.Weight != 2 || !page.Draft {
+			t.Errorf("%s: Title, Weight, Draft = %q, %d, %v", format, page.Title, page.Weight, page.Draft)
+		}
+		if !page.ParsedDate.Equal(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("%s: ParsedDate = %v, want 2025-01-15", format, page.ParsedDate)
+		}
+		if !reflect.DeepEqual([]string(page.Tags), []string{"linux", "install"}) {
+			t.Errorf("%s: Tags = %v", format, page.Tags)
+		}
+		if page.Sitemap.Priority != 0.5 {
+			t.Errorf("%s: Sitemap.Priority = %v, want 0.5", format, page.Sitemap.Priority)
+		}
+		if !strings.Contains(string(page.Content), `id="install">Install`) {
+			t.Errorf("%s: body not rendered: %s", format, page.Content)
+		}
+	}
+}
+
+func TestParseTOML(t *testing.T) {
+	input := `str = "tab\there \u00e9"
+lit = 'C:\path'
+multi = """
+one \
+  two"""
+"quoted key" = 1
+site.name = "frostyard"
+hex = 0xff
+big = 1_000
+float = 6.5e-1
+when = 1979-05-27T07:32:00Z
+local = 1979-05-27 07:32:00
+time = 07:32:00
+point = { x = 1, y = [true, false] }
+
+[[links]]
+name = "a"
+
+[[links]]
+name = "b"
+
+[links.meta]
+depth = 2
+`
+	node, err := parseTOML([]byte(input), 1)
+	if err != nil {
+		t.Fatalf("parseTOML returned error: %v", err)
+	}
+	var got map[string]any
+	if err := node.Decode(&got); err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+
+	want := map[string]any{
+		"str":        "tab\there é",
+		"lit":        `C:\path`,
+		"multi":      "one two",
+		"quoted key": 1,
+		"site":       map[string]any{"name": "frostyard"},
+		"hex":        255,
+		"big":        1000,
+		"float":      0.65,
+		"when":       time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC),
+		"local":      time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC),
+		"time":       "07:32:00",
+		"point":      map[string]any{"x": 1, "y": []any{true, false}},
+		"links": []any{
+			map[string]any{"name": "a"},
+			map[string]any{"name": "b", "meta": map[string]any{"depth": 2}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTOML decoded to\n%#v\nwant\n%#v", got, want)
+	}
+
+	// Line numbers count from firstLine
+	if line := node.Content[0].Line; line != 1 {
+		t.Errorf("first key on line %d, want 1", line)
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		line  int
+	}{
+		{"title = \"a\"\ntitle = \"b\"\n", 3},
+		{"title = \"unterminated\n", 2},
+		{"a = 1\n\n[t]\nx = 1\n[t]\n", 6},
+		{"a = 1 b = 2\n", 2},
+		{"a = nope\n", 2},
+		{"tags = [1, 2\n", 3},
+	}
+	for _, tt := range tests {
+		_, err := parseTOML([]byte(tt.input), 2)
+		var fmErr *frontmatterError
+		if !errors.As(err, &fmErr) {
+			t.Errorf("parseTOML(%q) error = %v, want a frontmatterError", tt.input, err)
+			continue
+		}
+		if fmErr.Line != tt.line {
+			t.Errorf("parseTOML(%q) error on line %d, want %d: %v", tt.input, fmErr.Line, tt.line, err)
+		}
+	}
+}
+
+func TestLintPageTOMLLines(t *testing.T) {
+	input := "+++\ntitle = \"Hello\"\nwieght = 3\n+++\n"
+	problems := LintPage([]byte(input), "content/docs/a.md", LintOptions{})
+	if len(problems) != 1 || problems[0].Line != 3 {
+		t.Errorf("problems = %v, want one unknown key on line 3", problems)
+	}
+}
+
+func TestParseFrontmatterBodyStartingWithBrace(t *testing.T) {
+	for _, input := range []string{
+		"{{< image-pull name=\"snow\" >}}\n\nSome text.\n",
+		"{.lead}\nSome text.\n",
+	} {
+		node, body, err := parseFrontmatter([]byte(input))
+		if err != nil {
+			t.Errorf("parseFrontmatter(%q) returned error: %v", input, err)
+			continue
+		}
+		if node != nil {
+			t.Errorf("parseFrontmatter(%q) found frontmatter, want none", input)
+		}
+		if string(body) != input {
+			t.Errorf("parseFrontmatter(%q) body = %q, want the whole input", input, body)
+		}
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	input := "{\n  \"title\": \"A \\/ B \\u00e9\",\n  \"weight\": 3,\n  \"ratio\": 1.5e1,\n  \"draft\": false,\n  \"hero\": null,\n  \"tags\": [\"a\", {\"b\": [1]}]\n}\n\nBody.\n"
+	node, body, err := parseFrontmatter([]byte(input))
+	if err != nil {
+		t.Fatalf("parseFrontmatter returned error: %v", err)
+	}
+	if string(body) != "\nBody.\n" {
+		t.Errorf("body = %q", body)
+	}
+	var got map[string]any
+	if err := node.Decode(&got); err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+	want := map[string]any{
+		"title":  "A / B é",
+		"weight": 3,
+		"ratio":  15.0,
+		"draft":  false,
+		"hero":   nil,
+		"tags":   []any{"a", map[string]any{"b": []any{1}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseJSON decoded to\n%#v\nwant\n%#v", got, want)
+	}
+
+	// Every key is on its own line
+	for i := 0; i < len(node.Content); i += 2 {
+		if line := node.Content[i].Line; line != i/2+2 {
+			t.Errorf("key %q on line %d, want %d", node.Content[i].Value, line, i/2+2)
+		}
+	}
+}
+
+func TestParseJSONErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		line  int
+	}{
+		{"{\n  \"title\": \"a\",\n  \"weight\": nope\n}\n", 3},
+		{"{\n  \"title\": \"a\"\n  \"weight\": 2\n}\n", 3},
+		{"{\n  \"title\": \"\\x\"\n}\n", 2},
+	}
+	for _, tt := range tests {
+		_, _, err := parseFrontmatter([]byte(tt.input))
+		var fmErr *frontmatterError
+		if !errors.As(err, &fmErr) {
+			t.Errorf("parseFrontmatter(%q) error = %v, want a frontmatterError", tt.input, err)
+			continue
+		}
+		if fmErr.Line != tt.line {
+			t.Errorf("parseFrontmatter(%q) error on line %d, want %d: %v", tt.input, fmErr.Line, tt.line, err)
+		}
+	}
+}
+
+func TestLintPageJSONLines(t *testing.T) {
+	input := "{\n  \"title\": \"Hello\",\n  \"wieght\": 3\n}\n"
+	problems := LintPage([]byte(input), "content/docs/a.md", LintOptions{})
+	if len(problems) != 1 || problems[0].Line != 3 {
+		t.Errorf("problems = %v, want one unknown key on line 3", problems)
+	}
+}
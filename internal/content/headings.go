@@ -0,0 +1,171 @@
+package content
+
+import (
+	"fmt"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// layoutIDs are element IDs the page layouts use, which headings must not
+// take.
+var layoutIDs = map[string]bool{
+	"search":      true,
+	"mobile-menu": true,
+}
+
+// headingIDs generates heading IDs the same way as goldmark's default, but
+// also avoids reserved IDs and remembers which headings set their ID explicitly
+// with the {#id} attribute syntax.
+type headingIDs struct {
+	used     map[string]bool
+	reserved map[string]bool // IDs no heading may take, such as layoutIDs
+	explicit []bool          // Per heading in document order, whether its ID is explicit
+}
+
+func newHeadingIDs(reserved map[string]bool) *headingIDs {
+	ids := &headingIDs{used: make(map[string]bool), reserved: reserved}
+	for id := range reserved {
+		ids.used[id] = true
+	}
+	return ids
+}
+
+// Generate implements parser.IDs, turning heading text into a lowercase,
+// dash-separated ID that is not used yet.
+func (s *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	value = util.TrimRightSpace(util.TrimLeftSpace(value))
+	var result []byte
+	for i := 0; i < len(value); {
+		v := value[i]
+		l := util.UTF8Len(v)
+		i += int(l)
+		if l != 1 {
+			continue
+		}
+		if util.IsAlphaNumeric(v) {
+			if 'A' <= v && v <= 'Z' {
+				v += 'a' - 'A'
+			}
+			result = append(result, v)
+		} else if util.IsSpace(v) || v == '-' || v == '_' {
+			result = append(result, '-')
+		}
+	}
+	if len(result) == 0 {
+		result = []byte("heading")
+		if kind != ast.KindHeading {
+			result = []byte("id")
+		}
+	}
+	if kind == ast.KindHeading {
+		s.explicit = append(s.explicit, false)
+	}
+	return []byte(s.unique(string(result)))
+}
+
+// Put implements parser.IDs, recording an ID set with {#id}.
+func (s *headingIDs) Put(value []byte) {
+	s.used[string(value)] = true
+	s.explicit = append(s.explicit, true)
+}
+
+// unique returns id, or id with the first free -N suffix if id is used, and
+// marks the result as used.
+func (s *headingIDs) unique(id string) string {
+	result := id
+	for i := 1; s.used[result]; i++ {
+		result = fmt.Sprintf("%s-%d", id, i)
+	}
+	s.used[result] = true
+	return result
+}
+
+// headingIDTransformer makes heading IDs unique within a page. The parser only
+// avoids IDs it has seen so far, so an explicit {#id} can still repeat an
+// earlier generated ID, another explicit ID or a reserved ID. Explicit IDs are
+// kept in such conflicts, so deep links written for them keep working; the
+// other heading gets a -N suffix.
+type headingIDTransformer struct{}
+
+func (headingIDTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	ids, ok := pc.IDs().(*headingIDs)
+	if !ok {
+		return
+	}
+
+	var headings []*ast.Heading
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if h, ok := n.(*ast.Heading); ok && entering {
+			headings = append(headings, h)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	// The parser assigns IDs in document order, so ids.explicit lines up
+	// with headings. The first heading with an explicit ID owns it.
+	owner := make(map[string]*ast.Heading)
+	if len(ids.explicit) == len(headings) {
+		for i, h := range headings {
+			id := headingID(h)
+			if ids.explicit[i] && !ids.reserved[id] && owner[id] == nil {
+				owner[id] = h
+			}
+		}
+	}
+
+	for _, h := range headings {
+		id := headingID(h)
+		if id == "" || owner[id] == h {
+			continue
+		}
+		if owner[id] != nil || ids.reserved[id] {
+			id = ids.unique(id)
+			h.SetAttributeString("id", []byte(id))
+		}
+		owner[id] = h
+	}
+}
+
+// headingID returns the id attribute of a heading, or "".
+func headingID(h *ast.Heading) string {
+	if id, ok := h.AttributeString("id"); ok {
+		if b, ok := id.([]byte); ok {
+			return string(b)
+		}
+	}
+	return ""
+}
+
+// headingRenderer renders headings like goldmark's HTML renderer, adding a ¶
+// link to the heading's own ID that the stylesheet shows on hover.
+type headingRenderer struct{}
+
+func (headingRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindHeading, renderHeading)
+}
+
+func renderHeading(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*ast.Heading)
+	if entering {
+		_, _ = w.WriteString("<h")
+		_ = w.WriteByte("0123456"[n.Level])
+		if n.Attributes() != nil {
+			html.RenderAttributes(w, node, html.HeadingAttributeFilter)
+		}
+		_ = w.WriteByte('>')
+		return ast.WalkContinue, nil
+	}
+
+	if id := headingID(n); id != "" {
+		_, _ = fmt.Fprintf(w, `<a class="heading-anchor" href="#%s" aria-label="Link to this section" data-pagefind-ignore>¶</a>`, util.EscapeHTML(util.URLEscape([]byte(id), false)))
+	}
+	_, _ = w.WriteString("</h")
+	_ = w.WriteByte("0123456"[n.Level])
+	_, _ = w.WriteString(">\n")
+	return ast.WalkContinue, nil
+}
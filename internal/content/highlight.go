@@ -0,0 +1,262 @@
+package content
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// codeInfo holds the options of a fenced code block, written after its
+// language:
+//
+//	```bash title="install.sh" {3-5,8} linenos
+//
+// Other options are ignored, so info strings written for other tools, such
+// as {.class} or Hugo's {linenos=table,hl_lines=[3]}, still render.
+type codeInfo struct {
+	Lang        string
+	Title       string   // Caption, usually a file name
+	Highlight   [][2]int // Highlighted line ranges, 1-based and inclusive
+	LineNumbers bool
+}
+
+var (
+	// codeInfoRE matches one option of a code block info string.
+	codeInfoRE = regexp.MustCompile(`^(?:([A-Za-z]+)=(?:"([^"]*)"|(\S+))|\{([^}]*)\}|(\S+))`)
+	// lineRangesRE matches the contents of a {...} option that lists lines.
+	lineRangesRE = regexp.MustCompile(`^[\d\s,-]+$`)
+)
+
+// parseCodeInfo parses the info string of a fenced code block.
+func parseCodeInfo(info string) (codeInfo, error) {
+	var ci codeInfo
+	info = strings.TrimSpace(info)
+	if info != "" && info[0] != '{' {
+		lang, rest, _ := strings.Cut(info, " ")
+		if !strings.Contains(lang, "=") {
+			ci.Lang, info = lang, rest
+		}
+	}
+
+	for info = strings.TrimSpace(info); info != ""; info = strings.TrimSpace(info) {
+		m := codeInfoRE.FindStringSubmatch(info)
+		info = info[len(m[0]):]
+		switch {
+		case m[1] == "title":
+			ci.Title = m[2] + m[3]
+		case m[1] == "linenos":
+			on, err := strconv.ParseBool(m[2] + m[3])
+			if err != nil {
+				return ci, fmt.Errorf("linenos must be true or false, got %q", m[2]+m[3])
+			}
+			ci.LineNumbers = on
+		case lineRangesRE.MatchString(m[4]):
+			ranges, err := parseLineRanges(m[4])
+			if err != nil {
+				return ci, err
+			}
+			ci.Highlight = append(ci.Highlight, ranges...)
+		case m[5] == "linenos":
+			ci.LineNumbers = true
+		}
+	}
+	return ci, nil
+}
+
+// parseLineRanges parses line ranges such as "3-5,8" or "3-5 8".
+func parseLineRanges(s string) ([][2]int, error) {
+	var ranges [][2]int
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		from, to, isRange := strings.Cut(field, "-")
+		start, err := strconv.Atoi(from)
+		end := start
+		if err == nil && isRange {
+			end, err = strconv.Atoi(to)
+		}
+		if err != nil || start < 1 || end < start {
+			return nil, fmt.Errorf("invalid line range %q", field)
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("no line ranges in {%s}", s)
+	}
+	return ranges, nil
+}
+
+// writeCodeBlock writes code as a highlighted code block with a copy button
+// and, if set, a title. Highlighting uses CSS classes; HighlightCSS
+// generates the matching stylesheet.
+func writeCodeBlock(w io.Writer, code string, info codeInfo) error {
+	lexer := lexers.Get(info.Lang)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return err
+	}
+	formatter := chromahtml.New(
+		chromahtml.WithClasses(true),
+		chromahtml.WithLineNumbers(info.LineNumbers),
+		chromahtml.HighlightLines(info.Highlight),
+	)
+
+	var buf bytes.Buffer
+	buf.WriteString(`<div class="code-block">` + "\n")
+	if info.Title != "" {
+		fmt.Fprintf(&buf, `<div class="code-title">%s</div>`+"\n", html.EscapeString(info.Title))
+	}
+	buf.WriteString(`<div class="code-body">`)
+	buf.WriteString(`<button type="button" class="code-copy" data-copy-code data-pagefind-ignore aria-label="Copy code to clipboard">Copy</button>`)
+	if err := formatter.Format(&buf, styles.Fallback, iterator); err != nil {
+		return err
+	}
+	buf.WriteString("</div>\n</div>\n")
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// HighlightCode returns code as a highlighted code block in lang, the same
+// as a fenced code block would render. Shortcodes use it to show code.
+func HighlightCode(lang, code string) (string, error) {
+	var buf strings.Builder
+	if err := writeCodeBlock(&buf, code, codeInfo{Lang: lang}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// HighlightCSS returns the stylesheet for highlighted code in the Chroma
+// styles light and dark. The dark style applies below the .dark class the
+// layout's theme toggle sets, the light style everywhere else.
+func HighlightCSS(light, dark string) (string, error) {
+	var buf strings.Builder
+	for _, theme := range []struct{ name, scope string }{
+		{light, ":root:not(.dark)"},
+		{dark, ".dark"},
+	} {
+		style, ok := styles.Registry[theme.name]
+		if !ok {
+			return "", fmt.Errorf("unknown highlight style %q", theme.name)
+		}
+
+		// Chroma only writes the rules for line numbers and highlighted
+		// lines if the formatter uses them
+		formatter := chromahtml.New(
+			chromahtml.WithClasses(true),
+			chromahtml.WithLineNumbers(true),
+			chromahtml.HighlightLines([][2]int{{1, 1}}),
+		)
+		var css bytes.Buffer
+		if err := formatter.WriteCSS(&css, style); err != nil {
+			return "", fmt.Errorf("writing %s style: %w", theme.name, err)
+		}
+		fmt.Fprintf(&buf, "/* %s */\n", theme.name)
+		for _, line := range strings.Split(strings.TrimSpace(css.String()), "\n") {
+			// Lines look like "/* Keyword */ .chroma .k { color: #ff79c6 }"
+			if _, rule, ok := strings.Cut(line, "*/ "); ok {
+				line = theme.scope + " " + rule
+			}
+			buf.WriteString(line + "\n")
+		}
+
+		// Some styles leave plain text to inherit its color, which the
+		// prose styles set for dark backgrounds only
+		bg := style.Get(chroma.Background)
+		textColor := bg.Colour
+		if !textColor.IsSet() {
+			textColor = chroma.MustParseColour("#1f2328")
+			if bg.Background.IsSet() && bg.Background.Brightness() < 0.5 {
+				textColor = chroma.MustParseColour("#f8f8f2")
+			}
+		}
+		fmt.Fprintf(&buf, "%s .chroma { color: %s }\n", theme.scope, textColor)
+	}
+	return buf.String(), nil
+}
+
+// codeBlockExtension renders fenced and indented code blocks with
+// writeCodeBlock. A fenced block with invalid options is an error.
+type codeBlockExtension struct{}
+
+func (codeBlockExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithASTTransformers(util.Prioritized(codeInfoTransformer{}, 500)),
+	)
+	m.Renderer().AddOptions(
+		renderer.WithNodeRenderers(util.Prioritized(codeBlockRenderer{}, 100)),
+	)
+}
+
+// codeInfoTransformer checks the info strings of fenced code blocks.
+type codeInfoTransformer struct{}
+
+func (codeInfoTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		block, ok := n.(*ast.FencedCodeBlock)
+		if !entering || !ok || block.Info == nil {
+			return ast.WalkContinue, nil
+		}
+
+		// The fence is the line of the info string
+		line := bytes.Count(source[:block.Info.Segment.Start], []byte("\n")) + 1
+		info, err := parseCodeInfo(string(block.Info.Segment.Value(source)))
+		if err != nil {
+			setMarkdownError(pc, line, fmt.Sprintf("code block: %v", err))
+			return ast.WalkStop, nil
+		}
+		for _, r := range info.Highlight {
+			if r[1] > block.Lines().Len() {
+				setMarkdownError(pc, line, fmt.Sprintf("code block: highlighted line %d is past its %d lines", r[1], block.Lines().Len()))
+				return ast.WalkStop, nil
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// codeBlockRenderer renders code blocks with writeCodeBlock.
+type codeBlockRenderer struct{}
+
+func (codeBlockRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, renderCodeBlock)
+	reg.Register(ast.KindCodeBlock, renderCodeBlock)
+}
+
+func renderCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	var info codeInfo
+	if block, ok := node.(*ast.FencedCodeBlock); ok && block.Info != nil {
+		// Checked by codeInfoTransformer
+		info, _ = parseCodeInfo(string(block.Info.Segment.Value(source)))
+	}
+	var code strings.Builder
+	for i := 0; i < node.Lines().Len(); i++ {
+		segment := node.Lines().At(i)
+		code.Write(segment.Value(source))
+	}
+	if err := writeCodeBlock(w, code.String(), info); err != nil {
+		return ast.WalkStop, fmt.Errorf("highlighting code: %w", err)
+	}
+	return ast.WalkSkipChildren, nil
+}
@@ -0,0 +1,120 @@
+package content
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCodeInfo(t *testing.T) {
+	tests := []struct {
+		info string
+		want codeInfo
+	}{
+		{"", codeInfo{}},
+		{"bash", codeInfo{Lang: "bash"}},
+		{`bash title="install.sh" {3-5} linenos`, codeInfo{Lang: "bash", Title: "install.sh", Highlight: [][2]int{{3, 5}}, LineNumbers: true}},
+		{`go title="main file.go" {1,4-6 9}`, codeInfo{Lang: "go", Title: "main file.go", Highlight: [][2]int{{1, 1}, {4, 6}, {9, 9}}}},
+		{`title=setup.sh linenos=false`, codeInfo{Title: "setup.sh"}},
+		{`{2}`, codeInfo{Highlight: [][2]int{{2, 2}}}},
+		{`js {.foo}`, codeInfo{Lang: "js"}},
+		{`go {linenos=table,hl_lines=[3]} {}`, codeInfo{Lang: "go"}},
+		{`bash caption="x" nolines {x} {4`, codeInfo{Lang: "bash"}},
+	}
+	for _, tt := range tests {
+		got, err := parseCodeInfo(tt.info)
+		if err != nil {
+			t.Errorf("parseCodeInfo(%q) returned error: %v", tt.info, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseCodeInfo(%q) = %+v, want %+v", tt.info, got, tt.want)
+		}
+	}
+
+	for _, info := range []string{
+		"bash {5-3}",
+		"bash {0}",
+		"bash {1,-}",
+		"bash linenos=maybe",
+	} {
+		if _, err := parseCodeInfo(info); err == nil {
+			t.Errorf("parseCodeInfo(%q) returned nil error, want error", info)
+		}
+	}
+}
+
+func TestParseCodeBlocks(t *testing.T) {
+	input := "---\ntitle: Code\n---\n\n```bash title=\"install.sh\" {2} linenos\necho one\necho <two>\n```\n\n    indented\n"
+	page, err := ParsePage([]byte(input), "content/docs/a.md")
+	if err != nil {
+		t.Fatalf("ParsePage returned error: %v", err)
+	}
+	html := string(page.Content)
+
+	for _, want := range []string{
+		`<div class="code-title">install.sh</div>`,
+		`<button type="button" class="code-copy" data-copy-code`,
+		`<pre class="chroma">`,
+		`<span class="line hl"><span class="ln">2</span>`,
+		`&lt;two&gt;`,
+		`<span class="cl">indented`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %q in:\n%s", want, html)
+		}
+	}
+	if strings.Contains(html, "style=") {
+		t.Errorf("expected CSS classes instead of inline styles:\n%s", html)
+	}
+	if got := strings.Count(html, `class="code-block"`); got != 2 {
+		t.Errorf("found %d code blocks, want 2", got)
+	}
+}
+
+func TestParseCodeBlockErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"---\ntitle: A\n---\n\n```bash {9}\necho\n```\n", "line 5: code block: highlighted line 9 is past its 1 lines"},
+		{"---\ntitle: A\n---\n\nText.\n\n```bash {4-2}\necho\n```\n", `line 7: code block: invalid line range "4-2"`},
+	}
+	for _, tt := range tests {
+		_, err := ParsePage([]byte(tt.input), "content/docs/a.md")
+		if err == nil {
+			t.Errorf("expected an error containing %q", tt.want)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("error = %q, want it to contain %q", err, tt.want)
+		}
+	}
+}
+
+func TestHighlightCSS(t *testing.T) {
+	css, err := HighlightCSS("github", "dracula")
+	if err != nil {
+		t.Fatalf("HighlightCSS returned error: %v", err)
+	}
+	for _, want := range []string{
+		":root:not(.dark) .chroma .k { color: #cf222e }",
+		".dark .chroma .k { color: #ff79c6 }",
+		":root:not(.dark) .chroma .hl {",
+		".dark .chroma .ln {",
+		":root:not(.dark) .chroma { color: #1f2328 }",
+	} {
+		if !strings.Contains(css, want) {
+			t.Errorf("expected %q in:\n%s", want, css)
+		}
+	}
+	for _, line := range strings.Split(css, "\n") {
+		if strings.HasPrefix(line, ".chroma") || strings.HasPrefix(line, ".bg") {
+			t.Errorf("unscoped rule %q", line)
+		}
+	}
+
+	if _, err := HighlightCSS("github", "no-such-style"); err == nil {
+		t.Error("HighlightCSS returned nil error for an unknown style")
+	}
+}
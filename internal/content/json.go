@@ -0,0 +1,122 @@
+package content
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseJSON parses JSON frontmatter, the object at the start of a content
+// file, into a YAML mapping node, so that it decodes like YAML frontmatter.
+// encoding/json reads it token by token, which gives every key and value
+// the line it is on.
+func parseJSON(src []byte) (*yaml.Node, error) {
+	p := &jsonParser{src: src, dec: json.NewDecoder(bytes.NewReader(src))}
+	p.dec.UseNumber()
+
+	root, err := p.value()
+	if err == nil {
+		if _, extra := p.dec.Token(); extra != io.EOF {
+			err = errors.New("unexpected data after the frontmatter object")
+		}
+	}
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return nil, &frontmatterError{Line: p.lineAt(syntaxErr.Offset), Msg: "json: " + syntaxErr.Error()}
+		}
+		return nil, &frontmatterError{Line: p.line(), Msg: "json: " + err.Error()}
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil, &frontmatterError{Line: root.Line, Msg: "frontmatter must be a mapping of keys to values"}
+	}
+	return root, nil
+}
+
+// jsonParser holds the state of parseJSON.
+type jsonParser struct {
+	src []byte
+	dec *json.Decoder
+}
+
+// lineAt returns the line, counting from 1, of the byte at offset.
+func (p *jsonParser) lineAt(offset int64) int {
+	offset = min(max(offset, 0), int64(len(p.src)))
+	return bytes.Count(p.src[:offset], []byte("\n")) + 1
+}
+
+// line returns the line of the token just read. Tokens never span lines.
+func (p *jsonParser) line() int {
+	return p.lineAt(p.dec.InputOffset() - 1)
+}
+
+// value reads the next JSON value and converts it to a node.
+func (p *jsonParser) value() (*yaml.Node, error) {
+	tok, err := p.dec.Token()
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	line := p.line()
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '{' {
+			return p.object(line)
+		}
+		return p.array(line)
+	case string:
+		return stringNode(tok, line), nil
+	case json.Number:
+		if n, err := strconv.ParseInt(string(tok), 10, 64); err == nil {
+			return scalarNode("!!int", strconv.FormatInt(n, 10), line), nil
+		}
+		f, err := tok.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return scalarNode("!!float", strconv.FormatFloat(f, 'g', -1, 64), line), nil
+	case bool:
+		return scalarNode("!!bool", strconv.FormatBool(tok), line), nil
+	}
+	return scalarNode("!!null", "null", line), nil
+}
+
+// object reads the members of an object whose { is on line.
+func (p *jsonParser) object(line int) (*yaml.Node, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: line}
+	for p.dec.More() {
+		tok, err := p.dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := stringNode(tok.(string), p.line())
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, key, value)
+	}
+	_, err := p.dec.Token() // }
+	return node, err
+}
+
+// array reads the items of an array whose [ is on line.
+func (p *jsonParser) array(line int) (*yaml.Node, error) {
+	node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: line}
+	for p.dec.More() {
+		item, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, item)
+	}
+	_, err := p.dec.Token() // ]
+	return node, err
+}
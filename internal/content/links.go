@@ -0,0 +1,161 @@
+package content
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// pageLink is a markdown link to another .md file, rewritten to its page path.
+type pageLink struct {
+	Dest   string // Destination as written (e.g., "../getting-started/quickstart.md#install")
+	Target string // Source path of the linked file (e.g., "content/docs/getting-started/quickstart.md")
+	Line   int    // 1-based line in the source file
+}
+
+var (
+	sourcePathKey = parser.NewContextKey() // Source path of the page being parsed
+	pageLinksKey  = parser.NewContextKey() // *[]pageLink collected while parsing
+)
+
+// mdLinkTransformer rewrites links to .md files, relative to the page's
+// source file or (with a leading slash) to the content directory, into the
+// linked page's Path, keeping any query and fragment. For example, in
+// content/docs/install.md, "../getting-started/_index.md#usage" becomes
+// "/getting-started/#usage". Rewritten links are recorded so that
+// CheckLinks can verify their targets exist.
+type mdLinkTransformer struct{}
+
+func (mdLinkTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	sourcePath, _ := pc.Get(sourcePathKey).(string)
+	links, _ := pc.Get(pageLinksKey).(*[]pageLink)
+	if sourcePath == "" || links == nil {
+		return
+	}
+	source := reader.Source()
+
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		link, ok := n.(*ast.Link)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+
+		dest := string(link.Destination)
+		target, rewritten, ok := resolveMarkdownLink(sourcePath, dest)
+		if !ok {
+			return ast.WalkContinue, nil
+		}
+
+		link.Destination = []byte(rewritten)
+		*links = append(*links, pageLink{
+			Dest:   dest,
+			Target: target,
+			Line:   nodeLine(link, source),
+		})
+		return ast.WalkContinue, nil
+	})
+}
+
+// resolveMarkdownLink resolves dest, a link in the page at sourcePath, to the
+// source path of the linked markdown file and the URL that replaces dest. It
+// reports false if dest is not a relative or root-relative link to a .md file.
+func resolveMarkdownLink(sourcePath, dest string) (target, rewritten string, ok bool) {
+	u, err := url.Parse(dest)
+	if err != nil || u.Scheme != "" || u.Host != "" || !strings.HasSuffix(u.Path, ".md") {
+		return "", "", false
+	}
+
+	if strings.HasPrefix(u.Path, "/") {
+		root, _, _ := strings.Cut(sourcePath, "/")
+		target = path.Join(root, u.Path)
+	} else {
+		target = path.Join(path.Dir(sourcePath), u.Path)
+	}
+
+	rewritten = computePath(target)
+	if u.RawQuery != "" {
+		rewritten += "?" + u.RawQuery
+	}
+	if u.Fragment != "" {
+		rewritten += "#" + u.EscapedFragment()
+	}
+	return target, rewritten, true
+}
+
+// nodeLine returns the 1-based line of the first text inside n, or 0 if it
+// has none (e.g., a link around an image without alt text).
+func nodeLine(n ast.Node, source []byte) int {
+	line := 0
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if t, ok := c.(*ast.Text); ok && entering {
+			line = bytes.Count(source[:t.Segment.Start], []byte("\n")) + 1
+			return ast.WalkStop, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return line
+}
+
+// CheckLinks verifies that every markdown link to a .md file in pages points
+// to one of pages. The returned error joins one error per broken link, naming
+// the source file and line of the link.
+func CheckLinks(pages []*Page) error {
+	sources := make(map[string]bool, len(pages))
+	for _, p := range pages {
+		sources[p.SourcePath] = true
+	}
+
+	var errs []error
+	for _, p := range pages {
+		for _, link := range p.links {
+			if !sources[link.Target] {
+				errs = append(errs, fmt.Errorf("%s:%d: link to %s: no such page %s", p.SourcePath, link.Line, link.Dest, link.Target))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CheckPublishedLinks verifies that the pages opts publishes do not link to
+// drafts, which would 404 on the site. The returned error joins one error per
+// such link. Links to future or expired pages 404 too, but whether a page is
+// published depends on the date, so they are returned as warnings instead:
+// otherwise a build that passes today could fail tomorrow without any change.
+// Links to pages not in pages are left to CheckLinks.
+func CheckPublishedLinks(pages []*Page, opts LoadOptions) (warnings []string, err error) {
+	bySource := make(map[string]*Page, len(pages))
+	for _, p := range pages {
+		bySource[p.SourcePath] = p
+	}
+
+	var errs []error
+	for _, p := range pages {
+		if !opts.includes(p) {
+			continue
+		}
+		for _, link := range p.links {
+			target, ok := bySource[link.Target]
+			if !ok {
+				continue
+			}
+			reason := opts.excludedBecause(target)
+			if reason == "" {
+				continue
+			}
+			msg := fmt.Sprintf("%s:%d: link to %s: page %s is %s", p.SourcePath, link.Line, link.Dest, link.Target, reason)
+			if target.Draft && !opts.Drafts {
+				errs = append(errs, errors.New(msg))
+			} else {
+				warnings = append(warnings, msg)
+			}
+		}
+	}
+	return warnings, errors.Join(errs...)
+}
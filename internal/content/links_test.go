@@ -0,0 +1,110 @@
+package content
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRewritesMarkdownLinks(t *testing.T) {
+	input := `---
+title: "Install"
+---
+
+- [quickstart](../getting-started/quickstart.md#step-1)
+- [section](../getting-started/_index.md)
+- [sibling](upgrade.md)
+- [rooted](/blog/_index.md)
+- [external](https://example.com/readme.md)
+- [plain](/docs/)
+`
+	page, err := ParsePage([]byte(input), "content/docs/tools/install.md")
+	if err != nil {
+		t.Fatalf("ParsePage returned error: %v", err)
+	}
+
+	html := string(page.Content)
+	for _, want := range []string{
+		`href="/docs/getting-started/quickstart/#step-1"`,
+		`href="/docs/getting-started/"`,
+		`href="/docs/tools/upgrade/"`,
+		`href="/blog/"`,
+		`href="https://example.com/readme.md"`,
+		`href="/docs/"`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %s in:\n%s", want, html)
+		}
+	}
+
+	if len(page.links) != 4 {
+		t.Fatalf("len(page.links) = %d, want 4", len(page.links))
+	}
+	if got := page.links[0]; got.Target != "content/docs/getting-started/quickstart.md" || got.Line != 5 {
+		t.Errorf("links[0] = %+v, want target content/docs/getting-started/quickstart.md on line 5", got)
+	}
+}
+
+func TestParseDirRejectsUnresolvedLinks(t *testing.T) {
+	tmp := t.TempDir()
+	writeFile(t, tmp, "content/docs/a.md", "---\ntitle: \"A\"\n---\n\nSee [b](b.md) and [gone](gone.md).\n")
+	writeFile(t, tmp, "content/docs/b.md", "---\ntitle: \"B\"\n---\n\nBack to [a](./a.md#top).\n")
+
+	_, err := ParseDir(filepath.Join(tmp, "content"), 1)
+	if err == nil {
+		t.Fatal("ParseDir should fail on a link to a missing page")
+	}
+	want := "content/docs/a.md:5: link to gone.md: no such page content/docs/gone.md"
+	if err.Error() != want {
+		t.Errorf("error = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestLoadContentRejectsLinksToDrafts(t *testing.T) {
+	tmp := t.TempDir()
+	writeFile(t, tmp, "content/docs/a.md", "---\ntitle: \"A\"\n---\n\nSee [wip](wip.md) and [old](old.md).\n")
+	writeFile(t, tmp, "content/docs/wip.md", "---\ntitle: \"WIP\"\ndraft: true\n---\n\nBack to [a](a.md).\n")
+	writeFile(t, tmp, "content/docs/old.md", "---\ntitle: \"Old\"\nexpiryDate: \"2020-01-01\"\n---\n")
+	contentDir := filepath.Join(tmp, "content")
+
+	_, err := LoadContent(contentDir, LoadOptions{})
+	if err == nil {
+		t.Fatal("LoadContent should fail on links to drafts")
+	}
+	want := "content/docs/a.md:5: link to wip.md: page content/docs/wip.md is a draft"
+	if err.Error() != want {
+		t.Errorf("error = %q, want %q", err.Error(), want)
+	}
+
+	// Once the draft is published, so is the link
+	if _, err := LoadContent(contentDir, LoadOptions{Drafts: true}); err != nil {
+		t.Errorf("LoadContent with drafts: %v", err)
+	}
+}
+
+func TestCheckPublishedLinksWarnsAboutDatedPages(t *testing.T) {
+	tmp := t.TempDir()
+	writeFile(t, tmp, "content/docs/a.md", "---\ntitle: \"A\"\n---\n\nSee [soon](soon.md) and [old](old.md).\n")
+	writeFile(t, tmp, "content/docs/soon.md", "---\ntitle: \"Soon\"\npublishDate: \"2025-09-01\"\n---\n")
+	writeFile(t, tmp, "content/docs/old.md", "---\ntitle: \"Old\"\nexpiryDate: \"2025-03-01\"\n---\n")
+	pages, err := ParseDir(filepath.Join(tmp, "content"), 1)
+	if err != nil {
+		t.Fatalf("ParseDir returned error: %v", err)
+	}
+
+	// Whether these pages are published depends on the date, so the build
+	// must not fail on them
+	warnings, err := CheckPublishedLinks(pages, LoadOptions{Now: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("CheckPublishedLinks returned error: %v", err)
+	}
+	want := []string{
+		"content/docs/a.md:5: link to soon.md: page content/docs/soon.md is not published yet",
+		"content/docs/a.md:5: link to old.md: page content/docs/old.md is expired",
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("warnings = %q, want %q", warnings, want)
+	}
+}
@@ -0,0 +1,312 @@
+package content
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/frostyard/site/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// LintOptions controls which frontmatter Lint accepts.
+type LintOptions struct {
+	Taxonomies []config.Taxonomy // Taxonomy fields allowed besides the standard ones
+	Params     []string          // Custom keys allowed with any value
+	Icons      []string          // Valid icon names; nil skips the icon check
+}
+
+// Problem is an error in a content file's frontmatter.
+type Problem struct {
+	File    string // Source path (e.g., "content/docs/intro.md")
+	Line    int    // 1-based line in File
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+}
+
+// fieldKind is the type of value a frontmatter field takes.
+type fieldKind int
+
+const (
+	kindString   fieldKind = iota // A scalar, used as text
+	kindInt                       // An integer
+	kindBool                      // true or false
+	kindDate                      // A date in one of the formats parseDate accepts
+	kindSitemap                   // A boolean or a sitemap options mapping
+	kindTOC                       // A boolean or a toc options mapping
+	kindTaxonomy                  // A scalar or a list of scalars
+	kindParam                     // Anything
+)
+
+// fieldKinds maps every standard frontmatter key (the yaml tags of Page) to
+// the kind of value it takes.
+var fieldKinds = map[string]fieldKind{
+	"title":       kindString,
+	"description": kindString,
+	"section":     kindString,
+	"weight":      kindInt,
+	"draft":       kindBool,
+	"icon":        kindString,
+	"date":        kindDate,
+	"author":      kindString,
+	"tags":        kindTaxonomy,
+	"lastmod":     kindDate,
+	"publishDate": kindDate,
+	"expiryDate":  kindDate,
+	"sitemap":     kindSitemap,
+	"toc":         kindTOC,
+	"math":        kindBool,
+}
+
+// requiredPostFields are the keys every blog post must set.
+var requiredPostFields = []string{"title", "date"}
+
+// LintDir checks the frontmatter of every .md file below contentDir.
+// Problems are sorted by file and line.
+func LintDir(contentDir string, opts LintOptions) ([]Problem, error) {
+	var problems []Problem
+
+	err := filepath.Walk(contentDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".md") {
+			return err
+		}
+		found, err := LintFile(contentDir, path, opts)
+		problems = append(problems, found...)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking content directory: %w", err)
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].File != problems[j].File {
+			return problems[i].File < problems[j].File
+		}
+		return problems[i].Line < problems[j].Line
+	})
+	return problems, nil
+}
+
+// LintFile checks the frontmatter of the markdown file at path, which must be
+// inside contentDir.
+func LintFile(contentDir, path string, opts LintOptions) ([]Problem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	sourcePath, err := filepath.Rel(filepath.Dir(contentDir), path)
+	if err != nil {
+		return nil, fmt.Errorf("computing relative path for %s: %w", path, err)
+	}
+	return LintPage(data, filepath.ToSlash(sourcePath), opts), nil
+}
+
+// LintPage checks the frontmatter of a markdown file: every key must be a
+// standard field, a configured taxonomy or a declared param, values must
+// have the right type, dates must parse, icons must be known, and blog posts
+// must set requiredPostFields.
+func LintPage(data []byte, sourcePath string, opts LintOptions) []Problem {
+	var problems []Problem
+	report := func(line int, format string, args ...any) {
+		problems = append(problems, Problem{File: sourcePath, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	root, _, err := parseFrontmatter(data)
+	var syntaxErr *frontmatterError
+	if errors.As(err, &syntaxErr) {
+		report(syntaxErr.Line, "%s", syntaxErr.Msg)
+		return problems
+	}
+	if err != nil {
+		report(1, "%v", err)
+		return problems
+	}
+
+	kinds := make(map[string]fieldKind, len(fieldKinds)+len(opts.Taxonomies)+len(opts.Params))
+	for _, name := range opts.Params {
+		kinds[name] = kindParam
+	}
+	for _, tax := range opts.Taxonomies {
+		kinds[tax.Name] = kindTaxonomy
+	}
+	for key, kind := range fieldKinds {
+		kinds[key] = kind
+	}
+
+	seen := make(map[string]bool)
+	if root != nil {
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			keyNode, value := root.Content[i], root.Content[i+1]
+			key := keyNode.Value
+			line := keyNode.Line
+
+			if seen[key] {
+				report(line, "duplicate key %q", key)
+				continue
+			}
+			seen[key] = true
+
+			kind, ok := kinds[key]
+			if !ok {
+				if suggestion := closestKey(key, kinds); suggestion != "" {
+					report(line, "unknown key %q (did you mean %q?)", key, suggestion)
+				} else {
+					report(line, "unknown key %q", key)
+				}
+				continue
+			}
+
+			if msg, at := checkValue(key, kind, value); msg != "" {
+				if at > 0 {
+					line = at
+				}
+				report(line, "%s %s", key, msg)
+				continue
+			}
+
+			if key == "icon" && opts.Icons != nil && !slices.Contains(opts.Icons, value.Value) {
+				report(line, "unknown icon %q (available: %s)", value.Value, strings.Join(opts.Icons, ", "))
+			}
+		}
+	}
+
+	isPost := strings.HasPrefix(sourcePath, "content/blog/posts/") && !strings.HasSuffix(sourcePath, "_index.md")
+	if isPost {
+		for _, key := range requiredPostFields {
+			if !seen[key] {
+				report(1, "blog posts must set %q", key)
+			}
+		}
+	}
+
+	return problems
+}
+
+// checkValue returns what is wrong with value for the field key of kind, or
+// "". For an unknown key in a nested mapping it also returns the line of that
+// key; otherwise the line is 0 and the problem is on the line of key.
+func checkValue(key string, kind fieldKind, value *yaml.Node) (msg string, line int) {
+	isScalar := value.Kind == yaml.ScalarNode && value.Tag != "!!null"
+
+	switch kind {
+	case kindString:
+		if !isScalar {
+			return "must be a single value", 0
+		}
+	case kindInt:
+		var n int
+		if !isScalar || value.Decode(&n) != nil {
+			return "must be an integer", 0
+		}
+	case kindBool:
+		var b bool
+		if !isScalar || value.Decode(&b) != nil {
+			return "must be true or false", 0
+		}
+	case kindDate:
+		if !isScalar {
+			return "must be a date", 0
+		}
+		if _, err := parseDate(value.Value); err != nil {
+			return fmt.Sprintf("must be a date like 2006-01-02, got %q", value.Value), 0
+		}
+	case kindTaxonomy:
+		if !isScalar && !isScalarList(value) {
+			return "must be a value or a list of values", 0
+		}
+	case kindSitemap, kindTOC:
+		var target yaml.Unmarshaler = &SitemapOptions{}
+		if kind == kindTOC {
+			target = &TOCOptions{}
+		}
+		if err := value.Decode(target); err != nil {
+			var keyErr *frontmatterError
+			if errors.As(err, &keyErr) {
+				return strings.TrimPrefix(keyErr.Msg, key+" "), keyErr.Line
+			}
+			return strings.TrimPrefix(lineRE.ReplaceAllString(err.Error(), ""), key+" "), 0
+		}
+	}
+	return "", 0
+}
+
+// checkKeys returns a frontmatterError at the line of the first key of
+// mapping that is not one of known. field names the mapping in the message.
+func checkKeys(mapping *yaml.Node, field string, known ...string) error {
+	if mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	kinds := make(map[string]fieldKind, len(known))
+	for _, k := range known {
+		kinds[k] = kindString
+	}
+	for i := 0; i < len(mapping.Content); i += 2 {
+		key := mapping.Content[i]
+		if _, ok := kinds[key.Value]; ok {
+			continue
+		}
+		if suggestion := closestKey(key.Value, kinds); suggestion != "" {
+			return &frontmatterError{Line: key.Line, Msg: fmt.Sprintf("%s has unknown key %q (did you mean %q?)", field, key.Value, suggestion)}
+		}
+		return &frontmatterError{Line: key.Line, Msg: fmt.Sprintf("%s has unknown key %q", field, key.Value)}
+	}
+	return nil
+}
+
+// isScalarList reports whether value is a sequence of scalars.
+func isScalarList(value *yaml.Node) bool {
+	if value.Kind != yaml.SequenceNode {
+		return false
+	}
+	for _, item := range value.Content {
+		if item.Kind != yaml.ScalarNode {
+			return false
+		}
+	}
+	return true
+}
+
+// lineRE matches the "line N: " prefix of YAML, sitemap and toc errors.
+var lineRE = regexp.MustCompile(`^(yaml: )?line (\d+): `)
+
+// closestKey returns the known key most similar to key, if any is within an
+// edit distance of 2.
+func closestKey(key string, kinds map[string]fieldKind) string {
+	best, bestDist := "", 3
+	for known := range kinds {
+		d := editDistance(strings.ToLower(key), strings.ToLower(known))
+		if d < bestDist || (d == bestDist && known < best) {
+			best, bestDist = known, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,146 @@
+package content
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/frostyard/site/internal/config"
+)
+
+func TestLintPage(t *testing.T) {
+	input := `---
+title: [Install, Guide]
+wieght: 3
+icon: snowman
+date: yesterday
+sitemap:
+  priority: 2
+tags: linux
+category: guides
+author: [a, b]
+---
+
+# Install
+`
+	opts := LintOptions{
+		Taxonomies: []config.Taxonomy{{Name: "category"}},
+		Icons:      []string{"server", "cloud"},
+	}
+	problems := LintPage([]byte(input), "content/docs/install.md", opts)
+
+	want := []struct {
+		line int
+		text string
+	}{
+		{2, "title must be a single value"},
+		{3, `unknown key "wieght" (did you mean "weight"?)`},
+		{4, `unknown icon "snowman"`},
+		{5, `date must be a date like 2006-01-02, got "yesterday"`},
+		{6, "sitemap priority must be between 0.0 and 1.0"},
+		{10, "author must be a single value"},
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("got %d problems, want %d: %v", len(problems), len(want), problems)
+	}
+	for i, w := range want {
+		p := problems[i]
+		if p.File != "content/docs/install.md" || p.Line != w.line || !strings.Contains(p.Message, w.text) {
+			t.Errorf("problem %d = %s, want line %d containing %q", i, p, w.line, w.text)
+		}
+	}
+}
+
+func TestLintPageValid(t *testing.T) {
+	input := `---
+title: "Hello"
+date: 2025-01-15
+publishDate: "2025-01-16T09:00:00Z"
+weight: 2
+draft: false
+icon: cloud
+tags: [a, b]
+sitemap: false
+---
+`
+	problems := LintPage([]byte(input), "content/blog/posts/hello.md", LintOptions{Icons: []string{"cloud"}})
+	if len(problems) != 0 {
+		t.Errorf("got problems for valid frontmatter: %v", problems)
+	}
+}
+
+func TestLintPageRequiredPostFields(t *testing.T) {
+	problems := LintPage([]byte("---\ntitle: \"Hello\"\n---\n"), "content/blog/posts/hello.md", LintOptions{})
+	if len(problems) != 1 || !strings.Contains(problems[0].Message, `"date"`) {
+		t.Errorf("problems = %v, want one about a missing date", problems)
+	}
+
+	problems = LintPage([]byte("---\ntitle: \"Blog\"\n---\n"), "content/blog/posts/_index.md", LintOptions{})
+	if len(problems) != 0 {
+		t.Errorf("problems for section index = %v, want none", problems)
+	}
+}
+
+func TestLintPageSyntaxError(t *testing.T) {
+	input := "---\ntitle: \"Hello\"\ntags: [a, b\n---\n"
+	problems := LintPage([]byte(input), "content/docs/a.md", LintOptions{})
+	// yaml.v3 reports some syntax errors at the line before the problem, so
+	// only require a line inside the frontmatter
+	if len(problems) != 1 || problems[0].Line < 2 || problems[0].Line > 3 {
+		t.Errorf("problems = %v, want one syntax error inside the frontmatter", problems)
+	}
+}
+
+// TestFieldKindsCoverPage guards against adding a frontmatter field to Page
+// without teaching the linter about it.
+func TestFieldKindsCoverPage(t *testing.T) {
+	typ := reflect.TypeOf(Page{})
+	for i := range typ.NumField() {
+		tag := strings.Split(typ.Field(i).Tag.Get("yaml"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+		if _, ok := fieldKinds[tag]; !ok {
+			t.Errorf("frontmatter field %q missing from fieldKinds", tag)
+		}
+	}
+}
+
+func TestLintPageParams(t *testing.T) {
+	input := "---\ntitle: \"Hello\"\nstatus: beta\nmin_version: 1.2\nimage_ref: {name: snow}\n---\n"
+	problems := LintPage([]byte(input), "content/docs/a.md", LintOptions{Params: []string{"status", "min_version", "image_ref"}})
+	if len(problems) != 0 {
+		t.Errorf("declared params reported as problems: %v", problems)
+	}
+
+	problems = LintPage([]byte(input), "content/docs/a.md", LintOptions{Params: []string{"status"}})
+	if len(problems) != 2 {
+		t.Errorf("problems = %v, want the two undeclared params", problems)
+	}
+}
+
+func TestLintPageNestedKeys(t *testing.T) {
+	input := `---
+title: "Hello"
+sitemap:
+  priority: 0.5
+  changeFreq: weekly
+toc:
+  maxlevel: 4
+  depth: 2
+---
+`
+	problems := LintPage([]byte(input), "content/docs/a.md", LintOptions{})
+	want := []Problem{
+		{File: "content/docs/a.md", Line: 5, Message: `sitemap has unknown key "changeFreq" (did you mean "changefreq"?)`},
+		{File: "content/docs/a.md", Line: 7, Message: `toc has unknown key "maxlevel" (did you mean "maxLevel"?)`},
+	}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("problems = %v, want %v", problems, want)
+	}
+
+	// Parsing the page fails the same way, so a build cannot ignore them
+	if _, err := ParsePage([]byte(input), "content/docs/a.md"); err == nil || !strings.Contains(err.Error(), `unknown key "changeFreq"`) {
+		t.Errorf("ParsePage error = %v, want the unknown sitemap key", err)
+	}
+}
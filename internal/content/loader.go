@@ -1,22 +1,83 @@
 package content
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
+
+	"github.com/frostyard/site/internal/config"
+	"github.com/frostyard/site/internal/parallel"
 )
 
-// LoadContent walks contentDir, parses all .md files, skips drafts,
-// separates blog posts, and builds a section tree.
-func LoadContent(contentDir string) (*Site, error) {
-	var allPages []*Page
-	var posts []*Page
+// LoadOptions controls how LoadContent builds the site.
+type LoadOptions struct {
+	Taxonomies []config.Taxonomy // Frontmatter fields to index as taxonomies
+	Drafts     bool              // Include pages with draft: true
+	Future     bool              // Include pages published after Now
+	Expired    bool              // Include pages that expired before Now
+	Now        time.Time         // Reference time for publish and expiry dates; zero means time.Now()
+	Jobs       int               // Files parsed in parallel by LoadContent; all CPUs if < 1
+}
 
-	// The parent of contentDir — sourcePaths should be relative to this
-	// so that they start with "content/".
-	baseDir := filepath.Dir(contentDir)
+// includes reports whether page is part of the site under these options.
+func (o LoadOptions) includes(page *Page) bool {
+	return o.excludedBecause(page) == ""
+}
+
+// excludedBecause returns why page is not part of the site under these
+// options ("a draft", "not published yet" or "expired"), or "" if it is.
+func (o LoadOptions) excludedBecause(page *Page) string {
+	now := o.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	if page.Draft && !o.Drafts {
+		return "a draft"
+	}
+	if publish := page.PublishTime(); publish.After(now) && !o.Future {
+		return "not published yet"
+	}
+	if expiry := page.ParsedExpiry; !expiry.IsZero() && !expiry.After(now) && !o.Expired {
+		return "expired"
+	}
+	return ""
+}
+
+// LoadContent walks contentDir, parses all .md files, skips unpublished
+// pages (drafts, future and expired pages unless opts include them),
+// separates blog posts, and builds a section tree and taxonomy index. Links
+// from published pages to drafts are errors; links to future or expired
+// pages are allowed (see CheckPublishedLinks).
+func LoadContent(contentDir string, opts LoadOptions) (*Site, error) {
+	pages, err := ParseDir(contentDir, opts.Jobs)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := CheckPublishedLinks(pages, opts); err != nil {
+		return nil, err
+	}
+	return NewSite(pages, opts), nil
+}
+
+// ParseDir walks contentDir and parses every .md file, including drafts, on
+// up to jobs goroutines (all CPUs if jobs < 1). Pages are returned in lexical
+// order of their source paths. If any files fail to parse, the returned error
+// joins the errors of all of them, in the same order. Links to .md files that
+// were not parsed are errors too (see CheckLinks). The markdown is rendered
+// with the default Renderer.
+func ParseDir(contentDir string, jobs int) ([]*Page, error) {
+	return defaultRenderer.ParseDir(contentDir, jobs)
+}
+
+// ParseDir is like the package-level ParseDir, but renders the markdown
+// with r.
+func (r *Renderer) ParseDir(contentDir string, jobs int) ([]*Page, error) {
+	var paths []string
 
 	err := filepath.Walk(contentDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -28,27 +89,85 @@ func LoadContent(contentDir string) (*Site, error) {
 			return nil
 		}
 
-		data, err := os.ReadFile(path)
-		if err != nil {
-			return fmt.Errorf("reading %s: %w", path, err)
-		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking content directory: %w", err)
+	}
 
-		// Compute sourcePath relative to baseDir so it starts with "content/"
-		sourcePath, err := filepath.Rel(baseDir, path)
-		if err != nil {
-			return fmt.Errorf("computing relative path for %s: %w", path, err)
-		}
-		// Normalize to forward slashes for consistent path handling
-		sourcePath = filepath.ToSlash(sourcePath)
+	pages := make([]*Page, len(paths))
+	errs := make([]error, len(paths))
+	parallel.Run(len(paths), jobs, func(i int) {
+		pages[i], errs[i] = r.LoadPage(contentDir, paths[i])
+	})
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
 
-		page, err := ParsePage(data, sourcePath)
-		if err != nil {
-			return fmt.Errorf("parsing %s: %w", sourcePath, err)
-		}
+	if err := CheckLinks(pages); err != nil {
+		return nil, err
+	}
 
-		// Skip drafts
-		if page.Draft {
-			return nil
+	return pages, nil
+}
+
+// LoadPage reads and parses the markdown file at path, which must be inside contentDir.
+func LoadPage(contentDir, path string) (*Page, error) {
+	return defaultRenderer.LoadPage(contentDir, path)
+}
+
+// LoadPage is like the package-level LoadPage, but renders the markdown
+// with r.
+func (r *Renderer) LoadPage(contentDir, path string) (*Page, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	// The parent of contentDir — sourcePaths should be relative to this
+	// so that they start with "content/".
+	baseDir := filepath.Dir(contentDir)
+
+	// Compute sourcePath relative to baseDir so it starts with "content/"
+	sourcePath, err := filepath.Rel(baseDir, path)
+	if err != nil {
+		return nil, fmt.Errorf("computing relative path for %s: %w", path, err)
+	}
+	// Normalize to forward slashes for consistent path handling
+	sourcePath = filepath.ToSlash(sourcePath)
+
+	page, err := r.ParsePage(data, sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", sourcePath, err)
+	}
+	page.ModTime = info.ModTime()
+
+	return page, nil
+}
+
+// NewSite assembles a Site from parsed pages: it skips unpublished pages
+// (see LoadOptions), separates and links blog posts, and builds the section
+// tree and taxonomy index. Derived page fields (PrevPost, NextPost, Terms)
+// are reset first, so NewSite can be called again on the same pages after
+// some of them change.
+func NewSite(pages []*Page, opts LoadOptions) *Site {
+	var allPages []*Page
+	var posts []*Page
+
+	for _, page := range pages {
+		page.PrevPost = nil
+		page.NextPost = nil
+		page.Terms = nil
+
+		// Skip drafts, future and expired pages
+		if !opts.includes(page) {
+			continue
 		}
 
 		allPages = append(allPages, page)
@@ -57,11 +176,6 @@ func LoadContent(contentDir string) (*Site, error) {
 		if strings.HasPrefix(page.Path, "/blog/posts/") && !page.IsIndex {
 			posts = append(posts, page)
 		}
-
-		return nil
-	})
-	if err != nil {
-		return nil, fmt.Errorf("walking content directory: %w", err)
 	}
 
 	// Sort posts by date descending
@@ -69,14 +183,26 @@ func LoadContent(contentDir string) (*Site, error) {
 		return posts[i].ParsedDate.After(posts[j].ParsedDate)
 	})
 
+	// Link neighbouring posts: posts are newest first, so the previous
+	// (older) post follows in the slice and the next (newer) one precedes it.
+	for i, post := range posts {
+		if i > 0 {
+			post.NextPost = posts[i-1]
+		}
+		if i < len(posts)-1 {
+			post.PrevPost = posts[i+1]
+		}
+	}
+
 	// Build section tree
 	sections := buildSectionTree(allPages)
 
 	return &Site{
-		Pages:    allPages,
-		Posts:    posts,
-		Sections: sections,
-	}, nil
+		Pages:      allPages,
+		Posts:      posts,
+		Sections:   sections,
+		Taxonomies: buildTaxonomies(allPages, opts.Taxonomies),
+	}
 }
 
 // buildSectionTree organizes pages into a hierarchical section tree.
@@ -100,6 +226,7 @@ func buildSectionTree(pages []*Page) []*Section {
 			Path:        p.Path,
 			IndexPage:   p,
 			Weight:      p.Weight,
+			Params:      p.Params,
 		}
 		sectionMap[p.Path] = sec
 	}
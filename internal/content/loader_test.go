@@ -3,7 +3,12 @@ package content
 import (
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/frostyard/site/internal/config"
 )
 
 // helper to write a file at the given path under dir, creating parent dirs as needed.
@@ -96,7 +101,7 @@ Hello world blog post.
 `)
 
 	contentDir := filepath.Join(tmp, "content")
-	site, err := LoadContent(contentDir)
+	site, err := LoadContent(contentDir, LoadOptions{})
 	if err != nil {
 		t.Fatalf("LoadContent returned error: %v", err)
 	}
@@ -211,7 +216,7 @@ This page should NOT appear.
 `)
 
 	contentDir := filepath.Join(tmp, "content")
-	site, err := LoadContent(contentDir)
+	site, err := LoadContent(contentDir, LoadOptions{})
 	if err != nil {
 		t.Fatalf("LoadContent returned error: %v", err)
 	}
@@ -231,3 +236,203 @@ This page should NOT appear.
 		}
 	}
 }
+
+func TestLoadContentPublishOptions(t *testing.T) {
+	tmp := t.TempDir()
+	writeFile(t, tmp, "content/current.md", "---\ntitle: \"Current\"\ndate: \"2026-01-01\"\n---\n")
+	writeFile(t, tmp, "content/draft.md", "---\ntitle: \"Draft\"\ndraft: true\n---\n")
+	writeFile(t, tmp, "content/future.md", "---\ntitle: \"Future\"\ndate: \"2026-01-01\"\npublishDate: \"2026-09-01\"\n---\n")
+	writeFile(t, tmp, "content/dated.md", "---\ntitle: \"Dated\"\ndate: \"2026-12-01\"\n---\n")
+	writeFile(t, tmp, "content/expired.md", "---\ntitle: \"Expired\"\nexpiryDate: \"2026-03-01\"\n---\n")
+	contentDir := filepath.Join(tmp, "content")
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		opts LoadOptions
+		want []string
+	}{
+		{"default", LoadOptions{}, []string{"Current"}},
+		{"drafts", LoadOptions{Drafts: true}, []string{"Current", "Draft"}},
+		{"future", LoadOptions{Future: true}, []string{"Current", "Dated", "Future"}},
+		{"expired", LoadOptions{Expired: true}, []string{"Current", "Expired"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Now = now
+			site, err := LoadContent(contentDir, tt.opts)
+			if err != nil {
+				t.Fatalf("LoadContent returned error: %v", err)
+			}
+			var got []string
+			for _, p := range site.Pages {
+				got = append(got, p.Title)
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("pages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDirAggregatesErrors(t *testing.T) {
+	tmp := t.TempDir()
+	writeFile(t, tmp, "content/a.md", "---\ntitle: [unclosed\n---\n")
+	writeFile(t, tmp, "content/b.md", "---\ntitle: \"Fine\"\n---\n")
+	writeFile(t, tmp, "content/c.md", "---\nweight: heavy\n---\n")
+
+	_, err := ParseDir(filepath.Join(tmp, "content"), 4)
+	if err == nil {
+		t.Fatal("ParseDir should fail on invalid frontmatter")
+	}
+	msg := err.Error()
+	a, c := strings.Index(msg, "content/a.md"), strings.Index(msg, "content/c.md")
+	if a < 0 || c < 0 {
+		t.Fatalf("error should name every failing file, got: %v", err)
+	}
+	if a > c {
+		t.Errorf("errors should be reported in path order, got: %v", err)
+	}
+	if strings.Contains(msg, "content/b.md") {
+		t.Errorf("error should not mention the valid file, got: %v", err)
+	}
+}
+
+func TestLoadContentLinksPosts(t *testing.T) {
+	tmp := t.TempDir()
+
+	writeFile(t, tmp, "content/blog/posts/first.md", `---
+title: "First"
+date: "2026-01-01"
+---
+`)
+	writeFile(t, tmp, "content/blog/posts/second.md", `---
+title: "Second"
+date: "2026-02-01"
+---
+`)
+	writeFile(t, tmp, "content/blog/posts/third.md", `---
+title: "Third"
+date: "2026-03-01"
+---
+`)
+
+	site, err := LoadContent(filepath.Join(tmp, "content"), LoadOptions{})
+	if err != nil {
+		t.Fatalf("LoadContent returned error: %v", err)
+	}
+	if len(site.Posts) != 3 {
+		t.Fatalf("len(site.Posts) = %d, want 3", len(site.Posts))
+	}
+
+	third, second, first := site.Posts[0], site.Posts[1], site.Posts[2]
+	if third.NextPost != nil {
+		t.Errorf("newest post NextPost = %q, want nil", third.NextPost.Title)
+	}
+	if third.PrevPost != second {
+		t.Errorf("Third.PrevPost = %v, want Second", third.PrevPost)
+	}
+	if second.PrevPost != first || second.NextPost != third {
+		t.Errorf("Second links = (%v, %v), want (First, Third)", second.PrevPost, second.NextPost)
+	}
+	if first.PrevPost != nil {
+		t.Errorf("oldest post PrevPost = %q, want nil", first.PrevPost.Title)
+	}
+}
+
+func TestLoadContentTaxonomies(t *testing.T) {
+	tmp := t.TempDir()
+
+	writeFile(t, tmp, "content/blog/posts/one.md", `---
+title: "One"
+date: "2026-01-01"
+tags: [Release, nbc]
+---
+`)
+	writeFile(t, tmp, "content/blog/posts/two.md", `---
+title: "Two"
+date: "2026-02-01"
+tags: [release, release]
+---
+`)
+	writeFile(t, tmp, "content/docs/snow.md", `---
+title: "Snow"
+image: snow
+---
+`)
+
+	opts := LoadOptions{
+		Taxonomies: []config.Taxonomy{
+			{Name: "tags", Title: "Tags", Singular: "Tag"},
+			{Name: "image", Title: "Images", Singular: "Image"},
+		},
+	}
+	site, err := LoadContent(filepath.Join(tmp, "content"), opts)
+	if err != nil {
+		t.Fatalf("LoadContent returned error: %v", err)
+	}
+
+	if len(site.Taxonomies) != 2 {
+		t.Fatalf("len(site.Taxonomies) = %d, want 2", len(site.Taxonomies))
+	}
+
+	tags := site.Taxonomy("tags")
+	if tags == nil || tags.Path != "/tags/" {
+		t.Fatalf("tags taxonomy = %+v, want path /tags/", tags)
+	}
+	if len(tags.Terms) != 2 {
+		t.Fatalf("len(tags.Terms) = %d, want 2", len(tags.Terms))
+	}
+
+	nbc, release := tags.Terms[0], tags.Terms[1]
+	if nbc.Slug != "nbc" || len(nbc.Pages) != 1 {
+		t.Errorf("nbc term = %+v, want slug nbc with 1 page", nbc)
+	}
+	if release.Name != "Release" || release.Path != "/tags/release/" {
+		t.Errorf("release term = (%q, %q), want (Release, /tags/release/)", release.Name, release.Path)
+	}
+	// Case-insensitive match and per-page de-duplication: one entry per page, newest first
+	if len(release.Pages) != 2 {
+		t.Fatalf("len(release.Pages) = %d, want 2", len(release.Pages))
+	}
+	if release.Pages[0].Title != "Two" {
+		t.Errorf("release.Pages[0] = %q, want newest post %q", release.Pages[0].Title, "Two")
+	}
+	if got := release.Pages[0].Terms["tags"]; len(got) != 1 || got[0] != release {
+		t.Errorf("Two.Terms[tags] = %v, want [release]", got)
+	}
+
+	// A scalar frontmatter value is a single term
+	images := site.Taxonomy("image")
+	if images == nil || len(images.Terms) != 1 || images.Terms[0].Path != "/image/snow/" {
+		t.Errorf("image taxonomy = %+v, want single term at /image/snow/", images)
+	}
+
+	if site.Taxonomy("category") != nil {
+		t.Error("site.Taxonomy(\"category\") != nil, want nil for unconfigured taxonomy")
+	}
+}
+
+func TestLoadContentParams(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "docs/_index.md", "---\ntitle: \"Docs\"\nstatus: stable\n---\n")
+	writeFile(t, dir, "docs/nbc.md", "---\ntitle: \"nbc\"\nmin_version: \"1.2\"\nimage_ref: {name: snow, tag: latest}\n---\n")
+
+	site, err := LoadContent(dir, LoadOptions{})
+	if err != nil {
+		t.Fatalf("LoadContent returned error: %v", err)
+	}
+
+	if len(site.Sections) != 1 || site.Sections[0].Params["status"] != "stable" {
+		t.Fatalf("section params = %v, want status: stable", site.Sections[0].Params)
+	}
+	page := site.Sections[0].Pages[0]
+	if page.Params["min_version"] != "1.2" || page.Params["title"] != "nbc" {
+		t.Errorf("page params = %v, want min_version and title", page.Params)
+	}
+	ref, ok := page.Params["image_ref"].(map[string]any)
+	if !ok || ref["name"] != "snow" {
+		t.Errorf("image_ref = %#v, want a map with name: snow", page.Params["image_ref"])
+	}
+}
@@ -0,0 +1,234 @@
+package content
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// mathKey holds true while parsing a page with math: true in its
+// frontmatter. Other pages keep their dollar signs as text.
+var mathKey = parser.NewContextKey()
+
+// mathEnabled reports whether the page being parsed has math turned on.
+func mathEnabled(pc parser.Context) bool {
+	on, _ := pc.Get(mathKey).(bool)
+	return on
+}
+
+// kindMath and kindMathBlock are the AST node kinds of inline and display
+// math.
+var (
+	kindMath      = ast.NewNodeKind("Math")
+	kindMathBlock = ast.NewNodeKind("MathBlock")
+)
+
+// mathNode is inline math, already converted to MathML.
+type mathNode struct {
+	ast.BaseInline
+	MathML string
+}
+
+func (n *mathNode) Kind() ast.NodeKind { return kindMath }
+
+func (n *mathNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// mathBlockNode is a $$ block of display math. Its lines hold the TeX
+// source until the block is closed and converted to MathML.
+type mathBlockNode struct {
+	ast.BaseBlock
+	MathML string
+	line   int  // Line of the opening $$
+	closed bool // Whether the closing $$ was found
+}
+
+func (n *mathBlockNode) Kind() ast.NodeKind { return kindMathBlock }
+
+// IsRaw keeps the parser from parsing the TeX source as inline markdown.
+func (n *mathBlockNode) IsRaw() bool { return true }
+
+func (n *mathBlockNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// mathExtension renders TeX math to MathML at build time, on pages that
+// turn it on with math: true:
+//
+//	Inline math such as $e^{i\pi} + 1 = 0$, and display math:
+//
+//	$$
+//	\sum_{i=1}^{n} i = \frac{n(n+1)}{2}
+//	$$
+//
+// An opening $ must not be followed by a space, and a closing $ must not be
+// preceded by a space or followed by a digit, so prices such as $5 and $10
+// stay text. \$ is a literal dollar sign. Math that fails to convert fails
+// the page.
+type mathExtension struct{}
+
+func (mathExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(mathBlockParser{}, 730)),
+		parser.WithInlineParsers(util.Prioritized(mathInlineParser{}, 500)),
+	)
+	m.Renderer().AddOptions(
+		renderer.WithNodeRenderers(util.Prioritized(mathRenderer{}, 100)),
+	)
+}
+
+// mathInlineParser parses $...$ and, within a paragraph, $$...$$ as
+// display math.
+type mathInlineParser struct{}
+
+func (mathInlineParser) Trigger() []byte { return []byte{'$'} }
+
+func (mathInlineParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	if !mathEnabled(pc) {
+		return nil
+	}
+	line, segment := block.PeekLine()
+
+	display := bytes.HasPrefix(line, []byte("$$"))
+	var tex []byte
+	var length int
+	if display {
+		end := bytes.Index(line[2:], []byte("$$"))
+		if end <= 0 {
+			return nil
+		}
+		tex, length = line[2:2+end], end+4
+	} else {
+		if len(line) < 3 || util.IsSpace(line[1]) {
+			return nil
+		}
+		for i := 2; i < len(line); i++ {
+			if line[i] == '\\' {
+				i++
+				continue
+			}
+			if line[i] == '$' && !util.IsSpace(line[i-1]) && (i+1 == len(line) || !isDigit(line[i+1])) {
+				tex, length = line[1:i], i+1
+				break
+			}
+		}
+		if tex == nil {
+			return nil
+		}
+	}
+
+	mathml, err := texToMathML(string(tex), display)
+	if err != nil {
+		lineNum := bytes.Count(block.Source()[:segment.Start], []byte("\n")) + 1
+		setMarkdownError(pc, lineNum, fmt.Sprintf("math: %v", err))
+		return nil
+	}
+	block.Advance(length)
+	return &mathNode{MathML: mathml}
+}
+
+// mathBlockParser parses display math between $$ lines. The opening $$ may
+// be followed by math, and the closing $$ preceded by it, so a block can
+// also be a single $$...$$ line.
+type mathBlockParser struct{}
+
+func (mathBlockParser) Trigger() []byte { return []byte{'$'} }
+
+func (mathBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	if !mathEnabled(pc) {
+		return nil, parser.NoChildren
+	}
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || !bytes.HasPrefix(line[pos:], []byte("$$")) {
+		return nil, parser.NoChildren
+	}
+
+	node := &mathBlockNode{line: bytes.Count(reader.Source()[:segment.Start], []byte("\n")) + 1}
+	rest := bytes.TrimRight(line[pos+2:], " \t\r\n")
+	start := segment.Start + pos + 2
+	if bytes.HasSuffix(rest, []byte("$$")) {
+		// A single line, which must hold nothing after the closing $$
+		node.Lines().Append(text.NewSegment(start, start+len(rest)-2))
+		node.closed = true
+		reader.Advance(len(bytes.TrimRight(line, "\r\n")))
+		return node, parser.NoChildren
+	}
+	if bytes.Contains(rest, []byte("$$")) {
+		// Inline display math followed by more text
+		return nil, parser.NoChildren
+	}
+	node.Lines().Append(text.NewSegment(start, start+len(rest)))
+	reader.Advance(len(bytes.TrimRight(line, "\r\n")))
+	return node, parser.NoChildren
+}
+
+func (mathBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	if node.(*mathBlockNode).closed {
+		return parser.Close
+	}
+	line, segment := reader.PeekLine()
+	trimmed := bytes.TrimRight(line, " \t\r\n")
+	if bytes.HasSuffix(trimmed, []byte("$$")) {
+		node.Lines().Append(text.NewSegment(segment.Start, segment.Start+len(trimmed)-2))
+		node.(*mathBlockNode).closed = true
+		reader.Advance(len(bytes.TrimRight(line, "\r\n")))
+		return parser.Close
+	}
+	node.Lines().Append(segment)
+	reader.Advance(len(bytes.TrimRight(line, "\r\n")))
+	return parser.Continue | parser.NoChildren
+}
+
+func (mathBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {
+	n := node.(*mathBlockNode)
+	if !n.closed {
+		setMarkdownError(pc, n.line, "math: $$ block has no closing $$")
+		return
+	}
+
+	var tex bytes.Buffer
+	for i := 0; i < n.Lines().Len(); i++ {
+		segment := n.Lines().At(i)
+		tex.Write(segment.Value(reader.Source()))
+		tex.WriteByte('\n')
+	}
+	mathml, err := texToMathML(tex.String(), true)
+	if err != nil {
+		setMarkdownError(pc, n.line, fmt.Sprintf("math: %v", err))
+		return
+	}
+	n.MathML = mathml
+}
+
+func (mathBlockParser) CanInterruptParagraph() bool { return true }
+
+func (mathBlockParser) CanAcceptIndentedLine() bool { return false }
+
+// mathRenderer writes the MathML of math nodes.
+type mathRenderer struct{}
+
+func (mathRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindMath, renderMath)
+	reg.Register(kindMathBlock, renderMath)
+}
+
+func renderMath(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	switch n := node.(type) {
+	case *mathNode:
+		_, _ = w.WriteString(n.MathML)
+	case *mathBlockNode:
+		_, _ = w.WriteString(`<div class="math-display">` + n.MathML + "</div>\n")
+	}
+	return ast.WalkSkipChildren, nil
+}
@@ -0,0 +1,150 @@
+package content
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTeXToMathML(t *testing.T) {
+	tests := []struct {
+		tex  string
+		want string // Expected inside <semantics>, before the annotation
+	}{
+		{`x^2`, `<msup><mi>x</mi><mn>2</mn></msup>`},
+		{`x_i^{10}`, `<msubsup><mi>x</mi><mi>i</mi><mrow><mn>10</mn></mrow></msubsup>`},
+		{`x^23`, `<mrow><msup><mi>x</mi><mn>2</mn></msup><mn>3</mn></mrow>`},
+		{`3.14 - a`, `<mrow><mn>3.14</mn><mo>−</mo><mi>a</mi></mrow>`},
+		{`\frac{a}{b}`, `<mfrac><mrow><mi>a</mi></mrow><mrow><mi>b</mi></mrow></mfrac>`},
+		{`\sqrt[3]{x}`, `<mroot><mrow><mi>x</mi></mrow><mrow><mn>3</mn></mrow></mroot>`},
+		{`\sqrt x`, `<msqrt><mi>x</mi></msqrt>`},
+		{`\alpha \Gamma`, `<mrow><mi>α</mi><mi mathvariant="normal">Γ</mi></mrow>`},
+		{`a \leq b`, `<mrow><mi>a</mi><mo>≤</mo><mi>b</mi></mrow>`},
+		{`a < b`, `<mrow><mi>a</mi><mo>&lt;</mo><mi>b</mi></mrow>`},
+		{`\sum_{i=1}^n`, `<munderover><mo>∑</mo><mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mi>n</mi></munderover>`},
+		{`\int_0^1`, `<msubsup><mo>∫</mo><mn>0</mn><mn>1</mn></msubsup>`},
+		{`\lim_{x \to 0}`, `<munder><mo movablelimits="true" form="prefix">lim</mo><mrow><mi>x</mi><mo>→</mo><mn>0</mn></mrow></munder>`},
+		{`\sin x`, "<mrow><mi>sin</mi><mo>\u2061</mo><mi>x</mi></mrow>"},
+		{`f'`, `<msup><mi>f</mi><mrow><mo>′</mo></mrow></msup>`},
+		{`\left( x \right.`, `<mrow><mo fence="true" stretchy="true" symmetric="true">(</mo><mi>x</mi></mrow>`},
+		{`\hat{x}`, `<mover accent="true"><mrow><mi>x</mi></mrow><mo stretchy="false">^</mo></mover>`},
+		{`\mathbb{R}`, `<mi>ℝ</mi>`},
+		{`\mathbf{v1}`, `<mrow><mi>𝐯</mi><mn>𝟏</mn></mrow>`},
+		{`\mathrm{d}x`, `<mrow><mi mathvariant="normal">d</mi><mi>x</mi></mrow>`},
+		{`\text{if } x`, "<mrow><mtext>if\u00a0</mtext><mi>x</mi></mrow>"},
+		{`\begin{pmatrix} 1 & 2 \\ 3 & 4 \end{pmatrix}`, `<mrow><mo fence="true" stretchy="true" symmetric="true">(</mo><mtable><mtr><mtd><mn>1</mn></mtd><mtd><mn>2</mn></mtd></mtr><mtr><mtd><mn>3</mn></mtd><mtd><mn>4</mn></mtd></mtr></mtable><mo fence="true" stretchy="true" symmetric="true">)</mo></mrow>`},
+		{`\begin{aligned} a &= b \\ &= c \\ \end{aligned}`, `<mrow><mtable columnalign="right left"><mtr><mtd><mi>a</mi></mtd><mtd><mo>=</mo><mi>b</mi></mtd></mtr><mtr><mtd></mtd><mtd><mo>=</mo><mi>c</mi></mtd></mtr></mtable></mrow>`},
+		{"\\begin{aligned}\n\ta &= b \\\\\n\t&= c\n\\end{aligned}", `<mrow><mtable columnalign="right left"><mtr><mtd><mi>a</mi></mtd><mtd><mo>=</mo><mi>b</mi></mtd></mtr><mtr><mtd></mtd><mtd><mo>=</mo><mi>c</mi></mtd></mtr></mtable></mrow>`},
+	}
+	for _, tt := range tests {
+		got, err := texToMathML(tt.tex, false)
+		if err != nil {
+			t.Errorf("texToMathML(%q): %v", tt.tex, err)
+			continue
+		}
+		body := strings.TrimPrefix(got, `<math xmlns="http://www.w3.org/1998/Math/MathML"><semantics>`)
+		body, _, _ = strings.Cut(body, "<annotation")
+		if body != tt.want {
+			t.Errorf("texToMathML(%q) =\n%s\nwant\n%s", tt.tex, body, tt.want)
+		}
+	}
+
+	got, err := texToMathML("a", true)
+	if err != nil || !strings.HasPrefix(got, `<math xmlns="http://www.w3.org/1998/Math/MathML" display="block">`) ||
+		!strings.Contains(got, `<annotation encoding="application/x-tex">a</annotation>`) {
+		t.Errorf("display math = %s, %v", got, err)
+	}
+}
+
+func TestTeXToMathMLErrors(t *testing.T) {
+	tests := []struct {
+		tex, want string
+	}{
+		{`\foo`, `unknown command \foo`},
+		{`{x`, `unclosed {`},
+		{`x}`, `unexpected }`},
+		{`x^`, `^ needs an argument`},
+		{`x^1^2`, `double superscript`},
+		{`\frac{a}`, `\frac needs an argument`},
+		{`\left( x`, `\left has no matching \right`},
+		{`\begin{foo}x\end{foo}`, `unknown environment foo`},
+		{`\begin{matrix}x\end{cases}`, `\begin{matrix} ended by \end{cases}`},
+		{`\begin{matrix}x`, `\begin{matrix} has no \end{matrix}`},
+		{`a & b`, `unexpected &`},
+		{"x\x1b", `unexpected control character U+001B`},
+	}
+	for _, tt := range tests {
+		_, err := texToMathML(tt.tex, false)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("texToMathML(%q) error = %v, want %q", tt.tex, err, tt.want)
+		}
+	}
+}
+
+func TestParseMath(t *testing.T) {
+	input := `---
+title: "Math"
+math: true
+---
+
+Euler: $e^{i\pi} + 1 = 0$. It costs $5 and $10, or \$x\$.
+
+$$
+\int_0^1 x\,dx
+$$
+
+$$ a = b $$
+
+Inline $$c$$ display, and ` + "`$code$`" + `.
+`
+	page, err := ParsePage([]byte(input), "content/docs/math.md")
+	if err != nil {
+		t.Fatalf("ParsePage: %v", err)
+	}
+	html := string(page.Content)
+
+	for _, want := range []string{
+		`<p>Euler: <math xmlns="http://www.w3.org/1998/Math/MathML"><semantics><mrow><msup><mi>e</mi>`,
+		`</math>. It costs $5 and $10, or $x$.</p>`,
+		`<div class="math-display"><math xmlns="http://www.w3.org/1998/Math/MathML" display="block"><semantics><mrow><msubsup><mo>∫</mo>`,
+		`<annotation encoding="application/x-tex">a = b</annotation>`,
+		`<p>Inline <math xmlns="http://www.w3.org/1998/Math/MathML" display="block"><semantics><mi>c</mi>`,
+		`<code>$code$</code>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("missing %q in:\n%s", want, html)
+		}
+	}
+	if n := strings.Count(html, "<math "); n != 4 {
+		t.Errorf("rendered %d math elements, want 4:\n%s", n, html)
+	}
+}
+
+func TestParseMathOptIn(t *testing.T) {
+	input := "---\ntitle: \"No math\"\n---\n\nIt is $x$ here.\n\n$$\ny\n$$\n"
+	page, err := ParsePage([]byte(input), "content/docs/plain.md")
+	if err != nil {
+		t.Fatalf("ParsePage: %v", err)
+	}
+	if strings.Contains(string(page.Content), "<math") || !strings.Contains(string(page.Content), "It is $x$ here.") {
+		t.Errorf("math rendered without math: true:\n%s", page.Content)
+	}
+}
+
+func TestParseMathErrors(t *testing.T) {
+	tests := []struct {
+		name, body, want string
+	}{
+		{"inline", "Text\n\nSee $\\frac{a}$.\n", `line 8: math: \frac needs an argument`},
+		{"block", "Text\n\n$$\n\\bad\n$$\n", `line 8: math: unknown command \bad`},
+		{"unclosed", "$$\nx\n", `line 6: math: $$ block has no closing $$`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := "---\ntitle: \"Math\"\nmath: true\n---\n\n" + tt.body
+			_, err := ParsePage([]byte(input), "content/docs/math.md")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,838 @@
+package content
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// texToMathML converts TeX math to a MathML <math> element, which browsers
+// render without any script. display renders it as a block, like $$...$$.
+// The TeX source is kept in an annotation, for copying and screen readers.
+//
+// It supports the subset of LaTeX used in technical writing: scripts,
+// fractions, roots, Greek letters and common symbols, operators with
+// limits, \left...\right, accents, font commands, \text and the matrix,
+// cases, aligned and array environments. Control characters other than tabs
+// and line breaks are rejected.
+func texToMathML(tex string, display bool) (string, error) {
+	for _, r := range tex {
+		if unicode.IsControl(r) && !isTeXSpace(byte(r)) {
+			return "", fmt.Errorf("unexpected control character %U", r)
+		}
+	}
+
+	p := &mathParser{src: tex}
+	body, err := p.parseRow()
+	if err != nil {
+		return "", err
+	}
+	if tok := p.next(); tok.kind != tokEOF {
+		return "", fmt.Errorf("unexpected %s", tok.text)
+	}
+
+	var b strings.Builder
+	b.WriteString(`<math xmlns="http://www.w3.org/1998/Math/MathML"`)
+	if display {
+		b.WriteString(` display="block"`)
+	}
+	b.WriteString(`><semantics>`)
+	if len(body) == 1 {
+		b.WriteString(body[0])
+	} else {
+		b.WriteString("<mrow>" + strings.Join(body, "") + "</mrow>")
+	}
+	fmt.Fprintf(&b, `<annotation encoding="application/x-tex">%s</annotation>`, html.EscapeString(strings.TrimSpace(tex)))
+	b.WriteString(`</semantics></math>`)
+	return b.String(), nil
+}
+
+// Kinds of mathToken.
+const (
+	tokEOF     = iota
+	tokChar    // A single character, such as x, + or {
+	tokNumber  // Digits, with at most one decimal point
+	tokCommand // A backslash command such as \frac, \{ or \\
+)
+
+type mathToken struct {
+	kind int
+	text string
+}
+
+// mathParser is a recursive descent parser of TeX math, producing MathML.
+type mathParser struct {
+	src      string
+	pos      int
+	optional int // Depth of \sqrt[...] arguments, in which ] ends the row
+}
+
+// element is a parsed element, before its scripts.
+type element struct {
+	mathml string
+	limits bool // Scripts go below and above, like the limits of \sum
+	apply  bool // A function name such as \sin, applied to what follows
+}
+
+// peek returns the next token without consuming it.
+func (p *mathParser) peek() mathToken {
+	tok, _ := p.scan()
+	return tok
+}
+
+// next consumes and returns the next token.
+func (p *mathParser) next() mathToken {
+	tok, end := p.scan()
+	p.pos = end
+	return tok
+}
+
+// scan returns the token at the current position, skipping white space,
+// and the position after it.
+func (p *mathParser) scan() (mathToken, int) {
+	i := p.pos
+	for i < len(p.src) && isTeXSpace(p.src[i]) {
+		i++
+	}
+	if i == len(p.src) {
+		return mathToken{kind: tokEOF, text: "end of math"}, i
+	}
+
+	c := p.src[i]
+	switch {
+	case c == '\\':
+		j := i + 1
+		for j < len(p.src) && isASCIILetter(p.src[j]) {
+			j++
+		}
+		if j == i+1 && j < len(p.src) {
+			// A command of one other character, such as \{ or \,
+			_, size := utf8.DecodeRuneInString(p.src[j:])
+			j += size
+		}
+		return mathToken{kind: tokCommand, text: p.src[i:j]}, j
+	case isDigit(c):
+		j, dot := i, false
+		for ; j < len(p.src); j++ {
+			if p.src[j] == '.' && !dot && j+1 < len(p.src) && isDigit(p.src[j+1]) {
+				dot = true
+			} else if !isDigit(p.src[j]) {
+				break
+			}
+		}
+		return mathToken{kind: tokNumber, text: p.src[i:j]}, j
+	}
+	_, size := utf8.DecodeRuneInString(p.src[i:])
+	return mathToken{kind: tokChar, text: p.src[i : i+size]}, i + size
+}
+
+func isTeXSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isASCIILetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// endsRow reports whether tok ends the row being parsed: the end of the
+// math, a closing brace, a table cell or row separator, \right or \end.
+func (p *mathParser) endsRow(tok mathToken) bool {
+	switch tok.text {
+	case "}", "&", `\\`, `\right`, `\end`:
+		return true
+	case "]":
+		return p.optional > 0
+	}
+	return tok.kind == tokEOF
+}
+
+// parseRow parses elements until the end of the row, which it does not
+// consume.
+func (p *mathParser) parseRow() ([]string, error) {
+	var items []string
+	for {
+		tok := p.peek()
+		if p.endsRow(tok) {
+			return items, nil
+		}
+		if tok.text == `\displaystyle` || tok.text == `\textstyle` {
+			p.next()
+			rest, err := p.parseRow()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, fmt.Sprintf(`<mstyle displaystyle="%t">%s</mstyle>`, tok.text == `\displaystyle`, strings.Join(rest, "")))
+			return items, nil
+		}
+
+		item, err := p.parseScripted()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+}
+
+// parseScripted parses an element with its subscript, superscript and
+// primes, if any.
+func (p *mathParser) parseScripted() (string, error) {
+	base := element{mathml: "<mrow></mrow>"}
+	if tok := p.peek(); tok.text != "^" && tok.text != "_" {
+		var err error
+		if base, err = p.parseElement(); err != nil {
+			return "", err
+		}
+	}
+
+	var sub, sup, primes string
+	for done := false; !done; {
+		switch tok := p.peek(); tok.text {
+		case "^", "_":
+			p.next()
+			arg, err := p.parseArg(tok.text)
+			if err != nil {
+				return "", err
+			}
+			if tok.text == "^" {
+				if sup != "" {
+					return "", fmt.Errorf("double superscript")
+				}
+				sup = arg
+			} else {
+				if sub != "" {
+					return "", fmt.Errorf("double subscript")
+				}
+				sub = arg
+			}
+		case "'":
+			p.next()
+			primes += "′"
+		case `\limits`, `\nolimits`:
+			p.next()
+			base.limits = tok.text == `\limits`
+		default:
+			done = true
+		}
+	}
+	if primes != "" {
+		sup = "<mrow><mo>" + primes + "</mo>" + sup + "</mrow>"
+	}
+
+	under, over := "msub", "msup"
+	both := "msubsup"
+	if base.limits {
+		under, over, both = "munder", "mover", "munderover"
+	}
+	out := base.mathml
+	switch {
+	case sub != "" && sup != "":
+		out = fmt.Sprintf("<%s>%s%s%s</%s>", both, out, sub, sup, both)
+	case sub != "":
+		out = fmt.Sprintf("<%s>%s%s</%s>", under, out, sub, under)
+	case sup != "":
+		out = fmt.Sprintf("<%s>%s%s</%s>", over, out, sup, over)
+	}
+	if base.apply {
+		// Function application, which spaces the name from its argument
+		out += "<mo>\u2061</mo>"
+	}
+	return out, nil
+}
+
+// parseArg parses the argument of cmd: a {group}, or else a single token.
+// As in TeX, only the first digit of a number is the argument.
+func (p *mathParser) parseArg(cmd string) (string, error) {
+	tok, end := p.scan()
+	switch {
+	case p.endsRow(tok) || tok.text == "^" || tok.text == "_":
+		return "", fmt.Errorf("%s needs an argument", cmd)
+	case tok.kind == tokNumber:
+		p.pos = end - len(tok.text) + 1
+		return "<mn>" + tok.text[:1] + "</mn>", nil
+	}
+	el, err := p.parseElement()
+	if err != nil {
+		return "", err
+	}
+	return el.mathml, nil
+}
+
+// parseElement parses one element, without its scripts.
+func (p *mathParser) parseElement() (element, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		return element{mathml: "<mn>" + tok.text + "</mn>"}, nil
+	case tokCommand:
+		return p.parseCommand(tok.text)
+	}
+
+	switch tok.text {
+	case "{":
+		// ] is an ordinary character again inside braces
+		optional := p.optional
+		p.optional = 0
+		items, err := p.parseRow()
+		p.optional = optional
+		if err != nil {
+			return element{}, err
+		}
+		if p.next().text != "}" {
+			return element{}, fmt.Errorf("unclosed {")
+		}
+		return element{mathml: "<mrow>" + strings.Join(items, "") + "</mrow>"}, nil
+	case "~":
+		return element{mathml: `<mspace width="0.25em"></mspace>`}, nil
+	case "'":
+		return element{mathml: "<mo>′</mo>"}, nil
+	case "-":
+		return element{mathml: "<mo>−</mo>"}, nil
+	case "*":
+		return element{mathml: "<mo>∗</mo>"}, nil
+	}
+	if d, ok := mathDelimiters[tok.text]; ok {
+		return element{mathml: `<mo stretchy="false">` + d + "</mo>"}, nil
+	}
+	if r, _ := utf8.DecodeRuneInString(tok.text); unicode.IsLetter(r) {
+		return element{mathml: "<mi>" + html.EscapeString(tok.text) + "</mi>"}, nil
+	}
+	return element{mathml: "<mo>" + html.EscapeString(tok.text) + "</mo>"}, nil
+}
+
+// parseCommand parses the command name and its arguments.
+func (p *mathParser) parseCommand(name string) (element, error) {
+	if s, ok := mathIdentifiers[name]; ok {
+		if strings.ContainsAny(s, upperGreek) {
+			return element{mathml: `<mi mathvariant="normal">` + s + "</mi>"}, nil
+		}
+		return element{mathml: "<mi>" + s + "</mi>"}, nil
+	}
+	if s, ok := mathOperators[name]; ok {
+		return element{mathml: "<mo>" + html.EscapeString(s) + "</mo>"}, nil
+	}
+	if s, ok := mathDelimiters[name]; ok {
+		return element{mathml: `<mo stretchy="false">` + s + "</mo>"}, nil
+	}
+	if op, ok := bigOperators[name]; ok {
+		return element{mathml: "<mo>" + op.symbol + "</mo>", limits: op.limits}, nil
+	}
+	if s, ok := limitFunctions[name]; ok {
+		return element{mathml: `<mo movablelimits="true" form="prefix">` + s + "</mo>", limits: true}, nil
+	}
+	if mathFunctions[name] {
+		return element{mathml: "<mi>" + name[1:] + "</mi>", apply: true}, nil
+	}
+	if width, ok := mathSpaces[name]; ok {
+		return element{mathml: `<mspace width="` + width + `"></mspace>`}, nil
+	}
+	if a, ok := mathAccents[name]; ok {
+		return p.parseAccent(name, a)
+	}
+	if a, ok := mathAlphabets[name]; ok {
+		return p.parseFont(name, a)
+	}
+	if size, ok := delimiterSizes[strings.TrimRight(name, "lrm")]; ok {
+		tok := p.next()
+		d, ok := mathDelimiters[tok.text]
+		if !ok {
+			return element{}, fmt.Errorf("%s needs a delimiter, got %s", name, tok.text)
+		}
+		return element{mathml: fmt.Sprintf(`<mo stretchy="true" symmetric="true" minsize="%s" maxsize="%s">%s</mo>`, size, size, d)}, nil
+	}
+
+	switch name {
+	case `\frac`, `\dfrac`, `\tfrac`, `\cfrac`, `\binom`, `\overset`, `\underset`, `\stackrel`:
+		first, err := p.parseArg(name)
+		if err != nil {
+			return element{}, err
+		}
+		second, err := p.parseArg(name)
+		if err != nil {
+			return element{}, err
+		}
+		switch name {
+		case `\frac`:
+			return element{mathml: "<mfrac>" + first + second + "</mfrac>"}, nil
+		case `\dfrac`, `\cfrac`:
+			return element{mathml: `<mstyle displaystyle="true"><mfrac>` + first + second + "</mfrac></mstyle>"}, nil
+		case `\tfrac`:
+			return element{mathml: `<mstyle displaystyle="false"><mfrac>` + first + second + "</mfrac></mstyle>"}, nil
+		case `\binom`:
+			return element{mathml: `<mrow><mo>(</mo><mfrac linethickness="0">` + first + second + "</mfrac><mo>)</mo></mrow>"}, nil
+		case `\underset`:
+			return element{mathml: "<munder>" + second + first + "</munder>"}, nil
+		default:
+			return element{mathml: "<mover>" + second + first + "</mover>"}, nil
+		}
+
+	case `\sqrt`:
+		var index []string
+		if p.peek().text == "[" {
+			p.next()
+			p.optional++
+			var err error
+			index, err = p.parseRow()
+			p.optional--
+			if err != nil {
+				return element{}, err
+			}
+			if p.next().text != "]" {
+				return element{}, fmt.Errorf(`unclosed [ after \sqrt`)
+			}
+		}
+		arg, err := p.parseArg(name)
+		if err != nil {
+			return element{}, err
+		}
+		if index != nil {
+			return element{mathml: "<mroot>" + arg + "<mrow>" + strings.Join(index, "") + "</mrow></mroot>"}, nil
+		}
+		return element{mathml: "<msqrt>" + arg + "</msqrt>"}, nil
+
+	case `\text`, `\textrm`, `\textit`, `\textbf`, `\mbox`:
+		s, err := p.parseRawArg(name)
+		if err != nil {
+			return element{}, err
+		}
+		s = texTextReplacer.Replace(s)
+		// Keep the spaces around the text, which MathML would drop
+		if trimmed := strings.TrimLeft(s, " "); trimmed != s {
+			s = "\u00a0" + trimmed
+		}
+		if trimmed := strings.TrimRight(s, " "); trimmed != s {
+			s = trimmed + "\u00a0"
+		}
+		style := map[string]string{`\textit`: ` style="font-style: italic"`, `\textbf`: ` style="font-weight: bold"`}[name]
+		return element{mathml: "<mtext" + style + ">" + html.EscapeString(s) + "</mtext>"}, nil
+
+	case `\operatorname`:
+		s, err := p.parseRawArg(name)
+		if err != nil {
+			return element{}, err
+		}
+		s = strings.TrimSpace(s)
+		if utf8.RuneCountInString(s) == 1 {
+			return element{mathml: `<mi mathvariant="normal">` + html.EscapeString(s) + "</mi>", apply: true}, nil
+		}
+		return element{mathml: "<mi>" + html.EscapeString(s) + "</mi>", apply: true}, nil
+
+	case `\left`:
+		open, err := p.parseFence(name)
+		if err != nil {
+			return element{}, err
+		}
+		items, err := p.parseRow()
+		if err != nil {
+			return element{}, err
+		}
+		if p.next().text != `\right` {
+			return element{}, fmt.Errorf(`\left has no matching \right`)
+		}
+		closing, err := p.parseFence(`\right`)
+		if err != nil {
+			return element{}, err
+		}
+		return element{mathml: "<mrow>" + open + strings.Join(items, "") + closing + "</mrow>"}, nil
+
+	case `\begin`:
+		return p.parseEnvironment()
+
+	case `\bmod`:
+		return element{mathml: `<mo lspace="0.2222em" rspace="0.2222em">mod</mo>`}, nil
+
+	case `\pmod`:
+		arg, err := p.parseArg(name)
+		if err != nil {
+			return element{}, err
+		}
+		return element{mathml: `<mrow><mspace width="1em"></mspace><mo stretchy="false">(</mo><mi>mod</mi><mspace width="0.3333em"></mspace>` + arg + `<mo stretchy="false">)</mo></mrow>`}, nil
+	}
+
+	return element{}, fmt.Errorf("unknown command %s", name)
+}
+
+// parseRawArg returns the text of the {braced} argument of cmd, unparsed.
+func (p *mathParser) parseRawArg(cmd string) (string, error) {
+	tok, start := p.scan()
+	if tok.text != "{" {
+		return "", fmt.Errorf("%s needs a {...} argument", cmd)
+	}
+	depth := 1
+	for i := start; i < len(p.src); i++ {
+		switch p.src[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				p.pos = i + 1
+				return p.src[start:i], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unclosed { after %s", cmd)
+}
+
+// parseFence parses the delimiter after \left or \right, where . is none.
+func (p *mathParser) parseFence(cmd string) (string, error) {
+	tok := p.next()
+	if tok.text == "." {
+		return "", nil
+	}
+	d, ok := mathDelimiters[tok.text]
+	if !ok {
+		return "", fmt.Errorf("%s needs a delimiter, got %s", cmd, tok.text)
+	}
+	return `<mo fence="true" stretchy="true" symmetric="true">` + d + "</mo>", nil
+}
+
+// parseAccent parses the argument of an accent command.
+func (p *mathParser) parseAccent(name string, a mathAccent) (element, error) {
+	arg, err := p.parseArg(name)
+	if err != nil {
+		return element{}, err
+	}
+	mark := fmt.Sprintf(`<mo stretchy="%t">%s</mo>`, a.stretchy, a.mark)
+	if a.under {
+		return element{mathml: `<munder accentunder="true">` + arg + mark + "</munder>", limits: a.limits}, nil
+	}
+	return element{mathml: `<mover accent="true">` + arg + mark + "</mover>", limits: a.limits}, nil
+}
+
+// parseFont parses the argument of a font command such as \mathbb. Letters
+// and digits are mapped to the font's Unicode math alphabet; anything more
+// is rendered in the normal font.
+func (p *mathParser) parseFont(name string, a mathAlphabet) (element, error) {
+	var s string
+	if tok, end := p.scan(); tok.kind == tokChar && tok.text != "{" {
+		// \mathbb R
+		p.pos = end
+		s = tok.text
+	} else {
+		var err error
+		if s, err = p.parseRawArg(name); err != nil {
+			return element{}, err
+		}
+	}
+
+	s = strings.Join(strings.Fields(s), "")
+	plain := s != ""
+	for i := 0; i < len(s); i++ {
+		plain = plain && (isASCIILetter(s[i]) || isDigit(s[i]))
+	}
+	if !plain {
+		sub := &mathParser{src: s}
+		items, err := sub.parseRow()
+		if err != nil {
+			return element{}, err
+		}
+		if tok := sub.next(); tok.kind != tokEOF {
+			return element{}, fmt.Errorf("unexpected %s", tok.text)
+		}
+		return element{mathml: "<mrow>" + strings.Join(items, "") + "</mrow>"}, nil
+	}
+
+	// Runs of letters are one identifier in \mathrm, one each otherwise
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		j := i + 1
+		for j < len(s) && isDigit(s[i]) == isDigit(s[j]) && (isDigit(s[i]) || a.upright) {
+			j++
+		}
+		run := s[i:j]
+		switch {
+		case isDigit(run[0]):
+			b.WriteString("<mn>" + a.apply(run) + "</mn>")
+		case a.upright && len(run) == 1:
+			b.WriteString(`<mi mathvariant="normal">` + run + "</mi>")
+		default:
+			b.WriteString("<mi>" + a.apply(run) + "</mi>")
+		}
+		i = j
+	}
+	if strings.Count(b.String(), "<m") == 1 {
+		return element{mathml: b.String()}, nil
+	}
+	return element{mathml: "<mrow>" + b.String() + "</mrow>"}, nil
+}
+
+// parseEnvironment parses \begin{name}...\end{name}, after the \begin.
+func (p *mathParser) parseEnvironment() (element, error) {
+	name, err := p.parseRawArg(`\begin`)
+	if err != nil {
+		return element{}, err
+	}
+	env, ok := mathEnvironments[name]
+	if !ok {
+		return element{}, fmt.Errorf("unknown environment %s", name)
+	}
+	var aligns []string
+	if name == "array" {
+		spec, err := p.parseRawArg(`\begin{array}`)
+		if err != nil {
+			return element{}, err
+		}
+		for _, c := range spec {
+			switch c {
+			case 'l':
+				aligns = append(aligns, "left")
+			case 'c':
+				aligns = append(aligns, "center")
+			case 'r':
+				aligns = append(aligns, "right")
+			}
+		}
+	}
+
+	var rows [][]string
+	var row []string
+	for {
+		items, err := p.parseRow()
+		if err != nil {
+			return element{}, err
+		}
+		row = append(row, strings.Join(items, ""))
+
+		tok := p.next()
+		if tok.text == "&" {
+			continue
+		}
+		if tok.text != `\\` && tok.text != `\end` {
+			return element{}, fmt.Errorf(`\begin{%s} has no \end{%s}`, name, name)
+		}
+		// A \\ at the end of the last row leaves an empty row
+		if tok.text == `\\` || len(row) > 1 || row[0] != "" {
+			rows = append(rows, row)
+		}
+		row = nil
+		if tok.text == `\end` {
+			end, err := p.parseRawArg(`\end`)
+			if err != nil {
+				return element{}, err
+			}
+			if end != name {
+				return element{}, fmt.Errorf(`\begin{%s} ended by \end{%s}`, name, end)
+			}
+			break
+		}
+	}
+
+	columns := 0
+	for _, r := range rows {
+		columns = max(columns, len(r))
+	}
+	for i := 0; env.align != nil && i < columns; i++ {
+		aligns = append(aligns, env.align[i%len(env.align)])
+	}
+
+	var b strings.Builder
+	b.WriteString("<mrow>")
+	if env.open != "" {
+		b.WriteString(`<mo fence="true" stretchy="true" symmetric="true">` + env.open + "</mo>")
+	}
+	b.WriteString("<mtable")
+	if len(aligns) > 0 {
+		b.WriteString(` columnalign="` + strings.Join(aligns, " ") + `"`)
+	}
+	b.WriteString(">")
+	for _, r := range rows {
+		b.WriteString("<mtr>")
+		for _, cell := range r {
+			b.WriteString("<mtd>" + cell + "</mtd>")
+		}
+		b.WriteString("</mtr>")
+	}
+	b.WriteString("</mtable>")
+	if env.close != "" {
+		b.WriteString(`<mo fence="true" stretchy="true" symmetric="true">` + env.close + "</mo>")
+	}
+	b.WriteString("</mrow>")
+	return element{mathml: b.String()}, nil
+}
+
+// texTextReplacer unescapes the special characters in \text.
+var texTextReplacer = strings.NewReplacer(`\{`, "{", `\}`, "}", `\$`, "$", `\%`, "%", `\&`, "&", `\_`, "_", `\#`, "#", `\ `, " ")
+
+// upperGreek holds the capital Greek letters, which are upright in TeX.
+const upperGreek = "ΓΔΘΛΞΠΣΥΦΨΩ"
+
+// mathIdentifiers maps commands to the letters and symbols they stand for.
+var mathIdentifiers = map[string]string{
+	`\alpha`: "α", `\beta`: "β", `\gamma`: "γ", `\delta`: "δ", `\epsilon`: "ϵ",
+	`\varepsilon`: "ε", `\zeta`: "ζ", `\eta`: "η", `\theta`: "θ", `\vartheta`: "ϑ",
+	`\iota`: "ι", `\kappa`: "κ", `\lambda`: "λ", `\mu`: "μ", `\nu`: "ν", `\xi`: "ξ",
+	`\pi`: "π", `\varpi`: "ϖ", `\rho`: "ρ", `\varrho`: "ϱ", `\sigma`: "σ",
+	`\varsigma`: "ς", `\tau`: "τ", `\upsilon`: "υ", `\phi`: "ϕ", `\varphi`: "φ",
+	`\chi`: "χ", `\psi`: "ψ", `\omega`: "ω",
+	`\Gamma`: "Γ", `\Delta`: "Δ", `\Theta`: "Θ", `\Lambda`: "Λ", `\Xi`: "Ξ",
+	`\Pi`: "Π", `\Sigma`: "Σ", `\Upsilon`: "Υ", `\Phi`: "Φ", `\Psi`: "Ψ", `\Omega`: "Ω",
+	`\infty`: "∞", `\partial`: "∂", `\nabla`: "∇", `\emptyset`: "∅", `\varnothing`: "∅",
+	`\hbar`: "ℏ", `\ell`: "ℓ", `\Re`: "ℜ", `\Im`: "ℑ", `\aleph`: "ℵ", `\wp`: "℘",
+	`\imath`: "ı", `\jmath`: "ȷ", `\top`: "⊤", `\bot`: "⊥", `\angle`: "∠",
+	`\triangle`: "△", `\degree`: "°",
+}
+
+// mathOperators maps commands to the operators and relations they stand for.
+var mathOperators = map[string]string{
+	`\pm`: "±", `\mp`: "∓", `\times`: "×", `\div`: "÷", `\cdot`: "⋅", `\ast`: "∗",
+	`\star`: "⋆", `\circ`: "∘", `\bullet`: "∙", `\oplus`: "⊕", `\ominus`: "⊖",
+	`\otimes`: "⊗", `\odot`: "⊙", `\wedge`: "∧", `\land`: "∧", `\vee`: "∨",
+	`\lor`: "∨", `\cap`: "∩", `\cup`: "∪", `\setminus`: "∖", `\sqcup`: "⊔", `\sqcap`: "⊓",
+	`\leq`: "≤", `\le`: "≤", `\geq`: "≥", `\ge`: "≥", `\leqslant`: "⩽", `\geqslant`: "⩾",
+	`\neq`: "≠", `\ne`: "≠", `\lt`: "<", `\gt`: ">", `\ll`: "≪", `\gg`: "≫",
+	`\approx`: "≈", `\equiv`: "≡", `\sim`: "∼", `\simeq`: "≃", `\cong`: "≅",
+	`\propto`: "∝", `\prec`: "≺", `\succ`: "≻", `\preceq`: "⪯", `\succeq`: "⪰",
+	`\triangleq`: "≜", `\coloneqq`: "≔", `\in`: "∈", `\notin`: "∉", `\ni`: "∋",
+	`\subset`: "⊂", `\supset`: "⊃", `\subseteq`: "⊆", `\supseteq`: "⊇",
+	`\perp`: "⊥", `\parallel`: "∥", `\mid`: "∣", `\nmid`: "∤",
+	`\to`: "→", `\rightarrow`: "→", `\leftarrow`: "←", `\gets`: "←",
+	`\leftrightarrow`: "↔", `\Rightarrow`: "⇒", `\Leftarrow`: "⇐",
+	`\Leftrightarrow`: "⇔", `\iff`: "⟺", `\implies`: "⟹", `\impliedby`: "⟸",
+	`\longrightarrow`: "⟶", `\longleftarrow`: "⟵", `\mapsto`: "↦",
+	`\forall`: "∀", `\exists`: "∃", `\nexists`: "∄", `\neg`: "¬", `\lnot`: "¬",
+	`\ldots`: "…", `\dots`: "…", `\cdots`: "⋯", `\vdots`: "⋮", `\ddots`: "⋱",
+	`\colon`: ":", `\therefore`: "∴", `\because`: "∵", `\prime`: "′",
+	`\%`: "%", `\&`: "&", `\#`: "#", `\$`: "$", `\_`: "_",
+}
+
+// mathDelimiters maps the characters and commands that can follow \left,
+// \right and \big to the delimiters they stand for.
+var mathDelimiters = map[string]string{
+	"(": "(", ")": ")", "[": "[", "]": "]", "|": "|", "/": "/",
+	`\{`: "{", `\}`: "}", `\|`: "‖", `\vert`: "|", `\lvert`: "|", `\rvert`: "|",
+	`\Vert`: "‖", `\lVert`: "‖", `\rVert`: "‖", `\langle`: "⟨", `\rangle`: "⟩",
+	`\lfloor`: "⌊", `\rfloor`: "⌋", `\lceil`: "⌈", `\rceil`: "⌉",
+	`\backslash`: "\\", `\uparrow`: "↑", `\downarrow`: "↓",
+}
+
+// delimiterSizes maps \big and its siblings to the size of their delimiter.
+// The \bigl, \bigr and \bigm variants have the same size.
+var delimiterSizes = map[string]string{
+	`\big`: "1.2em", `\Big`: "1.623em", `\bigg`: "2.047em", `\Bigg`: "2.470em",
+}
+
+// bigOperators maps commands to large operators. Operators with limits take
+// their scripts below and above in display math.
+var bigOperators = map[string]struct {
+	symbol string
+	limits bool
+}{
+	`\sum`: {"∑", true}, `\prod`: {"∏", true}, `\coprod`: {"∐", true},
+	`\bigcup`: {"⋃", true}, `\bigcap`: {"⋂", true}, `\bigvee`: {"⋁", true},
+	`\bigwedge`: {"⋀", true}, `\bigoplus`: {"⨁", true}, `\bigotimes`: {"⨂", true},
+	`\bigsqcup`: {"⨆", true},
+	`\int`:      {"∫", false}, `\iint`: {"∬", false}, `\iiint`: {"∭", false}, `\oint`: {"∮", false},
+}
+
+// limitFunctions maps commands to named operators with limits, like \lim.
+var limitFunctions = map[string]string{
+	`\lim`: "lim", `\liminf`: "lim inf", `\limsup`: "lim sup", `\max`: "max",
+	`\min`: "min", `\sup`: "sup", `\inf`: "inf", `\det`: "det", `\gcd`: "gcd", `\Pr`: "Pr",
+}
+
+// mathFunctions are the commands of named functions, like \sin.
+var mathFunctions = map[string]bool{
+	`\sin`: true, `\cos`: true, `\tan`: true, `\cot`: true, `\sec`: true, `\csc`: true,
+	`\arcsin`: true, `\arccos`: true, `\arctan`: true, `\sinh`: true, `\cosh`: true,
+	`\tanh`: true, `\coth`: true, `\log`: true, `\ln`: true, `\lg`: true, `\exp`: true,
+	`\deg`: true, `\dim`: true, `\arg`: true, `\ker`: true, `\hom`: true,
+}
+
+// mathSpaces maps spacing commands to their width.
+var mathSpaces = map[string]string{
+	`\,`: "0.1667em", `\:`: "0.2222em", `\>`: "0.2222em", `\;`: "0.2778em",
+	`\!`: "-0.1667em", `\ `: "0.25em", `\quad`: "1em", `\qquad`: "2em",
+}
+
+// mathAccent is a mark drawn above or below its argument.
+type mathAccent struct {
+	mark     string
+	under    bool // Below the argument instead of above
+	stretchy bool // Stretches over the whole argument
+	limits   bool // Scripts go below and above, like \underbrace{...}_{n}
+}
+
+var mathAccents = map[string]mathAccent{
+	`\hat`: {mark: "^"}, `\widehat`: {mark: "^", stretchy: true},
+	`\tilde`: {mark: "~"}, `\widetilde`: {mark: "~", stretchy: true},
+	`\bar`: {mark: "¯"}, `\overline`: {mark: "‾", stretchy: true},
+	`\vec`: {mark: "→"}, `\overrightarrow`: {mark: "→", stretchy: true},
+	`\dot`: {mark: "˙"}, `\ddot`: {mark: "¨"}, `\check`: {mark: "ˇ"},
+	`\breve`: {mark: "˘"}, `\acute`: {mark: "´"}, `\grave`: {mark: "`"},
+	`\underline`:  {mark: "_", under: true, stretchy: true},
+	`\overbrace`:  {mark: "⏞", stretchy: true, limits: true},
+	`\underbrace`: {mark: "⏟", under: true, stretchy: true, limits: true},
+}
+
+// mathAlphabet is a font of the Unicode mathematical alphanumeric symbols.
+type mathAlphabet struct {
+	upper, lower, digit rune          // Code points of A, a and 0; 0 if the font has none
+	except              map[rune]rune // Letters outside the block, in the Letterlike Symbols
+	upright             bool          // Letters are upright and runs of them are one identifier
+}
+
+// apply maps the ASCII letters and digits of s to the alphabet.
+func (a mathAlphabet) apply(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case a.except[r] != 0:
+			r = a.except[r]
+		case 'A' <= r && r <= 'Z' && a.upper != 0:
+			r = a.upper + r - 'A'
+		case 'a' <= r && r <= 'z' && a.lower != 0:
+			r = a.lower + r - 'a'
+		case '0' <= r && r <= '9' && a.digit != 0:
+			r = a.digit + r - '0'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+var mathAlphabets = map[string]mathAlphabet{
+	`\mathrm`:     {upright: true},
+	`\mathit`:     {upper: 0x1D434, lower: 0x1D44E, except: map[rune]rune{'h': 'ℎ'}},
+	`\mathbf`:     {upper: 0x1D400, lower: 0x1D41A, digit: 0x1D7CE},
+	`\boldsymbol`: {upper: 0x1D468, lower: 0x1D482, digit: 0x1D7CE},
+	`\mathsf`:     {upper: 0x1D5A0, lower: 0x1D5BA, digit: 0x1D7E2},
+	`\mathtt`:     {upper: 0x1D670, lower: 0x1D68A, digit: 0x1D7F6},
+	`\mathbb`: {upper: 0x1D538, lower: 0x1D552, digit: 0x1D7D8, except: map[rune]rune{
+		'C': 'ℂ', 'H': 'ℍ', 'N': 'ℕ', 'P': 'ℙ', 'Q': 'ℚ', 'R': 'ℝ', 'Z': 'ℤ',
+	}},
+	`\mathcal`: {upper: 0x1D49C, lower: 0x1D4B6, except: map[rune]rune{
+		'B': 'ℬ', 'E': 'ℰ', 'F': 'ℱ', 'H': 'ℋ', 'I': 'ℐ', 'L': 'ℒ', 'M': 'ℳ', 'R': 'ℛ',
+		'e': 'ℯ', 'g': 'ℊ', 'o': 'ℴ',
+	}},
+	`\mathfrak`: {upper: 0x1D504, lower: 0x1D51E, except: map[rune]rune{
+		'C': 'ℭ', 'H': 'ℌ', 'I': 'ℑ', 'R': 'ℜ', 'Z': 'ℨ',
+	}},
+}
+
+// mathEnvironments maps the environments to their fences and the alignment
+// of their columns, repeated across the table.
+var mathEnvironments = map[string]struct {
+	open, close string
+	align       []string
+}{
+	"matrix":   {},
+	"pmatrix":  {open: "(", close: ")"},
+	"bmatrix":  {open: "[", close: "]"},
+	"Bmatrix":  {open: "{", close: "}"},
+	"vmatrix":  {open: "|", close: "|"},
+	"Vmatrix":  {open: "‖", close: "‖"},
+	"cases":    {open: "{", align: []string{"left"}},
+	"aligned":  {align: []string{"right", "left"}},
+	"align":    {align: []string{"right", "left"}},
+	"align*":   {align: []string{"right", "left"}},
+	"gathered": {},
+	"array":    {},
+}
@@ -2,43 +2,67 @@ package content
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"html"
 	"html/template"
 	"path/filepath"
 	"strings"
 	"time"
 
-	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/ast"
-	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
-	"github.com/yuin/goldmark/text"
-	highlighting "github.com/yuin/goldmark-highlighting/v2"
-	"gopkg.in/yaml.v3"
 )
 
-// ParsePage parses a markdown file with YAML frontmatter and returns a Page.
+// ParsePage parses a markdown file with YAML, TOML or JSON frontmatter and
+// returns a Page, rendering the markdown with the default Renderer.
 // sourcePath is the filesystem path relative to the project root (e.g., "content/docs/tools/nbc/install.md").
 func ParsePage(data []byte, sourcePath string) (*Page, error) {
-	fm, body, err := splitFrontmatter(data)
+	return defaultRenderer.ParsePage(data, sourcePath)
+}
+
+// ParsePage parses a markdown file with YAML, TOML or JSON frontmatter and
+// returns a Page, rendering the markdown with r.
+func (r *Renderer) ParsePage(data []byte, sourcePath string) (*Page, error) {
+	fm, body, err := parseFrontmatter(data)
 	if err != nil {
-		return nil, fmt.Errorf("splitting frontmatter: %w", err)
+		return nil, fmt.Errorf("parsing frontmatter: %w", err)
 	}
 
 	var page Page
-	if len(fm) > 0 {
-		if err := yaml.Unmarshal(fm, &page); err != nil {
+	if fm != nil {
+		if err := fm.Decode(&page); err != nil {
+			return nil, fmt.Errorf("parsing frontmatter: %w", err)
+		}
+		if err := fm.Decode(&page.Params); err != nil {
 			return nil, fmt.Errorf("parsing frontmatter: %w", err)
 		}
 	}
 
-	html, headings, err := renderMarkdown(body)
+	// Lines in the body are offset by the frontmatter; make them relative
+	// to the file
+	bodyLine := bytes.Count(data[:len(data)-len(body)], []byte("\n"))
+
+	out, err := r.render(body, sourcePath, page.Math)
 	if err != nil {
+		var mdErr *markdownError
+		if errors.As(err, &mdErr) {
+			mdErr.Line += bodyLine
+		}
 		return nil, fmt.Errorf("rendering markdown: %w", err)
 	}
 
-	page.Content = template.HTML(html)
-	page.Headings = headings
+	for i := range out.Links {
+		if out.Links[i].Line > 0 {
+			out.Links[i].Line += bodyLine
+		}
+	}
+	page.links = out.Links
+
+	page.Content = template.HTML(out.HTML)
+	page.Footnotes = template.HTML(out.Footnotes)
+	page.Mermaid = strings.Contains(out.HTML, `<pre class="`+mermaidClass+`">`)
+	page.Headings = out.Headings
 	page.SourcePath = sourcePath
 	page.Path = computePath(sourcePath)
 	page.Slug = computeSlug(sourcePath)
@@ -51,42 +75,28 @@ func ParsePage(data []byte, sourcePath string) (*Page, error) {
 		}
 	}
 
-	return &page, nil
-}
-
-// splitFrontmatter splits YAML frontmatter (delimited by ---) from the markdown body.
-// Returns empty frontmatter if no frontmatter delimiters are found.
-func splitFrontmatter(data []byte) (frontmatter, body []byte, err error) {
-	content := string(data)
-
-	// Frontmatter must start with ---
-	if !strings.HasPrefix(content, "---") {
-		return nil, data, nil
-	}
-
-	// Find the closing ---
-	rest := content[3:]
-	// Skip the newline after opening ---
-	if idx := strings.Index(rest, "\n"); idx >= 0 {
-		rest = rest[idx+1:]
-	} else {
-		return nil, data, nil
+	if page.Lastmod != "" {
+		parsed, err := parseDate(page.Lastmod)
+		if err == nil {
+			page.ParsedLastmod = parsed
+		}
 	}
 
-	endIdx := strings.Index(rest, "\n---")
-	if endIdx < 0 {
-		return nil, data, nil
+	if page.PublishDate != "" {
+		parsed, err := parseDate(page.PublishDate)
+		if err == nil {
+			page.ParsedPublish = parsed
+		}
 	}
 
-	frontmatter = []byte(rest[:endIdx])
-	body = []byte(rest[endIdx+4:]) // skip \n---
-
-	// Skip the newline after closing ---
-	if len(body) > 0 && body[0] == '\n' {
-		body = body[1:]
+	if page.ExpiryDate != "" {
+		parsed, err := parseDate(page.ExpiryDate)
+		if err == nil {
+			page.ParsedExpiry = parsed
+		}
 	}
 
-	return frontmatter, body, nil
+	return &page, nil
 }
 
 // computePath derives the URL path from a source file path.
@@ -129,35 +139,26 @@ func computeSlug(sourcePath string) string {
 	return slug
 }
 
-// renderMarkdown converts markdown source to HTML and extracts headings.
-// Uses goldmark with GFM extension, syntax highlighting (Chroma dracula style with CSS classes),
-// and auto heading IDs.
-func renderMarkdown(source []byte) (string, []Heading, error) {
-	md := goldmark.New(
-		goldmark.WithExtensions(
-			extension.GFM,
-			highlighting.NewHighlighting(
-				highlighting.WithStyle("dracula"),
-			),
-		),
-		goldmark.WithParserOptions(
-			parser.WithAutoHeadingID(),
-		),
-	)
-
-	// Parse to AST to extract headings
-	reader := text.NewReader(source)
-	doc := md.Parser().Parse(reader)
-
-	headings := extractHeadings(doc, source)
-
-	// Render to HTML
-	var buf bytes.Buffer
-	if err := md.Renderer().Render(&buf, source, doc); err != nil {
-		return "", nil, fmt.Errorf("rendering markdown: %w", err)
-	}
+// markdownError is a problem in the markdown body that fails the page, such
+// as an unknown shortcode, at a line of the body.
+type markdownError struct {
+	Line int
+	Msg  string
+}
+
+func (e *markdownError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
 
-	return buf.String(), headings, nil
+// markdownErrKey holds the first *markdownError found while parsing.
+var markdownErrKey = parser.NewContextKey()
+
+// setMarkdownError records a problem at line of the body, unless an earlier
+// one was already found.
+func setMarkdownError(pc parser.Context, line int, msg string) {
+	if pc.Get(markdownErrKey) == nil {
+		pc.Set(markdownErrKey, &markdownError{Line: line, Msg: msg})
+	}
 }
 
 // extractHeadings walks the AST and extracts all headings with their level, ID, and text.
@@ -179,14 +180,11 @@ func extractHeadings(node ast.Node, source []byte) []Heading {
 				h.ID = string(id.([]byte))
 			}
 
-			// Get the text content of the heading
+			// Get the text content of the heading, including the text
+			// inside code spans, links and emphasis
 			var textBuf bytes.Buffer
-			for child := heading.FirstChild(); child != nil; child = child.NextSibling() {
-				if t, ok := child.(*ast.Text); ok {
-					textBuf.Write(t.Segment.Value(source))
-				}
-			}
-			h.Text = textBuf.String()
+			writeText(&textBuf, heading, source)
+			h.Text = strings.TrimSpace(textBuf.String())
 
 			headings = append(headings, h)
 		}
@@ -197,6 +195,32 @@ func extractHeadings(node ast.Node, source []byte) []Heading {
 	return headings
 }
 
+// writeText writes the plain text below node to buf, dropping inline markup.
+func writeText(buf *bytes.Buffer, node ast.Node, source []byte) {
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		switch n := child.(type) {
+		case *ast.Text:
+			buf.Write(n.Segment.Value(source))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				buf.WriteByte(' ')
+			}
+		case *ast.String:
+			if n.IsCode() {
+				// Typographer output, such as &rsquo;
+				buf.WriteString(html.UnescapeString(string(n.Value)))
+			} else {
+				buf.Write(n.Value)
+			}
+		case *ast.AutoLink:
+			buf.Write(n.Label(source))
+		case *ast.RawHTML:
+			// Inline HTML tags are markup, not text
+		default:
+			writeText(buf, n, source)
+		}
+	}
+}
+
 // parseDate tries several common date formats.
 func parseDate(s string) (time.Time, error) {
 	formats := []string{
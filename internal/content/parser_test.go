@@ -1,6 +1,7 @@
 package content
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -104,6 +105,22 @@ This is a blog post about Go and web development.
 	}
 }
 
+func TestParseSingleTag(t *testing.T) {
+	input := []byte(`---
+title: "Release notes"
+tags: linux
+---
+`)
+
+	page, err := ParsePage(input, "content/blog/posts/release.md")
+	if err != nil {
+		t.Fatalf("ParsePage returned error: %v", err)
+	}
+	if len(page.Tags) != 1 || page.Tags[0] != "linux" {
+		t.Errorf("Tags = %q, want [linux]", page.Tags)
+	}
+}
+
 func TestParseHeadings(t *testing.T) {
 	input := []byte(`---
 title: "Headings Test"
@@ -153,6 +170,29 @@ Even more text.
 	}
 }
 
+func TestParseHeadingText(t *testing.T) {
+	input := []byte("## Install `nbc` on *Debian*\n\n### See [the guide](/docs/) and <https://example.com>\n\n#### Use <kbd>Ctrl</kbd> **now**\n")
+
+	page, err := ParsePage(input, "content/docs/install.md")
+	if err != nil {
+		t.Fatalf("ParsePage returned error: %v", err)
+	}
+
+	want := []string{
+		"Install nbc on Debian",
+		"See the guide and https://example.com",
+		"Use Ctrl now",
+	}
+	if len(page.Headings) != len(want) {
+		t.Fatalf("len(Headings) = %d, want %d", len(page.Headings), len(want))
+	}
+	for i, text := range want {
+		if page.Headings[i].Text != text {
+			t.Errorf("Headings[%d].Text = %q, want %q", i, page.Headings[i].Text, text)
+		}
+	}
+}
+
 func TestParsePagePath(t *testing.T) {
 	tests := []struct {
 		sourcePath string
@@ -173,3 +213,83 @@ func TestParsePagePath(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSitemapOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		fm      string
+		want    SitemapOptions
+		wantErr bool
+	}{
+		{"unset", "title: x", SitemapOptions{}, false},
+		{"excluded", "sitemap: false", SitemapOptions{Exclude: true}, false},
+		{"included", "sitemap: true", SitemapOptions{}, false},
+		{"options", "sitemap:\n  priority: 0.8\n  changefreq: weekly", SitemapOptions{Priority: 0.8, PrioritySet: true, ChangeFreq: "weekly"}, false},
+		{"zero priority", "sitemap:\n  priority: 0.0", SitemapOptions{PrioritySet: true}, false},
+		{"bad priority", "sitemap:\n  priority: 2", SitemapOptions{}, true},
+		{"bad changefreq", "sitemap:\n  changefreq: sometimes", SitemapOptions{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := ParsePage([]byte("---\n"+tt.fm+"\n---\n"), "content/docs/page.md")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("ParsePage returned nil error, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePage returned error: %v", err)
+			}
+			if page.Sitemap != tt.want {
+				t.Errorf("Sitemap = %+v, want %+v", page.Sitemap, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHeadingIDs(t *testing.T) {
+	input := []byte(`## What's Included
+
+## Search
+
+## Pulling the Image
+
+### What's Included
+
+## Setup {#whats-included-1}
+
+## Custom {#custom-id .wide}
+`)
+
+	page, err := ParsePage(input, "content/docs/images/snow.md")
+	if err != nil {
+		t.Fatalf("ParsePage returned error: %v", err)
+	}
+
+	// The explicit whats-included-1 wins over the generated one, and search
+	// is taken by the layout
+	want := []string{"whats-included", "search-1", "pulling-the-image", "whats-included-1-1", "whats-included-1", "custom-id"}
+	if len(page.Headings) != len(want) {
+		t.Fatalf("len(Headings) = %d, want %d", len(page.Headings), len(want))
+	}
+	for i, id := range want {
+		if page.Headings[i].ID != id {
+			t.Errorf("Headings[%d].ID = %q, want %q", i, page.Headings[i].ID, id)
+		}
+	}
+	if page.Headings[5].Text != "Custom" {
+		t.Errorf("attribute block left in heading text: %q", page.Headings[5].Text)
+	}
+
+	html := string(page.Content)
+	for _, s := range []string{
+		`<h2 id="custom-id" class="wide">Custom<a class="heading-anchor" href="#custom-id"`,
+		`<h3 id="whats-included-1-1">What&rsquo;s Included<a class="heading-anchor" href="#whats-included-1-1"`,
+	} {
+		if !strings.Contains(html, s) {
+			t.Errorf("expected %s in:\n%s", s, html)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package content
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/frostyard/site/internal/config"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Renderer converts the markdown of pages to HTML. It is configured once by
+// NewRenderer and safe for concurrent use, so one Renderer serves a whole
+// build.
+//
+// Every Renderer uses goldmark with the GFM extension, syntax highlighting
+// (Chroma with CSS classes, see HighlightCSS), unique heading IDs,
+// admonitions, shortcodes, diagrams and, on pages with math: true, TeX math.
+// Footnotes, definition lists, the typographer, attribute lists and
+// abbreviations can be turned off (see WithMarkdown). An unknown or failing
+// shortcode, a diagram that fails to render, invalid code block options or
+// invalid math fail the page.
+type Renderer struct {
+	md         goldmark.Markdown
+	reserved   map[string]bool            // IDs that headings must not take
+	shortcodes map[string]ShortcodeFunc   // Used before the RegisterShortcode ones
+	diagrams   map[string]DiagramRenderer // Used before the RegisterDiagramRenderer ones
+}
+
+// RendererOption configures a Renderer.
+type RendererOption func(*rendererConfig)
+
+type rendererConfig struct {
+	markdown     config.Markdown
+	extensions   []goldmark.Extender
+	transformers []util.PrioritizedValue
+	reserved     []string
+	shortcodes   map[string]ShortcodeFunc
+	diagrams     map[string]DiagramRenderer
+}
+
+// WithMarkdown turns the optional markdown syntax on or off as m says. All of
+// it is on by default, as in config.Default.
+func WithMarkdown(m config.Markdown) RendererOption {
+	return func(c *rendererConfig) {
+		c.markdown = m
+	}
+}
+
+// WithExtensions adds goldmark extensions, after the built-in ones.
+func WithExtensions(exts ...goldmark.Extender) RendererOption {
+	return func(c *rendererConfig) {
+		c.extensions = append(c.extensions, exts...)
+	}
+}
+
+// WithASTTransformers adds AST transformers, each a parser.ASTTransformer
+// with a priority (see util.Prioritized). Lower priorities run first; the
+// built-in transformers use 100 to 650.
+func WithASTTransformers(transformers ...util.PrioritizedValue) RendererOption {
+	return func(c *rendererConfig) {
+		c.transformers = append(c.transformers, transformers...)
+	}
+}
+
+// WithReservedIDs keeps headings from taking ids, in addition to the IDs the
+// page layouts use.
+func WithReservedIDs(ids ...string) RendererOption {
+	return func(c *rendererConfig) {
+		c.reserved = append(c.reserved, ids...)
+	}
+}
+
+// WithShortcode makes the shortcode name available to this Renderer only,
+// taking precedence over one registered with RegisterShortcode.
+func WithShortcode(name string, fn ShortcodeFunc) RendererOption {
+	return func(c *rendererConfig) {
+		c.shortcodes[name] = fn
+	}
+}
+
+// WithDiagramRenderer renders fenced code blocks in lang with d, for this
+// Renderer only, taking precedence over RegisterDiagramRenderer.
+func WithDiagramRenderer(lang string, d DiagramRenderer) RendererOption {
+	return func(c *rendererConfig) {
+		c.diagrams[lang] = d
+	}
+}
+
+// WithDiagrams renders the diagram languages that d sets to
+// config.DiagramCommand with their program, for this Renderer only. Other
+// languages keep the renderers that need no external tools.
+func WithDiagrams(d config.Diagrams) RendererOption {
+	return func(c *rendererConfig) {
+		for lang, choice := range map[string]string{"graphviz": d.Graphviz, "mermaid": d.Mermaid, "d2": d.D2} {
+			if choice != config.DiagramCommand {
+				continue
+			}
+			r := diagramCommands[lang]
+			r.Timeout = d.Timeout
+			c.diagrams[lang] = r
+			if lang == "graphviz" {
+				c.diagrams["dot"] = r
+			}
+		}
+	}
+}
+
+// NewRenderer returns a Renderer with the built-in extensions and opts.
+func NewRenderer(opts ...RendererOption) *Renderer {
+	c := rendererConfig{
+		markdown:   config.Default().Markdown,
+		shortcodes: map[string]ShortcodeFunc{},
+		diagrams:   map[string]DiagramRenderer{},
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	r := &Renderer{
+		reserved:   make(map[string]bool, len(layoutIDs)+len(c.reserved)),
+		shortcodes: c.shortcodes,
+		diagrams:   c.diagrams,
+	}
+	for id := range layoutIDs {
+		r.reserved[id] = true
+	}
+	for _, id := range c.reserved {
+		r.reserved[id] = true
+	}
+
+	extensions := []goldmark.Extender{
+		extension.GFM,
+		admonitionExtension{},
+		shortcodeExtension{},
+		diagramExtension{},
+		codeBlockExtension{},
+		mathExtension{},
+	}
+	if c.markdown.Footnotes {
+		extensions = append(extensions, extension.NewFootnote(
+			extension.WithFootnoteBacklinkHTML("&#x21a9;&#xfe0e;"),
+		))
+	}
+	if c.markdown.DefinitionLists {
+		extensions = append(extensions, extension.DefinitionList)
+	}
+	if c.markdown.Typographer {
+		// -- stays as written, since the docs are full of command line flags
+		extensions = append(extensions, extension.NewTypographer(
+			extension.WithTypographicSubstitutions(map[extension.TypographicPunctuation][]byte{
+				extension.EnDash: nil,
+			}),
+		))
+	}
+	if c.markdown.Attributes {
+		extensions = append(extensions, attributeListExtension{})
+	}
+	if c.markdown.Abbreviations {
+		extensions = append(extensions, abbreviationExtension{})
+	}
+	extensions = append(extensions, c.extensions...)
+	transformers := append([]util.PrioritizedValue{
+		util.Prioritized(mdLinkTransformer{}, 100),
+		util.Prioritized(headingIDTransformer{}, 200),
+	}, c.transformers...)
+
+	r.md = goldmark.New(
+		goldmark.WithExtensions(extensions...),
+		goldmark.WithParserOptions(
+			parser.WithAutoHeadingID(),
+			parser.WithHeadingAttribute(),
+			parser.WithASTTransformers(transformers...),
+		),
+		goldmark.WithRendererOptions(
+			renderer.WithNodeRenderers(
+				util.Prioritized(headingRenderer{}, 100),
+			),
+		),
+	)
+	return r
+}
+
+// defaultRenderer renders pages for the package-level ParsePage, ParseDir
+// and LoadPage.
+var defaultRenderer = NewRenderer()
+
+// rendererKey holds the *Renderer parsing the page, for the extensions that
+// look up its shortcodes and diagram renderers.
+var rendererKey = parser.NewContextKey()
+
+// shortcode returns the shortcode name, from r or the registry.
+func (r *Renderer) shortcode(name string) (ShortcodeFunc, bool) {
+	if fn, ok := r.shortcodes[name]; ok {
+		return fn, true
+	}
+	return lookupShortcode(name)
+}
+
+// diagramRenderer returns the renderer of diagrams in lang, from r or the
+// registry.
+func (r *Renderer) diagramRenderer(lang string) (DiagramRenderer, bool) {
+	if d, ok := r.diagrams[lang]; ok {
+		return d, true
+	}
+	return lookupDiagramRenderer(lang)
+}
+
+// contextRenderer returns the Renderer parsing the page of pc.
+func contextRenderer(pc parser.Context) *Renderer {
+	if r, ok := pc.Get(rendererKey).(*Renderer); ok {
+		return r
+	}
+	return defaultRenderer
+}
+
+// renderedMarkdown is the output of Renderer.render.
+type renderedMarkdown struct {
+	HTML      string
+	Footnotes string // The footnote list, left out of HTML
+	Headings  []Heading
+	Links     []pageLink // Links to other .md files
+}
+
+// render converts markdown source to HTML and extracts headings, footnotes
+// and links to other pages. math turns on TeX math (see mathExtension).
+func (r *Renderer) render(source []byte, sourcePath string, math bool) (*renderedMarkdown, error) {
+	// Parse to AST to extract headings, rewriting links to .md files
+	var links []pageLink
+	ctx := parser.NewContext(parser.WithIDs(newHeadingIDs(r.reserved)))
+	ctx.Set(rendererKey, r)
+	ctx.Set(sourcePathKey, sourcePath)
+	ctx.Set(pageLinksKey, &links)
+	ctx.Set(mathKey, math)
+	reader := text.NewReader(source)
+	doc := r.md.Parser().Parse(reader, parser.WithContext(ctx))
+
+	if err, ok := ctx.Get(markdownErrKey).(*markdownError); ok {
+		return nil, err
+	}
+
+	out := &renderedMarkdown{
+		Headings: extractHeadings(doc, source),
+		Links:    links,
+	}
+
+	// The footnote list comes last; render it apart, for layouts to place
+	if list := doc.LastChild(); list != nil && list.Kind() == east.KindFootnoteList {
+		doc.RemoveChild(doc, list)
+		var buf bytes.Buffer
+		if err := r.md.Renderer().Render(&buf, source, list); err != nil {
+			return nil, fmt.Errorf("rendering footnotes: %w", err)
+		}
+		out.Footnotes = buf.String()
+	}
+
+	// Render to HTML
+	var buf bytes.Buffer
+	if err := r.md.Renderer().Render(&buf, source, doc); err != nil {
+		return nil, fmt.Errorf("rendering markdown: %w", err)
+	}
+	out.HTML = buf.String()
+
+	return out, nil
+}
@@ -0,0 +1,277 @@
+package content
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/frostyard/site/internal/config"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+func TestRendererConcurrentParsePage(t *testing.T) {
+	r := NewRenderer()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			input := fmt.Sprintf("---\ntitle: Page %d\n---\n\n## Setup\n\n## Setup\n\n```go\nvar n = %d\n```\n", i, i)
+			page, err := r.ParsePage([]byte(input), fmt.Sprintf("content/docs/p%d.md", i))
+			if err != nil {
+				errs <- err
+				return
+			}
+			// Heading IDs must not leak between concurrent pages
+			if len(page.Headings) != 2 || page.Headings[0].ID != "setup" || page.Headings[1].ID != "setup-1" {
+				errs <- fmt.Errorf("page %d: headings = %+v", i, page.Headings)
+				return
+			}
+			if !strings.Contains(string(page.Content), fmt.Sprintf(`<span class="mi">%d</span>`, i)) {
+				errs <- fmt.Errorf("page %d: content = %s", i, page.Content)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestRendererWithShortcode(t *testing.T) {
+	r := NewRenderer(WithShortcode("test-local", func(s Shortcode) (templ.Component, error) {
+		return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+			_, err := io.WriteString(w, "<local>"+s.Params["v"]+"</local>")
+			return err
+		}), nil
+	}))
+
+	input := "---\ntitle: A\n---\n\n{{< test-local v=\"1\" >}}\n"
+	page, err := r.ParsePage([]byte(input), "content/docs/a.md")
+	if err != nil {
+		t.Fatalf("ParsePage: %v", err)
+	}
+	if !strings.Contains(string(page.Content), "<local>1</local>") {
+		t.Errorf("content = %s", page.Content)
+	}
+
+	// Registered shortcodes still work, and the local one is not global
+	if _, err := r.ParsePage([]byte("---\ntitle: B\n---\n\n{{< test-greet name=\"a\" >}}\n"), "content/docs/b.md"); err != nil {
+		t.Errorf("registered shortcode: %v", err)
+	}
+	if _, err := ParsePage([]byte(input), "content/docs/a.md"); err == nil || !strings.Contains(err.Error(), `unknown shortcode "test-local"`) {
+		t.Errorf("default renderer error = %v, want unknown shortcode", err)
+	}
+}
+
+type upperDiagram struct{}
+
+func (upperDiagram) RenderDiagram(source []byte) (string, error) {
+	return "<svg>" + strings.ToUpper(strings.TrimSpace(string(source))) + "</svg>", nil
+}
+
+func TestRendererWithDiagramRenderer(t *testing.T) {
+	r := NewRenderer(WithDiagramRenderer("upper", upperDiagram{}))
+
+	input := "```upper\nabc\n```\n"
+	page, err := r.ParsePage([]byte(input), "content/docs/a.md")
+	if err != nil {
+		t.Fatalf("ParsePage: %v", err)
+	}
+	if !strings.Contains(string(page.Content), `<figure class="diagram diagram-upper">`+"\n<svg>ABC</svg>") {
+		t.Errorf("content = %s", page.Content)
+	}
+
+	page, err = ParsePage([]byte(input), "content/docs/a.md")
+	if err != nil {
+		t.Fatalf("ParsePage: %v", err)
+	}
+	if strings.Contains(string(page.Content), "<svg>") {
+		t.Errorf("default renderer drew the diagram: %s", page.Content)
+	}
+}
+
+func TestRendererWithDiagrams(t *testing.T) {
+	d := config.Default().Diagrams
+	d.Mermaid = config.DiagramCommand
+	r := NewRenderer(WithDiagrams(d))
+
+	input := "```mermaid\ngraph TD\n```\n\n```graphviz\ndigraph { a -> b }\n```\n"
+	if _, err := r.ParsePage([]byte(input), "content/docs/a.md"); err == nil || !strings.Contains(err.Error(), "mmdc") {
+		t.Errorf("error = %v, want mmdc to be required", err)
+	}
+
+	page, err := ParsePage([]byte(input), "content/docs/a.md")
+	if err != nil {
+		t.Fatalf("ParsePage: %v", err)
+	}
+	if !page.Mermaid || !strings.Contains(string(page.Content), "<svg") {
+		t.Errorf("default renderers did not draw the diagrams: %s", page.Content)
+	}
+}
+
+func TestRendererWithExtensions(t *testing.T) {
+	input := "Some ~text~ here.\n"
+
+	page, err := NewRenderer().ParsePage([]byte(input), "content/docs/a.md")
+	if err != nil {
+		t.Fatalf("ParsePage: %v", err)
+	}
+	if strings.Contains(string(page.Content), "<mark>") {
+		t.Fatalf("content without extension = %s", page.Content)
+	}
+
+	// A made-up extension rewriting strikethrough to highlights
+	r := NewRenderer(WithExtensions(markExtension{}))
+	page, err = r.ParsePage([]byte(input), "content/docs/a.md")
+	if err != nil {
+		t.Fatalf("ParsePage: %v", err)
+	}
+	if !strings.Contains(string(page.Content), "<mark>text</mark>") {
+		t.Errorf("content = %s", page.Content)
+	}
+}
+
+// markExtension renders GFM strikethrough as <mark>.
+type markExtension struct{}
+
+func (markExtension) Extend(m goldmark.Markdown) {
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(markRenderer{}, 50)))
+}
+
+type markRenderer struct{}
+
+func (markRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(extast.KindStrikethrough, func(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering {
+			_, _ = w.WriteString("<mark>")
+		} else {
+			_, _ = w.WriteString("</mark>")
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// upperHeadings upper-cases the text of level 2 headings.
+type upperHeadings struct{}
+
+func (upperHeadings) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if h, ok := n.(*ast.Heading); ok && entering && h.Level == 2 {
+			for c := h.FirstChild(); c != nil; c = c.NextSibling() {
+				if t, ok := c.(*ast.Text); ok {
+					h.ReplaceChild(h, t, ast.NewString([]byte(strings.ToUpper(string(t.Segment.Value(reader.Source()))))))
+				}
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+func TestRendererWithASTTransformers(t *testing.T) {
+	r := NewRenderer(WithASTTransformers(util.Prioritized(upperHeadings{}, 1000)))
+
+	page, err := r.ParsePage([]byte("## Install\n"), "content/docs/a.md")
+	if err != nil {
+		t.Fatalf("ParsePage: %v", err)
+	}
+	if len(page.Headings) != 1 || page.Headings[0].Text != "INSTALL" || page.Headings[0].ID != "install" {
+		t.Errorf("headings = %+v", page.Headings)
+	}
+}
+
+func TestRendererWithReservedIDs(t *testing.T) {
+	r := NewRenderer(WithReservedIDs("toc"))
+
+	page, err := r.ParsePage([]byte("## TOC\n\n## Search\n\n## More {#toc}\n"), "content/docs/a.md")
+	if err != nil {
+		t.Fatalf("ParsePage: %v", err)
+	}
+	var ids []string
+	for _, h := range page.Headings {
+		ids = append(ids, h.ID)
+	}
+	if got, want := strings.Join(ids, " "), "toc-1 search-1 toc-2"; got != want {
+		t.Errorf("heading IDs = %q, want %q", got, want)
+	}
+}
+
+func TestRendererMarkdownSyntax(t *testing.T) {
+	input := `---
+title: "Syntax"
+---
+
+## What's new
+
+Run ` + "`nbc update --force`" + ` -- or "wait"... The first note.[^a]
+
+Term
+: Its definition.
+
+[^a]: Notes go after the page.
+`
+	page, err := NewRenderer().ParsePage([]byte(input), "content/docs/a.md")
+	if err != nil {
+		t.Fatalf("ParsePage: %v", err)
+	}
+	html := string(page.Content)
+
+	for _, want := range []string{
+		`<code>nbc update --force</code> -- or &ldquo;wait&rdquo;&hellip; The first note.<sup id="fnref:1"><a href="#fn:1" class="footnote-ref" role="doc-noteref">1</a></sup>`,
+		"<dl>\n<dt>Term</dt>\n<dd>Its definition.</dd>\n</dl>",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %q in:\n%s", want, html)
+		}
+	}
+	if strings.Contains(html, "footnotes") {
+		t.Errorf("footnote list left in content:\n%s", html)
+	}
+	if !strings.Contains(string(page.Footnotes), `<li id="fn:1">`+"\n"+`<p>Notes go after the page.&#160;<a href="#fnref:1" class="footnote-backref" role="doc-backlink">`) {
+		t.Errorf("footnotes = %s", page.Footnotes)
+	}
+	if got := page.ContentWithFootnotes(); got != page.Content+page.Footnotes {
+		t.Errorf("ContentWithFootnotes = %s", got)
+	}
+	if len(page.Headings) != 1 || page.Headings[0].Text != "What’s new" {
+		t.Errorf("headings = %+v", page.Headings)
+	}
+}
+
+func TestRendererWithMarkdown(t *testing.T) {
+	input := "Say \"hi\".[^1]\n{.lead}\n\nTerm\n: Definition.\n\nThe ESP.\n\n*[ESP]: EFI System Partition\n\n[^1]: Note.\n"
+
+	r := NewRenderer(WithMarkdown(config.Markdown{}))
+	page, err := r.ParsePage([]byte(input), "content/docs/a.md")
+	if err != nil {
+		t.Fatalf("ParsePage: %v", err)
+	}
+	html := string(page.Content)
+	for _, want := range []string{
+		"<p>Say &quot;hi&quot;.",
+		"{.lead}</p>",
+		"<p>Term\n: Definition.</p>",
+		"<p>The ESP.</p>",
+		"<p>*[ESP]: EFI System Partition</p>",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %q in:\n%s", want, html)
+		}
+	}
+	if page.Footnotes != "" {
+		t.Errorf("footnotes = %s", page.Footnotes)
+	}
+}
@@ -0,0 +1,273 @@
+package content
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/a-h/templ"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Shortcode is one use of a shortcode in markdown, such as
+// {{< image-pull name="snow" >}}.
+type Shortcode struct {
+	Name   string
+	Params map[string]string
+	Inner  string // Raw text between the opening and closing tag, if any
+}
+
+// RequiredParam returns the value of the parameter key, or an error if the
+// shortcode does not set it.
+func (s Shortcode) RequiredParam(key string) (string, error) {
+	value, ok := s.Params[key]
+	if !ok || value == "" {
+		return "", fmt.Errorf("missing parameter %q", key)
+	}
+	return value, nil
+}
+
+// ShortcodeFunc returns the component a shortcode renders as. Errors are
+// reported with the file and line of the shortcode.
+type ShortcodeFunc func(s Shortcode) (templ.Component, error)
+
+var (
+	shortcodesMu sync.RWMutex
+	shortcodes   = map[string]ShortcodeFunc{}
+)
+
+// RegisterShortcode makes the shortcode name available to markdown pages.
+// Registering a name again replaces the earlier shortcode.
+func RegisterShortcode(name string, fn ShortcodeFunc) {
+	shortcodesMu.Lock()
+	defer shortcodesMu.Unlock()
+	shortcodes[name] = fn
+}
+
+func lookupShortcode(name string) (ShortcodeFunc, bool) {
+	shortcodesMu.RLock()
+	defer shortcodesMu.RUnlock()
+	fn, ok := shortcodes[name]
+	return fn, ok
+}
+
+// kindShortcode is the AST node kind of shortcodes.
+var kindShortcode = ast.NewNodeKind("Shortcode")
+
+// shortcodeNode is a shortcode on its own line, with the component it
+// renders as.
+type shortcodeNode struct {
+	ast.BaseBlock
+	Shortcode Shortcode
+	Component templ.Component
+	fn        ShortcodeFunc // Builds Component once the inner content is read
+	line      int           // Line of the opening tag in the markdown body
+	open      bool          // Whether the closing tag is still to come
+}
+
+func (n *shortcodeNode) Kind() ast.NodeKind { return kindShortcode }
+
+func (n *shortcodeNode) IsRaw() bool { return true }
+
+func (n *shortcodeNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Name": n.Shortcode.Name}, nil)
+}
+
+// shortcodeExtension adds shortcodes to goldmark. A shortcode stands on its
+// own line, and may wrap inner content up to a closing tag. Shortcodes within
+// other text are reported as errors:
+//
+//	{{< image-pull name="snow" >}}
+//
+//	{{< name key="value" >}}
+//	Inner content
+//	{{< /name >}}
+type shortcodeExtension struct{}
+
+func (shortcodeExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(shortcodeParser{}, 740)),
+		parser.WithASTTransformers(util.Prioritized(inlineShortcodeTransformer{}, 700)),
+	)
+	m.Renderer().AddOptions(
+		renderer.WithNodeRenderers(util.Prioritized(shortcodeRenderer{}, 100)),
+	)
+}
+
+var (
+	shortcodeOpenRE  = regexp.MustCompile(`^\{\{<\s*([A-Za-z][\w-]*)((?:\s+[A-Za-z][\w-]*=(?:"[^"]*"|[^\s">]+))*)\s*>\}\}\s*$`)
+	shortcodeParamRE = regexp.MustCompile(`([A-Za-z][\w-]*)=(?:"([^"]*)"|([^\s">]+))`)
+	shortcodeCloseRE = regexp.MustCompile(`^\s*\{\{<\s*/([A-Za-z][\w-]*)\s*>\}\}\s*$`)
+	shortcodeTagRE   = regexp.MustCompile(`(?m)^[ \t]*\{\{<\s*(/?)([A-Za-z][\w-]*)(?:\s|>)`)
+)
+
+// shortcodeTagsKey holds the shortcodeTags of the page being parsed.
+var shortcodeTagsKey = parser.NewContextKey()
+
+// shortcodeTag is a line starting with an opening or closing shortcode tag.
+type shortcodeTag struct {
+	pos     int
+	closing bool
+}
+
+// shortcodeTags returns the tags at the start of lines in source by name, in
+// source order. The source is scanned once per page.
+func shortcodeTags(source []byte, pc parser.Context) map[string][]shortcodeTag {
+	if tags, ok := pc.Get(shortcodeTagsKey).(map[string][]shortcodeTag); ok {
+		return tags
+	}
+	tags := map[string][]shortcodeTag{}
+	for _, m := range shortcodeTagRE.FindAllSubmatchIndex(source, -1) {
+		name := string(source[m[4]:m[5]])
+		tags[name] = append(tags[name], shortcodeTag{pos: m[0], closing: m[3] > m[2]})
+	}
+	pc.Set(shortcodeTagsKey, tags)
+	return tags
+}
+
+// shortcodeParser parses shortcodes and their inner content.
+type shortcodeParser struct{}
+
+func (shortcodeParser) Trigger() []byte { return []byte{'{'} }
+
+func (shortcodeParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	m := shortcodeOpenRE.FindSubmatch(line)
+	if m == nil {
+		return nil, parser.NoChildren
+	}
+	lineNum := bytes.Count(reader.Source()[:segment.Start], []byte("\n")) + 1
+
+	sc := Shortcode{Name: string(m[1]), Params: map[string]string{}}
+	for _, p := range shortcodeParamRE.FindAllSubmatch(m[2], -1) {
+		value := p[2]
+		if value == nil {
+			value = p[3]
+		}
+		sc.Params[string(p[1])] = string(value)
+	}
+
+	node := &shortcodeNode{Shortcode: sc, line: lineNum}
+	reader.Advance(segment.Len() - util.TrimRightSpaceLength(line))
+
+	// The shortcode has inner content if its closing tag comes before the
+	// next use of the same shortcode
+	tags := shortcodeTags(reader.Source(), pc)[sc.Name]
+	i := sort.Search(len(tags), func(i int) bool { return tags[i].pos >= segment.Stop })
+	node.open = i < len(tags) && tags[i].closing
+
+	fn, ok := contextRenderer(pc).shortcode(sc.Name)
+	if !ok {
+		setMarkdownError(pc, lineNum, fmt.Sprintf("unknown shortcode %q", sc.Name))
+		return node, parser.NoChildren
+	}
+	node.fn = fn
+	return node, parser.NoChildren
+}
+
+func (shortcodeParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	n := node.(*shortcodeNode)
+	if !n.open {
+		return parser.Close
+	}
+	line, segment := reader.PeekLine()
+	if line == nil {
+		return parser.Close
+	}
+	if m := shortcodeCloseRE.FindSubmatch(line); m != nil && string(m[1]) == n.Shortcode.Name {
+		reader.Advance(segment.Len() - util.TrimRightSpaceLength(line))
+		n.open = false
+		return parser.Close
+	}
+	n.Lines().Append(segment)
+	reader.AdvanceToEOL()
+	return parser.Continue | parser.NoChildren
+}
+
+func (shortcodeParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {
+	n := node.(*shortcodeNode)
+	if n.fn == nil {
+		return
+	}
+
+	var inner strings.Builder
+	for i := 0; i < n.Lines().Len(); i++ {
+		segment := n.Lines().At(i)
+		inner.Write(segment.Value(reader.Source()))
+	}
+	n.Shortcode.Inner = inner.String()
+
+	component, err := n.fn(n.Shortcode)
+	if err != nil {
+		setMarkdownError(pc, n.line, fmt.Sprintf("shortcode %q: %v", n.Shortcode.Name, err))
+		return
+	}
+	n.Component = component
+}
+
+func (shortcodeParser) CanInterruptParagraph() bool { return true }
+
+func (shortcodeParser) CanAcceptIndentedLine() bool { return false }
+
+// inlineShortcodeRE matches a shortcode tag within text.
+var inlineShortcodeRE = regexp.MustCompile(`\{\{<\s*/?[A-Za-z][\w-]*[\s>]`)
+
+// inlineShortcodeTransformer reports shortcodes used within a paragraph or
+// other text, which would otherwise appear on the page as literal text.
+// Code spans and code blocks may show shortcodes.
+type inlineShortcodeTransformer struct{}
+
+func (inlineShortcodeTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if _, ok := n.(*ast.CodeSpan); ok {
+			return ast.WalkSkipChildren, nil
+		}
+		t, ok := n.(*ast.Text)
+		if !ok {
+			return ast.WalkContinue, nil
+		}
+		// Text stops at the '<', so look at the source following it
+		rest := source[t.Segment.Start:]
+		if end := bytes.IndexByte(rest[t.Segment.Len():], '\n'); end >= 0 {
+			rest = rest[:t.Segment.Len()+end]
+		}
+		if loc := inlineShortcodeRE.FindIndex(rest); loc != nil && loc[0] < t.Segment.Len() {
+			line := bytes.Count(source[:t.Segment.Start], []byte("\n")) + 1
+			setMarkdownError(pc, line, "shortcodes must stand on their own line")
+			return ast.WalkStop, nil
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// shortcodeRenderer renders shortcodes by rendering their component.
+type shortcodeRenderer struct{}
+
+func (shortcodeRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindShortcode, renderShortcode)
+}
+
+func renderShortcode(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*shortcodeNode)
+	if !entering || n.Component == nil {
+		return ast.WalkContinue, nil
+	}
+	if err := n.Component.Render(context.Background(), w); err != nil {
+		return ast.WalkStop, fmt.Errorf("rendering shortcode %q: %w", n.Shortcode.Name, err)
+	}
+	_ = w.WriteByte('\n')
+	return ast.WalkSkipChildren, nil
+}
@@ -0,0 +1,110 @@
+package content
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+)
+
+func init() {
+	RegisterShortcode("test-greet", func(s Shortcode) (templ.Component, error) {
+		name, err := s.RequiredParam("name")
+		if err != nil {
+			return nil, err
+		}
+		return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+			_, err := io.WriteString(w, "<greet>"+name+"|"+s.Params["style"]+"|"+s.Inner+"</greet>")
+			return err
+		}), nil
+	})
+}
+
+func TestParseShortcodes(t *testing.T) {
+	input := `---
+title: "Shortcodes"
+---
+
+{{< test-greet name="snow" style=bold >}}
+
+Between.
+
+{{< test-greet name="cayo" >}}
+Inner *text* stays raw.
+{{< /test-greet >}}
+
+{{< test-greet name="one" >}}
+
+{{< test-greet name="two" >}}
+Second.
+{{< /test-greet >}}
+
+Code may show ` + "`{{< test-greet name=\"x\" >}}`" + `.
+
+` + "```markdown\n{{< /test-greet >}}\nA {{< test-greet >}} B\n```" + `
+`
+	page, err := ParsePage([]byte(input), "content/docs/a.md")
+	if err != nil {
+		t.Fatalf("ParsePage returned error: %v", err)
+	}
+	html := string(page.Content)
+
+	for _, want := range []string{
+		"<greet>snow|bold|</greet>\n<p>Between.</p>",
+		"<greet>cayo||Inner *text* stays raw.\n</greet>",
+		"<greet>one||</greet>\n<greet>two||Second.\n</greet>",
+		`<code>{{&lt; test-greet name=&quot;x&quot; &gt;}}</code>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %q in:\n%s", want, html)
+		}
+	}
+}
+
+func TestParseShortcodeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "unknown shortcode",
+			input: "---\ntitle: A\n---\n\nText.\n\n{{< nope >}}\n",
+			want:  `line 7: unknown shortcode "nope"`,
+		},
+		{
+			name:  "missing parameter",
+			input: "---\ntitle: A\n---\n{{< test-greet >}}\n",
+			want:  `line 4: shortcode "test-greet": missing parameter "name"`,
+		},
+		{
+			name:  "inline shortcode",
+			input: "---\ntitle: A\n---\n\nText and\nmore {{< test-greet name=\"x\" >}} text.\n",
+			want:  "line 6: shortcodes must stand on their own line",
+		},
+		{
+			name:  "shortcode after text",
+			input: "---\ntitle: A\n---\n\n- {{< test-greet name=\"x\" >}} item\n",
+			want:  "line 5: shortcodes must stand on their own line",
+		},
+		{
+			name:  "inline closing tag",
+			input: "---\ntitle: A\n---\n\n# Title {{</test-greet>}}\n",
+			want:  "line 5: shortcodes must stand on their own line",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePage([]byte(tt.input), "content/docs/a.md")
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package content
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// validChangeFreqs lists the changefreq values allowed by the sitemap protocol.
+var validChangeFreqs = map[string]bool{
+	"always":  true,
+	"hourly":  true,
+	"daily":   true,
+	"weekly":  true,
+	"monthly": true,
+	"yearly":  true,
+	"never":   true,
+}
+
+// SitemapOptions holds per-page sitemap settings from the `sitemap`
+// frontmatter field. The field is either a boolean (`sitemap: false` excludes
+// the page) or a mapping:
+//
+//	sitemap:
+//	  priority: 0.8
+//	  changefreq: weekly
+type SitemapOptions struct {
+	Exclude     bool
+	Priority    float64
+	PrioritySet bool // Priority was given, so that 0.0 is kept
+	ChangeFreq  string
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, accepting a boolean or a mapping.
+func (s *SitemapOptions) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		var include bool
+		if err := value.Decode(&include); err != nil {
+			return fmt.Errorf("line %d: sitemap must be a boolean or a mapping", value.Line)
+		}
+		*s = SitemapOptions{Exclude: !include}
+		return nil
+	}
+
+	if err := checkKeys(value, "sitemap", "priority", "changefreq"); err != nil {
+		return err
+	}
+	var raw struct {
+		Priority   *float64 `yaml:"priority"`
+		ChangeFreq string   `yaml:"changefreq"`
+	}
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+	if raw.Priority != nil && (*raw.Priority < 0 || *raw.Priority > 1) {
+		return fmt.Errorf("line %d: sitemap priority must be between 0.0 and 1.0, got %v", value.Line, *raw.Priority)
+	}
+	if raw.ChangeFreq != "" && !validChangeFreqs[raw.ChangeFreq] {
+		return fmt.Errorf("line %d: invalid sitemap changefreq %q", value.Line, raw.ChangeFreq)
+	}
+
+	*s = SitemapOptions{ChangeFreq: raw.ChangeFreq}
+	if raw.Priority != nil {
+		s.Priority, s.PrioritySet = *raw.Priority, true
+	}
+	return nil
+}
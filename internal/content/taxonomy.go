@@ -0,0 +1,133 @@
+package content
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/frostyard/site/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+var slugSeparators = regexp.MustCompile(`[\s-]+`)
+
+// Slugify converts s to a URL-friendly slug: lowercase letters and digits
+// separated by single hyphens.
+func Slugify(s string) string {
+	s = strings.ToLower(s)
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == ' ' || r == '-' {
+			return r
+		}
+		return -1
+	}, s)
+	s = strings.TrimSpace(s)
+	s = slugSeparators.ReplaceAllString(s, "-")
+	return strings.Trim(s, "-")
+}
+
+// buildTaxonomies collects the terms of every configured taxonomy from the
+// pages' frontmatter and records them on each page's Terms map. Terms are
+// matched by slug, so "Release" and "release" are the same term. Terms are
+// sorted by slug and each term's pages are sorted newest first, then by title.
+func buildTaxonomies(pages []*Page, configs []config.Taxonomy) []*Taxonomy {
+	taxonomies := make([]*Taxonomy, 0, len(configs))
+
+	for _, tc := range configs {
+		tax := &Taxonomy{
+			Name:     tc.Name,
+			Title:    tc.Title,
+			Singular: tc.Singular,
+			Path:     "/" + Slugify(tc.Name) + "/",
+		}
+		termMap := make(map[string]*Term)
+
+		for _, p := range pages {
+			seen := make(map[string]bool)
+			for _, value := range termValues(p.Params[tc.Name]) {
+				slug := Slugify(value)
+				if slug == "" || seen[slug] {
+					continue
+				}
+				seen[slug] = true
+
+				term, ok := termMap[slug]
+				if !ok {
+					term = &Term{
+						Name: value,
+						Slug: slug,
+						Path: tax.Path + slug + "/",
+					}
+					termMap[slug] = term
+				}
+				term.Pages = append(term.Pages, p)
+
+				if p.Terms == nil {
+					p.Terms = make(map[string][]*Term)
+				}
+				p.Terms[tc.Name] = append(p.Terms[tc.Name], term)
+			}
+		}
+
+		for _, term := range termMap {
+			sort.Slice(term.Pages, func(i, j int) bool {
+				a, b := term.Pages[i], term.Pages[j]
+				if !a.ParsedDate.Equal(b.ParsedDate) {
+					return a.ParsedDate.After(b.ParsedDate)
+				}
+				return a.Title < b.Title
+			})
+			tax.Terms = append(tax.Terms, term)
+		}
+		sort.Slice(tax.Terms, func(i, j int) bool {
+			return tax.Terms[i].Slug < tax.Terms[j].Slug
+		})
+
+		taxonomies = append(taxonomies, tax)
+	}
+
+	return taxonomies
+}
+
+// TermNames holds the terms of a taxonomy field such as tags, written in
+// frontmatter as a single value or a list.
+type TermNames []string
+
+// UnmarshalYAML implements yaml.Unmarshaler, accepting what termValues does.
+func (t *TermNames) UnmarshalYAML(value *yaml.Node) error {
+	var v any
+	if err := value.Decode(&v); err != nil {
+		return err
+	}
+	*t = termValues(v)
+	return nil
+}
+
+// termValues converts a frontmatter value into taxonomy term names. A single
+// scalar becomes one term and a list contributes each of its scalar items.
+func termValues(v any) []string {
+	var values []string
+
+	add := func(item any) {
+		switch item := item.(type) {
+		case nil, map[string]any, []any:
+			// Nested structures are not valid terms
+		default:
+			if s := strings.TrimSpace(fmt.Sprint(item)); s != "" {
+				values = append(values, s)
+			}
+		}
+	}
+
+	if list, ok := v.([]any); ok {
+		for _, item := range list {
+			add(item)
+		}
+	} else {
+		add(v)
+	}
+
+	return values
+}
@@ -0,0 +1,104 @@
+package content
+
+import (
+	"fmt"
+
+	"github.com/frostyard/site/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// TOCOptions holds per-page table of contents settings from the `toc`
+// frontmatter field. The field is either a boolean (`toc: false` hides the
+// table of contents) or a mapping that overrides the site-wide levels:
+//
+//	toc:
+//	  minLevel: 2
+//	  maxLevel: 4
+type TOCOptions struct {
+	Hide     bool
+	MinLevel int // 0 means the site-wide level
+	MaxLevel int // 0 means the site-wide level
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, accepting a boolean or a mapping.
+func (o *TOCOptions) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		var show bool
+		if err := value.Decode(&show); err != nil {
+			return fmt.Errorf("line %d: toc must be a boolean or a mapping", value.Line)
+		}
+		*o = TOCOptions{Hide: !show}
+		return nil
+	}
+
+	if err := checkKeys(value, "toc", "minLevel", "maxLevel"); err != nil {
+		return err
+	}
+	var raw struct {
+		MinLevel int `yaml:"minLevel"`
+		MaxLevel int `yaml:"maxLevel"`
+	}
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+	for _, level := range []int{raw.MinLevel, raw.MaxLevel} {
+		if level < 0 || level > 6 {
+			return fmt.Errorf("line %d: toc levels must be between 1 and 6, got %d", value.Line, level)
+		}
+	}
+	if raw.MinLevel > 0 && raw.MaxLevel > 0 && raw.MinLevel > raw.MaxLevel {
+		return fmt.Errorf("line %d: toc minLevel %d is greater than maxLevel %d", value.Line, raw.MinLevel, raw.MaxLevel)
+	}
+
+	*o = TOCOptions{MinLevel: raw.MinLevel, MaxLevel: raw.MaxLevel}
+	return nil
+}
+
+// TOCEntry is a heading in a table of contents, with the headings nested
+// below it.
+type TOCEntry struct {
+	Heading
+	Children []*TOCEntry
+}
+
+// TableOfContents returns the page's headings between the configured levels,
+// nested by level. The page's toc frontmatter overrides the site-wide levels
+// in site, and `toc: false` returns nil.
+func (p *Page) TableOfContents(site config.TOC) []*TOCEntry {
+	if p.TOC.Hide {
+		return nil
+	}
+	minLevel, maxLevel := site.MinLevel, site.MaxLevel
+	if p.TOC.MinLevel > 0 {
+		minLevel = p.TOC.MinLevel
+	}
+	if p.TOC.MaxLevel > 0 {
+		maxLevel = p.TOC.MaxLevel
+	}
+	return nestHeadings(p.Headings, minLevel, max(minLevel, maxLevel))
+}
+
+// nestHeadings builds a tree from the headings between minLevel and maxLevel.
+// Each heading becomes a child of the closest preceding heading with a lower
+// level; skipped levels (an h4 right after an h2) nest one step deep.
+func nestHeadings(headings []Heading, minLevel, maxLevel int) []*TOCEntry {
+	var roots, stack []*TOCEntry
+	for _, h := range headings {
+		if h.Level < minLevel || h.Level > maxLevel || h.ID == "" {
+			continue
+		}
+		entry := &TOCEntry{Heading: h}
+
+		for len(stack) > 0 && stack[len(stack)-1].Level >= h.Level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, entry)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, entry)
+		}
+		stack = append(stack, entry)
+	}
+	return roots
+}
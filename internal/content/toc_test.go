@@ -0,0 +1,75 @@
+package content
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/frostyard/site/internal/config"
+)
+
+// tocString renders entries as "text(children)" for compact comparison.
+func tocString(entries []*TOCEntry) string {
+	parts := make([]string, 0, len(entries))
+	for _, e := range entries {
+		s := e.Text
+		if len(e.Children) > 0 {
+			s += "(" + tocString(e.Children) + ")"
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestTableOfContents(t *testing.T) {
+	page := &Page{Headings: []Heading{
+		{Level: 1, ID: "title", Text: "Title"},
+		{Level: 2, ID: "a", Text: "A"},
+		{Level: 3, ID: "a1", Text: "A1"},
+		{Level: 4, ID: "a1x", Text: "A1x"},
+		{Level: 3, ID: "a2", Text: "A2"},
+		{Level: 2, ID: "b", Text: "B"},
+		{Level: 4, ID: "b1", Text: "B1"},
+	}}
+
+	tests := []struct {
+		name string
+		site config.TOC
+		page TOCOptions
+		want string
+	}{
+		{"site levels", config.TOC{MinLevel: 2, MaxLevel: 3}, TOCOptions{}, "A(A1 A2) B"},
+		{"skipped level nests once", config.TOC{MinLevel: 2, MaxLevel: 4}, TOCOptions{}, "A(A1(A1x) A2) B(B1)"},
+		{"page overrides", config.TOC{MinLevel: 2, MaxLevel: 3}, TOCOptions{MinLevel: 1, MaxLevel: 2}, "Title(A B)"},
+		{"page minLevel above site maxLevel", config.TOC{MinLevel: 2, MaxLevel: 3}, TOCOptions{MinLevel: 4}, "A1x B1"},
+		{"hidden", config.TOC{MinLevel: 2, MaxLevel: 3}, TOCOptions{Hide: true}, ""},
+	}
+	for _, tt := range tests {
+		page.TOC = tt.page
+		if got := tocString(page.TableOfContents(tt.site)); got != tt.want {
+			t.Errorf("%s: TableOfContents = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseTOCOptions(t *testing.T) {
+	page, err := ParsePage([]byte("---\ntitle: \"A\"\ntoc:\n  maxLevel: 4\n---\n"), "content/docs/a.md")
+	if err != nil {
+		t.Fatalf("ParsePage returned error: %v", err)
+	}
+	if page.TOC != (TOCOptions{MaxLevel: 4}) {
+		t.Errorf("TOC = %+v, want MaxLevel 4", page.TOC)
+	}
+
+	page, err = ParsePage([]byte("---\ntitle: \"A\"\ntoc: false\n---\n"), "content/docs/a.md")
+	if err != nil {
+		t.Fatalf("ParsePage returned error: %v", err)
+	}
+	if !page.TOC.Hide {
+		t.Errorf("TOC = %+v, want Hide", page.TOC)
+	}
+
+	problems := LintPage([]byte("---\ntitle: \"A\"\ntoc:\n  minLevel: 4\n  maxLevel: 2\n---\n"), "content/docs/a.md", LintOptions{})
+	if len(problems) != 1 || problems[0].Line != 3 || !strings.Contains(problems[0].Message, "toc minLevel 4") {
+		t.Errorf("problems = %v, want one about toc levels on line 3", problems)
+	}
+}
@@ -0,0 +1,172 @@
+package content
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+	"gopkg.in/yaml.v3"
+)
+
+// parseTOML parses TOML frontmatter that starts on file line firstLine into a
+// YAML mapping node, so that it decodes like YAML frontmatter. go-toml decodes
+// the values; its parser then gives the line of each top-level key, which is
+// what lint reports problems on. Nodes below a top-level key carry its line.
+func parseTOML(src []byte, firstLine int) (*yaml.Node, error) {
+	var doc map[string]any
+	if err := toml.Unmarshal(src, &doc); err != nil {
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			// Syntax errors carry their position
+			row, _ := decodeErr.Position()
+			return nil, &frontmatterError{Line: firstLine + row - 1, Msg: decodeErr.Error()}
+		}
+		return nil, &frontmatterError{Line: firstLine + tomlErrorLine(src) - 1, Msg: err.Error()}
+	}
+
+	lines := tomlKeyLines(src)
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	// Keep the order of the file, which is the order of the lines
+	slices.SortFunc(keys, func(a, b string) int {
+		if lines[a] != lines[b] {
+			return lines[a] - lines[b]
+		}
+		return strings.Compare(a, b)
+	})
+
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: firstLine}
+	for _, key := range keys {
+		line := firstLine + lines[key] - 1
+		root.Content = append(root.Content, stringNode(key, line), tomlNode(doc[key], line))
+	}
+	return root, nil
+}
+
+// tomlKeyLines returns the line within src, counting from 1, on which each
+// top-level key first appears, either on the left of a key/value pair or as
+// the first part of a [table] or [[array of tables]] header. src must be valid
+// TOML.
+func tomlKeyLines(src []byte) map[string]int {
+	lines := make(map[string]int)
+	p := &unstable.Parser{}
+	p.Reset(src)
+	inTable := false
+	for p.NextExpression() {
+		expr := p.Expression()
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			inTable = true
+		case unstable.KeyValue:
+			if inTable {
+				continue
+			}
+		default:
+			continue
+		}
+		it := expr.Key()
+		if !it.Next() {
+			continue
+		}
+		key := it.Node()
+		if _, ok := lines[string(key.Data)]; !ok {
+			lines[string(key.Data)] = p.Shape(key.Raw).Start.Line
+		}
+	}
+	return lines
+}
+
+// tomlErrorLine returns the line within src, counting from 1, of an error
+// that go-toml reports without a position: a key or table defined twice,
+// which its decoder finds only after parsing. Decoding src up to the start
+// of an expression fails exactly when an earlier expression is at fault, so
+// a binary search over the expressions finds the first bad one in a few
+// decodes.
+func tomlErrorLine(src []byte) int {
+	// Offsets of the lines on which expressions start, then the end of src
+	var starts []int
+	p := &unstable.Parser{}
+	p.Reset(src)
+	for p.NextExpression() {
+		it := p.Expression().Key()
+		if !it.Next() {
+			continue
+		}
+		offset := p.Shape(it.Node().Raw).Start.Offset
+		starts = append(starts, bytes.LastIndexByte(src[:offset], '\n')+1)
+	}
+	starts = append(starts, len(src))
+
+	bad := sort.Search(len(starts)-1, func(i int) bool {
+		var doc map[string]any
+		return toml.Unmarshal(src[:starts[i+1]], &doc) != nil
+	})
+	if bad == len(starts)-1 {
+		return 1
+	}
+	return bytes.Count(src[:starts[bad]], []byte("\n")) + 1
+}
+
+// tomlNode converts a value decoded by go-toml to a node with the equivalent
+// YAML tag and value, on line.
+func tomlNode(v any, line int) *yaml.Node {
+	scalar := func(tag, value string) *yaml.Node {
+		return scalarNode(tag, value, line)
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: line}
+		for _, key := range keys {
+			node.Content = append(node.Content, stringNode(key, line), tomlNode(v[key], line))
+		}
+		return node
+	case []any:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: line}
+		for _, item := range v {
+			node.Content = append(node.Content, tomlNode(item, line))
+		}
+		return node
+	case string:
+		return stringNode(v, line)
+	case bool:
+		return scalar("!!bool", strconv.FormatBool(v))
+	case int64:
+		return scalar("!!int", strconv.FormatInt(v, 10))
+	case float64:
+		switch {
+		case math.IsInf(v, 1):
+			return scalar("!!float", ".inf")
+		case math.IsInf(v, -1):
+			return scalar("!!float", "-.inf")
+		case math.IsNaN(v):
+			return scalar("!!float", ".nan")
+		}
+		return scalar("!!float", strconv.FormatFloat(v, 'g', -1, 64))
+	case time.Time:
+		return scalar("!!timestamp", v.Format(time.RFC3339Nano))
+	case toml.LocalDate:
+		return scalar("!!timestamp", v.String())
+	case toml.LocalDateTime:
+		// Written the way YAML's timestamp resolution accepts
+		return scalar("!!timestamp", v.LocalDate.String()+" "+v.LocalTime.String())
+	case toml.LocalTime:
+		// A local time has no YAML equivalent
+		return stringNode(v.String(), line)
+	}
+	return scalar("!!null", "")
+}
@@ -0,0 +1,259 @@
+package content
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// decodeTOML parses src and decodes it the way frontmatter is decoded.
+func decodeTOML(src string) (map[string]any, error) {
+	node, err := parseTOML([]byte(src), 1)
+	if err != nil {
+		return nil, err
+	}
+	var got map[string]any
+	if err := node.Decode(&got); err != nil {
+		return nil, err
+	}
+	return got, nil
+}
+
+// The cases below follow the examples of the TOML 1.0 spec
+// (https://toml.io/en/v1.0.0).
+func TestParseTOMLSpec(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want map[string]any
+	}{
+		{
+			name: "comments",
+			src:  "# full line\nkey = \"value\"  # end of line\nanother = \"# not a comment\"\n",
+			want: map[string]any{"key": "value", "another": "# not a comment"},
+		},
+		{
+			name: "keys",
+			src:  "bare_key = 1\nbare-key = 2\n1234 = 3\n\"127.0.0.1\" = 4\n\"ʎǝʞ\" = 5\n'quoted \"value\"' = 6\n\"\" = 7\n",
+			want: map[string]any{"bare_key": 1, "bare-key": 2, "1234": 3, "127.0.0.1": 4, "ʎǝʞ": 5, `quoted "value"`: 6, "": 7},
+		},
+		{
+			name: "dotted keys",
+			src:  "name = \"Orange\"\nphysical.color = \"orange\"\nphysical . shape = \"round\"\nsite.\"google.com\" = true\n",
+			want: map[string]any{
+				"name":     "Orange",
+				"physical": map[string]any{"color": "orange", "shape": "round"},
+				"site":     map[string]any{"google.com": true},
+			},
+		},
+		{
+			name: "dotted keys out of order",
+			src:  "apple.type = \"fruit\"\norange.type = \"fruit\"\napple.skin = \"thin\"\n",
+			want: map[string]any{
+				"apple":  map[string]any{"type": "fruit", "skin": "thin"},
+				"orange": map[string]any{"type": "fruit"},
+			},
+		},
+		{
+			name: "basic string escapes",
+			src:  `str = "I'm a string. \"You can quote me\". Name\tJos\u00E9\nLocation\tSF. \U0001F600 \b\f\r\\"` + "\n",
+			want: map[string]any{"str": "I'm a string. \"You can quote me\". Name\tJos\u00e9\nLocation\tSF. \U0001F600 \b\f\r\\"},
+		},
+		{
+			name: "multi-line basic strings",
+			src:  "str1 = \"\"\"\nRoses are red\nViolets are blue\"\"\"\nstr2 = \"\"\"\\\n  The quick brown \\\n\n\n  fox.\\\n  \"\"\"\nstr3 = \"\"\"Here are two quotation marks: \"\". Simple enough.\"\"\"\nstr4 = \"\"\"\"This,\" she said, \"is just a pointless statement.\"\"\"\"\n",
+			want: map[string]any{
+				"str1": "Roses are red\nViolets are blue",
+				"str2": "The quick brown fox.",
+				"str3": `Here are two quotation marks: "". Simple enough.`,
+				"str4": `"This," she said, "is just a pointless statement."`,
+			},
+		},
+		{
+			name: "literal strings",
+			src:  "winpath = 'C:\\Users\\nodejs\\templates'\nquoted = 'Tom \"Dubs\" Preston-Werner'\nregex = '<\\i\\c*\\s*>'\nlines = '''\nThe first newline is\ntrimmed in raw strings.\n'''\nquot15 = '''Here are fifteen quotation marks: \"\"\"\"\"\"\"\"\"\"\"\"\"\"\"'''\napos15 = \"Here are fifteen apostrophes: '''''''''''''''\"\nstr = ''''That,' she said, 'is still pointless.''''\n",
+			want: map[string]any{
+				"winpath": `C:\Users\nodejs\templates`,
+				"quoted":  `Tom "Dubs" Preston-Werner`,
+				"regex":   `<\i\c*\s*>`,
+				"lines":   "The first newline is\ntrimmed in raw strings.\n",
+				"quot15":  `Here are fifteen quotation marks: """""""""""""""`,
+				"apos15":  "Here are fifteen apostrophes: '''''''''''''''",
+				"str":     `'That,' she said, 'is still pointless.'`,
+			},
+		},
+		{
+			name: "integers",
+			src:  "int1 = +99\nint2 = 42\nint3 = 0\nint4 = -17\nint5 = 1_000\nint6 = 5_349_221\nhex1 = 0xDEADBEEF\nhex2 = 0xdead_beef\noct1 = 0o01234567\noct2 = 0o755\nbin1 = 0b11010110\n",
+			want: map[string]any{
+				"int1": 99, "int2": 42, "int3": 0, "int4": -17, "int5": 1000, "int6": 5349221,
+				"hex1": 3735928559, "hex2": 3735928559, "oct1": 342391, "oct2": 493, "bin1": 214,
+			},
+		},
+		{
+			name: "floats",
+			src:  "flt1 = +1.0\nflt2 = 3.1415\nflt3 = -0.01\nflt4 = 5e+22\nflt5 = 1e06\nflt6 = -2E-2\nflt7 = 6.626e-34\nflt8 = 224_617.445_991_228\nsf1 = inf\nsf2 = -inf\n",
+			want: map[string]any{
+				"flt1": 1.0, "flt2": 3.1415, "flt3": -0.01, "flt4": 5e+22, "flt5": 1e06, "flt6": -2e-2,
+				"flt7": 6.626e-34, "flt8": 224617.445991228, "sf1": math.Inf(1), "sf2": math.Inf(-1),
+			},
+		},
+		{
+			name: "booleans and dates",
+			src:  "t = true\nf = false\nodt1 = 1979-05-27T07:32:00Z\nodt2 = 1979-05-27T00:32:00-07:00\nodt3 = 1979-05-27T00:32:00.999999-07:00\nodt4 = 1979-05-27 07:32:00Z\nldt1 = 1979-05-27T07:32:00\nld1 = 1979-05-27\nlt1 = 07:32:00\n",
+			want: map[string]any{
+				"t":    true,
+				"f":    false,
+				"odt1": time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC),
+				"odt2": time.Date(1979, 5, 27, 0, 32, 0, 0, time.FixedZone("", -7*3600)),
+				"odt3": time.Date(1979, 5, 27, 0, 32, 0, 999999000, time.FixedZone("", -7*3600)),
+				"odt4": time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC),
+				"ldt1": time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC),
+				"ld1":  time.Date(1979, 5, 27, 0, 0, 0, 0, time.UTC),
+				"lt1":  "07:32:00",
+			},
+		},
+		{
+			name: "arrays",
+			src:  "integers = [ 1, 2, 3 ]\ncolors = [ \"red\", \"yellow\", \"green\" ]\nnested = [ [ 1, 2 ], [\"a\", 'b'] ]\nmixed = [ 0.1, 1, \"x\" ]\ncontributors = [\n  \"Foo Bar <foo@example.com>\", # a comment\n  { name = \"Baz Qux\", url = \"https://example.com/bazqux\" },\n]\nempty = []\n",
+			want: map[string]any{
+				"integers": []any{1, 2, 3},
+				"colors":   []any{"red", "yellow", "green"},
+				"nested":   []any{[]any{1, 2}, []any{"a", "b"}},
+				"mixed":    []any{0.1, 1, "x"},
+				"contributors": []any{
+					"Foo Bar <foo@example.com>",
+					map[string]any{"name": "Baz Qux", "url": "https://example.com/bazqux"},
+				},
+				"empty": []any{},
+			},
+		},
+		{
+			name: "tables",
+			src:  "[table-1]\nkey1 = \"some string\"\n\n[dog.\"tater.man\"]\ntype.name = \"pug\"\n\n[ j . \"ʞ\" . 'l' ]\nx = 1\n",
+			want: map[string]any{
+				"table-1": map[string]any{"key1": "some string"},
+				"dog":     map[string]any{"tater.man": map[string]any{"type": map[string]any{"name": "pug"}}},
+				"j":       map[string]any{"ʞ": map[string]any{"l": map[string]any{"x": 1}}},
+			},
+		},
+		{
+			name: "super-tables defined after sub-tables",
+			src:  "[x.y.z.w]\na = 1\n[x]\nb = 2\n",
+			want: map[string]any{"x": map[string]any{"b": 2, "y": map[string]any{"z": map[string]any{"w": map[string]any{"a": 1}}}}},
+		},
+		{
+			name: "sub-tables of tables defined by dotted keys",
+			src:  "[fruit]\napple.color = \"red\"\napple.taste.sweet = true\n\n[fruit.apple.texture]\nsmooth = true\n",
+			want: map[string]any{"fruit": map[string]any{"apple": map[string]any{
+				"color":   "red",
+				"taste":   map[string]any{"sweet": true},
+				"texture": map[string]any{"smooth": true},
+			}}},
+		},
+		{
+			name: "inline tables",
+			src:  "name = { first = \"Tom\", last = \"Preston-Werner\" }\npoint = { x = 1, y = 2 }\nanimal = { type.name = \"pug\" }\nempty = {}\n",
+			want: map[string]any{
+				"name":   map[string]any{"first": "Tom", "last": "Preston-Werner"},
+				"point":  map[string]any{"x": 1, "y": 2},
+				"animal": map[string]any{"type": map[string]any{"name": "pug"}},
+				"empty":  map[string]any{},
+			},
+		},
+		{
+			name: "arrays of tables",
+			src:  "[[products]]\nname = \"Hammer\"\nsku = 738594937\n\n[[products]]  # empty table within the array\n\n[[products]]\nname = \"Nail\"\ncolor = \"gray\"\n",
+			want: map[string]any{"products": []any{
+				map[string]any{"name": "Hammer", "sku": 738594937},
+				map[string]any{},
+				map[string]any{"name": "Nail", "color": "gray"},
+			}},
+		},
+		{
+			name: "nested arrays of tables",
+			src:  "[[fruits]]\nname = \"apple\"\n\n[fruits.physical]\ncolor = \"red\"\n\n[[fruits.varieties]]\nname = \"red delicious\"\n\n[[fruits.varieties]]\nname = \"granny smith\"\n\n[[fruits]]\nname = \"banana\"\n\n[[fruits.varieties]]\nname = \"plantain\"\n",
+			want: map[string]any{"fruits": []any{
+				map[string]any{
+					"name":      "apple",
+					"physical":  map[string]any{"color": "red"},
+					"varieties": []any{map[string]any{"name": "red delicious"}, map[string]any{"name": "granny smith"}},
+				},
+				map[string]any{
+					"name":      "banana",
+					"varieties": []any{map[string]any{"name": "plantain"}},
+				},
+			}},
+		},
+		{
+			name: "CRLF line endings",
+			src:  "a = 1\r\n[t]\r\nb = \"\"\"\r\nx\"\"\"\r\n",
+			want: map[string]any{"a": 1, "t": map[string]any{"b": "x"}},
+		},
+	}
+	for _, tt := range tests {
+		got, err := decodeTOML(tt.src)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: decoded to\n%#v\nwant\n%#v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// The invalid examples of the TOML 1.0 spec, and a few more, must fail on
+// the line at fault.
+func TestParseTOMLSpecErrors(t *testing.T) {
+	tests := []struct {
+		name, src string
+		line      int
+	}{
+		{"key without value", "key = # INVALID\n", 1},
+		{"two pairs on a line", "first = \"Tom\" last = \"Preston-Werner\"\n", 1},
+		{"empty bare key", "= \"no key name\"\n", 1},
+		{"key defined twice", "name = \"Tom\"\nname = \"Pradyun\"\n", 2},
+		{"quoted key repeats a bare key", "spelling = \"favorite\"\n\"spelling\" = \"favourite\"\n", 2},
+		{"value under a scalar", "fruit.apple = 1\nfruit.apple.smooth = true\n", 2},
+		{"unknown escape", "str = \"\\x41\"\n", 1},
+		{"surrogate escape", "str = \"\\uD800\"\n", 1},
+		{"control character", "str = \"a\x01b\"\n", 1},
+		{"control character in a literal string", "str = 'a\x7fb'\n", 1},
+		{"newline in a basic string", "str = \"a\nb\"\n", 1},
+		{"leading zero", "n = 012\n", 1},
+		{"dot without digits", "n = .7\n", 1},
+		{"trailing dot", "n = 7.\n", 1},
+		{"integer overflow", "n = 9223372036854775808\n", 1},
+		{"table defined twice", "[fruit]\napple = \"red\"\n\n[fruit]\norange = \"orange\"\n", 4},
+		{"table over a dotted key table", "[fruit]\napple = \"red\"\n\n[fruit.apple]\ntexture = \"smooth\"\n", 4},
+		{"header over dotted keys", "[fruit]\napple.color = \"red\"\napple.taste.sweet = true\n\n[fruit.apple]\n", 5},
+		{"header over nested dotted keys", "[fruit]\napple.taste.sweet = true\n[fruit.apple.taste]\n", 3},
+		{"dotted keys into a header table", "[product.type]\nname = \"Nail\"\n[product]\ntype.edible = false\n", 4},
+		{"extending an inline table with dotted keys", "type = { name = \"Nail\" }\ntype.edible = false\n", 2},
+		{"extending an inline table with a header", "[product]\ntype = { name = \"Nail\" }\n\n[product.type]\nedible = false\n", 4},
+		{"header into an inline table", "a = { b = {} }\n[a.b.c]\n", 2},
+		{"inline table over lines", "point = { x = 1,\ny = 2 }\n", 1},
+		{"trailing comma in an inline table", "point = { x = 1, }\n", 1},
+		{"appending to a static array", "fruits = []\n\n[[fruits]]\n", 3},
+		{"table over an array of tables", "[[fruits]]\nname = \"apple\"\n\n[fruits]\n", 4},
+		{"array of tables over a table", "[fruit.physical]\ncolor = \"red\"\n\n[[fruit.physical]]\n", 4},
+		{"header into a static array", "a = [{ b = 1 }]\n[a.c]\n", 2},
+		{"unclosed header", "[a\nb = 1\n", 1},
+		{"empty header", "[]\n", 1},
+		{"unterminated multi-line string", "s = \"\"\"\nabc\n", 3},
+	}
+	for _, tt := range tests {
+		_, err := decodeTOML(tt.src)
+		var fmErr *frontmatterError
+		if !errors.As(err, &fmErr) {
+			t.Errorf("%s: error = %v, want a frontmatterError", tt.name, err)
+			continue
+		}
+		if fmErr.Line != tt.line {
+			t.Errorf("%s: error on line %d, want %d: %v", tt.name, fmErr.Line, tt.line, err)
+		}
+	}
+}
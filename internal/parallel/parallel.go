@@ -0,0 +1,47 @@
+// Package parallel runs independent pieces of work on a bounded pool of
+// goroutines.
+package parallel
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Jobs returns the number of workers to use for n: runtime.NumCPU() if n is
+// less than 1.
+func Jobs(n int) int {
+	if n < 1 {
+		return runtime.NumCPU()
+	}
+	return n
+}
+
+// Run calls fn(i) for every i in [0, n) using at most Jobs(jobs) goroutines
+// and returns when all calls have finished. Calls may run in any order, so
+// fn should store its result at index i to keep output deterministic.
+func Run(n, jobs int, fn func(i int)) {
+	workers := min(Jobs(jobs), n)
+	if workers <= 1 {
+		for i := range n {
+			fn(i)
+		}
+		return
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := range n {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
@@ -0,0 +1,34 @@
+package parallel
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	for _, jobs := range []int{0, 1, 4, 100} {
+		results := make([]int, 50)
+		var calls atomic.Int32
+		Run(len(results), jobs, func(i int) {
+			calls.Add(1)
+			results[i] = i * i
+		})
+		if calls.Load() != 50 {
+			t.Errorf("jobs=%d: fn called %d times, want 50", jobs, calls.Load())
+		}
+		for i, got := range results {
+			if got != i*i {
+				t.Errorf("jobs=%d: results[%d] = %d, want %d", jobs, i, got, i*i)
+			}
+		}
+	}
+}
+
+func TestJobs(t *testing.T) {
+	if got := Jobs(3); got != 3 {
+		t.Errorf("Jobs(3) = %d, want 3", got)
+	}
+	if got := Jobs(0); got < 1 {
+		t.Errorf("Jobs(0) = %d, want at least 1", got)
+	}
+}
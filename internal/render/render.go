@@ -3,55 +3,108 @@ package render
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"html/template"
+	"strings"
 
 	"github.com/a-h/templ"
+	"github.com/frostyard/site/internal/config"
 	"github.com/frostyard/site/internal/content"
 	"github.com/frostyard/site/templates/components"
 	"github.com/frostyard/site/templates/layouts"
 )
 
-// RenderDocsPage renders a docs page with sidebar navigation and table of contents.
-func RenderDocsPage(page *content.Page, site *content.Site) (string, error) {
+// PageMeta builds the layout metadata for a page from the site configuration.
+func PageMeta(cfg config.Config, title, description, path string) layouts.PageMeta {
 	meta := layouts.PageMeta{
-		Title:    page.Title,
-		Path:     page.Path,
-		SiteName: "Frostyard",
+		Title:           title,
+		Description:     description,
+		Path:            path,
+		SiteName:        cfg.Name,
+		SiteDescription: cfg.Description,
 	}
-	if page.Description != "" {
-		meta.Description = page.Description
+	for _, link := range cfg.Nav {
+		meta.Nav = append(meta.Nav, components.NavLink{
+			Label: link.Label,
+			Path:  link.Path,
+		})
 	}
+	meta.Feeds = feedLinks(cfg.Feed.Title, "/blog/")
+	return meta
+}
+
+// feedLinks returns discovery links for the RSS, Atom and JSON feeds
+// published alongside the listing page at path.
+func feedLinks(title, path string) []layouts.FeedLink {
+	return []layouts.FeedLink{
+		{Title: title + " (RSS)", Type: "application/rss+xml", Href: path + "feed.xml"},
+		{Title: title + " (Atom)", Type: "application/atom+xml", Href: path + "atom.xml"},
+		{Title: title + " (JSON Feed)", Type: "application/feed+json", Href: path + "feed.json"},
+	}
+}
+
+// RenderDocsPage renders a docs page with sidebar navigation and table of contents.
+func RenderDocsPage(cfg config.Config, page *content.Page, site *content.Site) (string, error) {
+	meta := PageMeta(cfg, page.Title, page.Description, page.Path)
+	meta.Draft = page.Draft
+	meta.Params = page.Params
+	meta.Mermaid = page.Mermaid
 
 	sidebar := buildSidebar(site.Sections)
-	toc := buildTOC(page.Headings)
+	toc := buildTOC(page.TableOfContents(cfg.TOC))
+	terms := pageTermLinks(page, site)
 	rawContent := templ.Raw(string(page.Content))
 
-	wrapper := layouts.Docs(meta, sidebar, toc)
+	wrapper := layouts.Docs(meta, sidebar, toc, terms, string(page.Footnotes))
 	return renderWithChildren(wrapper, rawContent)
 }
 
 // RenderBlogPost renders a blog post page.
-func RenderBlogPost(page *content.Page) (string, error) {
-	meta := layouts.PageMeta{
-		Title:    page.Title,
-		Path:     page.Path,
-		SiteName: "Frostyard",
+func RenderBlogPost(cfg config.Config, page *content.Page) (string, error) {
+	meta := PageMeta(cfg, page.Title, page.Description, page.Path)
+	meta.Draft = page.Draft
+	meta.Params = page.Params
+	meta.Mermaid = page.Mermaid
+
+	rawContent := templ.Raw(string(page.ContentWithFootnotes()))
+	wrapper := layouts.Blog(meta, termLinks(page.Terms["tags"]), postLink(page.PrevPost), postLink(page.NextPost))
+	return renderWithChildren(wrapper, rawContent)
+}
+
+// RenderBlogIndex renders one page of the paginated blog index. index is the
+// blog's _index.md page (may be nil); its body is only shown on the first page.
+func RenderBlogIndex(cfg config.Config, index *content.Page, posts []*content.Page, pager components.Pager) (string, error) {
+	title := "Blog"
+	var description string
+	var intro template.HTML
+	if index != nil {
+		if index.Title != "" {
+			title = index.Title
+		}
+		description = index.Description
+		intro = index.ContentWithFootnotes()
 	}
-	if page.Description != "" {
-		meta.Description = page.Description
+	if pager.Current > 1 {
+		title = fmt.Sprintf("%s (Page %d)", title, pager.Current)
+		intro = ""
 	}
 
-	rawContent := templ.Raw(string(page.Content))
-	wrapper := layouts.Blog(meta)
-	return renderWithChildren(wrapper, rawContent)
+	meta := PageMeta(cfg, title, description, BlogPagePath(pager.Current))
+	if index != nil {
+		meta.Params = index.Params
+	}
+	summaries := make([]components.PostSummary, 0, len(posts))
+	for _, p := range posts {
+		summaries = append(summaries, postSummary(p))
+	}
+
+	wrapper := layouts.BlogIndex(meta, summaries, pager)
+	return renderWithChildren(wrapper, templ.Raw(string(intro)))
 }
 
 // RenderLandingPage renders the home/landing page.
-func RenderLandingPage(pageContent template.HTML) (string, error) {
-	meta := layouts.PageMeta{
-		Path:     "/",
-		SiteName: "Frostyard",
-	}
+func RenderLandingPage(cfg config.Config, pageContent template.HTML) (string, error) {
+	meta := PageMeta(cfg, "", "", "/")
 
 	rawContent := templ.Raw(string(pageContent))
 	wrapper := layouts.Landing(meta)
@@ -77,6 +130,94 @@ func renderWithChildren(wrapper templ.Component, child templ.Component) (string,
 	return buf.String(), nil
 }
 
+// RenderTaxonomy renders a taxonomy's index page (e.g., /tags/) listing every term with its page count.
+func RenderTaxonomy(cfg config.Config, tax *content.Taxonomy) (string, error) {
+	meta := PageMeta(cfg, tax.Title, "", tax.Path)
+
+	links := make([]components.TagLink, 0, len(tax.Terms))
+	for _, term := range tax.Terms {
+		links = append(links, components.TagLink{
+			Name:  term.Name,
+			Path:  term.Path,
+			Count: len(term.Pages),
+		})
+	}
+
+	return RenderStaticPage(layouts.Taxonomy(meta, links))
+}
+
+// RenderTerm renders the listing page for a single taxonomy term (e.g., /tags/release/).
+func RenderTerm(cfg config.Config, tax *content.Taxonomy, term *content.Term) (string, error) {
+	title := fmt.Sprintf("%s: %s", tax.Singular, term.Name)
+	description := fmt.Sprintf("Pages with %s %q", strings.ToLower(tax.Singular), term.Name)
+	meta := PageMeta(cfg, title, description, term.Path)
+	if cfg.Feed.PerTerm {
+		meta.Feeds = append(meta.Feeds, feedLinks(cfg.Feed.Title+": "+term.Name, term.Path)...)
+	}
+
+	summaries := make([]components.PostSummary, 0, len(term.Pages))
+	for _, p := range term.Pages {
+		summaries = append(summaries, postSummary(p))
+	}
+
+	parent := components.PostLink{Title: "All " + strings.ToLower(tax.Title), Path: tax.Path}
+	return RenderStaticPage(layouts.Term(meta, parent, summaries))
+}
+
+// postSummary converts a blog post into list item data for the blog index.
+func postSummary(p *content.Page) components.PostSummary {
+	summary := components.PostSummary{
+		Title:       p.Title,
+		Path:        p.Path,
+		Author:      p.Author,
+		Description: p.Description,
+		Tags:        termLinks(p.Terms["tags"]),
+	}
+	if !p.ParsedDate.IsZero() {
+		summary.Date = p.ParsedDate.Format("January 2, 2006")
+		summary.DateISO = p.ParsedDate.Format("2006-01-02")
+	}
+	return summary
+}
+
+// termLinks converts taxonomy terms into links to their listing pages.
+func termLinks(terms []*content.Term) []components.TagLink {
+	links := make([]components.TagLink, 0, len(terms))
+	for _, term := range terms {
+		links = append(links, components.TagLink{
+			Name: term.Name,
+			Path: term.Path,
+		})
+	}
+	return links
+}
+
+// pageTermLinks returns links to every taxonomy term used by page, in taxonomy order.
+func pageTermLinks(page *content.Page, site *content.Site) []components.TagLink {
+	var links []components.TagLink
+	for _, tax := range site.Taxonomies {
+		links = append(links, termLinks(page.Terms[tax.Name])...)
+	}
+	return links
+}
+
+// postLink converts a neighbouring blog post into a navigation link, or nil if there is none.
+func postLink(p *content.Page) *components.PostLink {
+	if p == nil {
+		return nil
+	}
+	return &components.PostLink{Title: p.Title, Path: p.Path}
+}
+
+// BlogPagePath returns the URL path of the nth blog index page (1-based):
+// "/blog/" for the first page and "/blog/page/N/" for the rest.
+func BlogPagePath(n int) string {
+	if n <= 1 {
+		return "/blog/"
+	}
+	return fmt.Sprintf("/blog/page/%d/", n)
+}
+
 // buildSidebar converts the content section tree into sidebar component data.
 func buildSidebar(sections []*content.Section) []components.SidebarSection {
 	result := make([]components.SidebarSection, 0, len(sections))
@@ -108,14 +249,25 @@ func convertSection(sec *content.Section) components.SidebarSection {
 	return ss
 }
 
-// buildTOC converts content headings into TOC component data.
-func buildTOC(headings []content.Heading) []components.TOCHeading {
-	result := make([]components.TOCHeading, 0, len(headings))
-	for _, h := range headings {
+// buildTOC converts a table of contents into TOC component data. A table of
+// contents with a single heading is left out, since it does not help
+// navigation.
+func buildTOC(entries []*content.TOCEntry) []components.TOCHeading {
+	if len(entries) == 1 && len(entries[0].Children) == 0 {
+		return nil
+	}
+	return tocHeadings(entries)
+}
+
+// tocHeadings converts TOC entries and their children to component data.
+func tocHeadings(entries []*content.TOCEntry) []components.TOCHeading {
+	result := make([]components.TOCHeading, 0, len(entries))
+	for _, e := range entries {
 		result = append(result, components.TOCHeading{
-			Level: h.Level,
-			ID:    h.ID,
-			Text:  h.Text,
+			Level:    e.Level,
+			ID:       e.ID,
+			Text:     e.Text,
+			Children: tocHeadings(e.Children),
 		})
 	}
 	return result
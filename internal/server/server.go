@@ -6,12 +6,14 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/frostyard/site/internal/build"
+	"github.com/frostyard/site/internal/config"
 	"github.com/fsnotify/fsnotify"
 )
 
@@ -22,6 +24,11 @@ type Config struct {
 	OutputDir  string
 	Addr       string
 	Root       string
+	Site       config.Config
+	InMemory   bool // Render pages on request instead of writing OutputDir
+	Drafts     bool // Serve pages with draft: true
+	Future     bool // Serve pages with a publish date in the future
+	Expired    bool // Serve pages whose expiry date has passed
 }
 
 const liveReloadScript = `<script>
@@ -32,14 +39,38 @@ es.onerror = () => setTimeout(() => location.reload(), 1000);
 
 // Serve starts the development server with file watching and live reload.
 func Serve(cfg Config) error {
-	// Run initial build
 	buildCfg := build.Config{
 		ContentDir: cfg.ContentDir,
 		StaticDir:  cfg.StaticDir,
 		OutputDir:  cfg.OutputDir,
 		Root:       cfg.Root,
+		Site:       cfg.Site,
+		Drafts:     cfg.Drafts,
+		Future:     cfg.Future,
+		Expired:    cfg.Expired,
+	}
+
+	// In memory, generated CSS goes to a scratch directory so that OutputDir
+	// is left untouched
+	if cfg.InMemory {
+		scratch, err := os.MkdirTemp("", "frostyard-serve-")
+		if err != nil {
+			return fmt.Errorf("creating scratch directory: %w", err)
+		}
+		defer os.RemoveAll(scratch)
+		buildCfg.OutputDir = scratch
 	}
-	if err := build.Build(buildCfg); err != nil {
+
+	// Run initial build
+	builder := build.NewBuilder(buildCfg)
+	if cfg.InMemory {
+		if err := builder.Load(); err != nil {
+			return fmt.Errorf("initial load failed: %w", err)
+		}
+		if err := builder.GenerateCSS(); err != nil {
+			return fmt.Errorf("initial load failed: %w", err)
+		}
+	} else if err := builder.Build(); err != nil {
 		return fmt.Errorf("initial build failed: %w", err)
 	}
 
@@ -79,6 +110,39 @@ func Serve(cfg Config) error {
 		}
 	}
 
+	// Changed paths collected during the debounce window; builderMu keeps
+	// rebuilds from overlapping when one takes longer than the debounce, and
+	// from racing with requests rendered from memory.
+	var (
+		pendingMu sync.Mutex
+		pending   = make(map[string]bool)
+		builderMu sync.RWMutex
+	)
+
+	rebuild := func() {
+		pendingMu.Lock()
+		changed := make([]string, 0, len(pending))
+		for path := range pending {
+			changed = append(changed, path)
+		}
+		pending = make(map[string]bool)
+		pendingMu.Unlock()
+
+		if len(changed) == 0 {
+			return
+		}
+
+		builderMu.Lock()
+		defer builderMu.Unlock()
+
+		fmt.Println("Change detected, rebuilding...")
+		if err := update(builder, cfg, changed); err != nil {
+			fmt.Fprintf(os.Stderr, "Rebuild failed: %v\n", err)
+			return
+		}
+		notifyClients()
+	}
+
 	// Set up fsnotify watcher
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
@@ -132,18 +196,15 @@ func Serve(cfg Config) error {
 					}
 				}
 
+				pendingMu.Lock()
+				pending[event.Name] = true
+				pendingMu.Unlock()
+
 				// Debounce: reset timer on each event
 				if debounceTimer != nil {
 					debounceTimer.Stop()
 				}
-				debounceTimer = time.AfterFunc(200*time.Millisecond, func() {
-					fmt.Println("Change detected, rebuilding...")
-					if err := build.Build(buildCfg); err != nil {
-						fmt.Fprintf(os.Stderr, "Rebuild failed: %v\n", err)
-						return
-					}
-					notifyClients()
-				})
+				debounceTimer = time.AfterFunc(200*time.Millisecond, rebuild)
 
 			case err, ok := <-watcher.Errors:
 				if !ok {
@@ -193,39 +254,42 @@ func Serve(cfg Config) error {
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		urlPath := r.URL.Path
 
-		// Determine file path
-		filePath := filepath.Join(cfg.OutputDir, filepath.Clean(urlPath))
-
-		// If path is a directory, serve index.html
-		info, err := os.Stat(filePath)
-		if err == nil && info.IsDir() {
-			filePath = filepath.Join(filePath, "index.html")
-		}
-
-		// Check if file exists
-		if _, err := os.Stat(filePath); os.IsNotExist(err) {
-			http.NotFound(w, r)
-			return
-		}
-
-		// For HTML files, inject live reload script
-		if strings.HasSuffix(filePath, ".html") {
-			data, err := os.ReadFile(filePath)
+		if cfg.InMemory {
+			builderMu.RLock()
+			html, ok, err := builder.RenderPath(pagePath(urlPath))
+			builderMu.RUnlock()
 			if err != nil {
-				http.Error(w, "Error reading file", http.StatusInternalServerError)
+				http.Error(w, fmt.Sprintf("Error rendering %s: %v", urlPath, err), http.StatusInternalServerError)
+				return
+			}
+			if ok {
+				serveHTML(w, []byte(html))
 				return
 			}
 
-			// Inject live reload script before </body>
-			content := bytes.Replace(data, []byte("</body>"), []byte(liveReloadScript+"\n</body>"), 1)
+			// Feeds and the sitemap
+			builderMu.RLock()
+			data, ok, err := builder.RenderFile(urlPath)
+			builderMu.RUnlock()
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Error rendering %s: %v", urlPath, err), http.StatusInternalServerError)
+				return
+			}
+			if ok {
+				http.ServeContent(w, r, path.Base(urlPath), time.Time{}, bytes.NewReader(data))
+				return
+			}
 
-			w.Header().Set("Content-Type", "text/html; charset=utf-8")
-			io.Copy(w, bytes.NewReader(content))
-			return
+			// Static assets are served from their source, generated CSS
+			// from the scratch directory
+			if serveFile(w, r, cfg.StaticDir, urlPath) {
+				return
+			}
 		}
 
-		// For non-HTML files, serve directly
-		http.ServeFile(w, r, filePath)
+		if !serveFile(w, r, buildCfg.OutputDir, urlPath) {
+			http.NotFound(w, r)
+		}
 	})
 
 	fmt.Printf("Dev server running at http://localhost%s\n", cfg.Addr)
@@ -233,3 +297,78 @@ func Serve(cfg Config) error {
 
 	return http.ListenAndServe(cfg.Addr, mux)
 }
+
+// update brings the served site up to date with the changed paths: in memory
+// it reloads the content (and regenerates CSS when anything besides content
+// changed), otherwise it rebuilds the output directory incrementally.
+func update(builder *build.Builder, cfg Config, changed []string) error {
+	if !cfg.InMemory {
+		return builder.Rebuild(changed)
+	}
+
+	start := time.Now()
+	if err := builder.Reload(changed); err != nil {
+		return err
+	}
+	for _, path := range changed {
+		if !build.IsWithin(cfg.ContentDir, path) && !build.IsWithin(cfg.StaticDir, path) {
+			if err := builder.GenerateCSS(); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	fmt.Printf("Reloaded in %s\n", time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// serveFile serves the file for urlPath below dir, or its index.html if it
+// is a directory. It reports false if there is no such file.
+func serveFile(w http.ResponseWriter, r *http.Request, dir, urlPath string) bool {
+	filePath := filepath.Join(dir, filepath.Clean(urlPath))
+
+	// If path is a directory, serve index.html
+	info, err := os.Stat(filePath)
+	if err == nil && info.IsDir() {
+		filePath = filepath.Join(filePath, "index.html")
+	}
+
+	// Check if file exists
+	if _, err := os.Stat(filePath); err != nil {
+		return false
+	}
+
+	// For HTML files, inject live reload script
+	if strings.HasSuffix(filePath, ".html") {
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			http.Error(w, "Error reading file", http.StatusInternalServerError)
+			return true
+		}
+		serveHTML(w, data)
+		return true
+	}
+
+	// For non-HTML files, serve directly
+	http.ServeFile(w, r, filePath)
+	return true
+}
+
+// serveHTML writes an HTML page with the live reload script injected before </body>.
+func serveHTML(w http.ResponseWriter, data []byte) {
+	content := bytes.Replace(data, []byte("</body>"), []byte(liveReloadScript+"\n</body>"), 1)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	io.Copy(w, bytes.NewReader(content))
+}
+
+// pagePath normalizes a request path to the form of page paths, which end
+// in a slash (e.g., "/docs/intro" and "/docs/intro/index.html" both become
+// "/docs/intro/"). Paths of other files are returned unchanged.
+func pagePath(urlPath string) string {
+	urlPath = strings.TrimSuffix(urlPath, "index.html")
+	if path.Ext(urlPath) == "" && !strings.HasSuffix(urlPath, "/") {
+		urlPath += "/"
+	}
+	return urlPath
+}
@@ -8,6 +8,18 @@ package components
 import "github.com/a-h/templ"
 import templruntime "github.com/a-h/templ/runtime"
 
+// IconNames lists the names Icon renders; any other name renders nothing.
+var IconNames = []string{
+	"server",
+	"wrench",
+	"bolt",
+	"computer-desktop",
+	"command-line",
+	"light-bulb",
+	"cloud",
+	"puzzle-piece",
+}
+
 func Icon(name string) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
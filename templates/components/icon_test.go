@@ -0,0 +1,28 @@
+package components
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestIconNamesRender(t *testing.T) {
+	for _, name := range IconNames {
+		var b strings.Builder
+		if err := Icon(name).Render(context.Background(), &b); err != nil {
+			t.Fatalf("Icon(%q) returned error: %v", name, err)
+		}
+		svg := strings.TrimSpace(b.String())
+		if !strings.HasPrefix(svg, "<svg") || !strings.Contains(svg, "<path") || !strings.HasSuffix(svg, "</svg>") {
+			t.Errorf("Icon(%q) should render an SVG, got %q", name, svg)
+		}
+	}
+
+	var b strings.Builder
+	if err := Icon("no-such-icon").Render(context.Background(), &b); err != nil {
+		t.Fatal(err)
+	}
+	if b.Len() != 0 {
+		t.Errorf("an unknown icon should render nothing, got %q", b.String())
+	}
+}
@@ -0,0 +1,48 @@
+// Package shortcodes holds the components behind the shortcodes available
+// in markdown content, such as {{< image-pull name="snow" >}}. Importing the
+// package registers them with the content package.
+package shortcodes
+
+import (
+	"github.com/a-h/templ"
+
+	"github.com/frostyard/site/internal/content"
+)
+
+func init() {
+	content.RegisterShortcode("image-pull", imagePull)
+	content.RegisterShortcode("bootc-switch", bootcSwitch)
+}
+
+// imagePull shows the podman command that pulls a Frostyard image:
+//
+//	{{< image-pull name="snow" >}}
+//	{{< image-pull name="cayo" tag="testing" >}}
+func imagePull(s content.Shortcode) (templ.Component, error) {
+	name, err := s.RequiredParam("name")
+	if err != nil {
+		return nil, err
+	}
+	return ImagePull(name, imageTag(s)), nil
+}
+
+// bootcSwitch shows the bootc command that switches a running system to a
+// Frostyard image:
+//
+//	{{< bootc-switch name="snow" >}}
+//	{{< bootc-switch name="cayo" tag="testing" >}}
+func bootcSwitch(s content.Shortcode) (templ.Component, error) {
+	name, err := s.RequiredParam("name")
+	if err != nil {
+		return nil, err
+	}
+	return BootcSwitch(name, imageTag(s)), nil
+}
+
+// imageTag returns the tag parameter of an image shortcode, "latest" if unset.
+func imageTag(s content.Shortcode) string {
+	if tag := s.Params["tag"]; tag != "" {
+		return tag
+	}
+	return "latest"
+}